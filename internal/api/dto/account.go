@@ -0,0 +1,86 @@
+package dto
+
+import "time"
+
+// RequestAccountDeletionRequest confirms the deletion with the password, if the user has one
+type RequestAccountDeletionRequest struct {
+	Password string `json:"password" binding:"max=72"`
+}
+
+// AdminAccountDeletionRequest deletes the account immediately instead of after the grace period
+type AdminAccountDeletionRequest struct {
+	Immediate bool `json:"immediate" binding:"boolean"`
+}
+
+type AccountDeletionResponse struct {
+	IsDeleted   bool       `json:"isDeleted"`
+	ScheduledAt *time.Time `json:"scheduledAt"`
+}
+
+type CancelAccountDeletionResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+// UserDataExport is the archive of the data held about a user
+type UserDataExport struct {
+	ExportedAt           time.Time                     `json:"exportedAt"`
+	Profile              ExportProfile                 `json:"profile"`
+	Role                 ExportRole                    `json:"role"`
+	Claims               []string                      `json:"claims"`
+	Sessions             []ExportSession               `json:"sessions"`
+	Identities           []IdentityResponse            `json:"identities"`
+	Passkeys             []PasskeyResponse             `json:"passkeys"`
+	PersonalAccessTokens []PersonalAccessTokenResponse `json:"personalAccessTokens"`
+	LoginAttempts        []ExportLoginAttempt          `json:"loginAttempts"`
+	Impersonations       []ExportImpersonation         `json:"impersonations"`
+	Organizations        []UserOrganizationResponse    `json:"organizations"`
+}
+
+type ExportProfile struct {
+	ID                  uint       `json:"id"`
+	Username            string     `json:"username"`
+	Email               string     `json:"email"`
+	EmailVerified       bool       `json:"emailVerified"`
+	EmailVerifiedAt     *time.Time `json:"emailVerifiedAt"`
+	Provider            string     `json:"provider"`
+	ProfileImage        string     `json:"profileImage"`
+	HasPassword         bool       `json:"hasPassword"`
+	TwoFactorEnabled    bool       `json:"twoFactorEnabled"`
+	LockedUntil         *time.Time `json:"lockedUntil"`
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+type ExportRole struct {
+	ID     uint     `json:"id"`
+	Name   string   `json:"name"`
+	Claims []string `json:"claims"`
+}
+
+// ExportSession is a refresh token record, the token itself is not exported
+type ExportSession struct {
+	ID          uint      `json:"id"`
+	SessionID   string    `json:"sessionId"`
+	DeviceLabel string    `json:"deviceLabel"`
+	UserAgent   string    `json:"userAgent"`
+	IPAddress   string    `json:"ipAddress"`
+	IsRevoked   bool      `json:"isRevoked"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastUsedAt  time.Time `json:"lastUsedAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+type ExportLoginAttempt struct {
+	IPAddress string    `json:"ipAddress"`
+	Succeeded bool      `json:"succeeded"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ExportImpersonation is an admin acting as the user
+type ExportImpersonation struct {
+	ActorUsername string     `json:"actorUsername"`
+	Reason        string     `json:"reason"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	EndedAt       *time.Time `json:"endedAt"`
+}
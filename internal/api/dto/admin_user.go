@@ -0,0 +1,57 @@
+package dto
+
+import "time"
+
+type ListUsersQuery struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"pageSize" binding:"omitempty,min=1,max=100"`
+	Username string `form:"username" binding:"max=50"`
+	Email    string `form:"email" binding:"max=100"`
+	Role     string `form:"role" binding:"max=30"`
+	Provider string `form:"provider" binding:"max=30"`
+}
+
+type ListUsersResponse struct {
+	Items    []AdminUserResponse `json:"items"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"pageSize"`
+}
+
+type AdminUserRole struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type AdminUserResponse struct {
+	ID                    uint          `json:"id"`
+	Username              string        `json:"username"`
+	Email                 string        `json:"email"`
+	EmailVerified         bool          `json:"emailVerified"`
+	Provider              string        `json:"provider"`
+	Role                  AdminUserRole `json:"role"`
+	TwoFactorEnabled      bool          `json:"twoFactorEnabled"`
+	DisabledAt            *time.Time    `json:"disabledAt"`
+	PasswordResetRequired bool          `json:"passwordResetRequired"`
+	LockedUntil           *time.Time    `json:"lockedUntil"`
+	DeletionScheduledAt   *time.Time    `json:"deletionScheduledAt"`
+	CreatedAt             time.Time     `json:"createdAt"`
+	UpdatedAt             time.Time     `json:"updatedAt"`
+}
+
+// AdminUserDetailResponse adds the claims of the user, EffectiveClaims being the ones its tokens carry
+type AdminUserDetailResponse struct {
+	AdminUserResponse
+	RoleClaims      []string `json:"roleClaims"`
+	DirectClaims    []string `json:"directClaims"`
+	EffectiveClaims []string `json:"effectiveClaims"`
+}
+
+type SetUserRoleRequest struct {
+	RoleID uint `json:"roleId" binding:"required"`
+}
+
+type ForcePasswordResetResponse struct {
+	IsSuccess       bool  `json:"isSuccess"`
+	RevokedSessions int64 `json:"revokedSessions"`
+}
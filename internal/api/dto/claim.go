@@ -9,8 +9,9 @@ type CreateClaimResponse struct {
 	Name string `json:"name"`
 }
 
+// UpdateClaimRequest renames a claim, the ID is taken from the path
 type UpdateClaimRequest struct {
-	ID   uint   `json:"id" binding:"required"`
+	ID   uint   `json:"-"`
 	Name string `json:"name" binding:"required,min=3,max=30"`
 }
 
@@ -23,8 +24,35 @@ type DeleteClaimRequest struct {
 	ID uint `json:"id" binding:"required"`
 }
 
+// DeleteClaimResponse reports who lost the deleted claim.
+// A deleted seeded claim isn't seeded again on restart, creating a claim with its name restores it.
 type DeleteClaimResponse struct {
-	Message string `json:"message"`
+	Message string   `json:"message"`
+	Roles   []string `json:"roles"`
+	// Users held the claim directly, RoleMembers through their role
+	Users       []ClaimHolder `json:"users"`
+	RoleMembers []ClaimHolder `json:"roleMembers"`
+	// PersonalAccessTokens is the number of tokens the claim was removed from
+	PersonalAccessTokens int64 `json:"personalAccessTokens"`
+}
+
+// ClaimHolder is a user that held a deleted claim
+type ClaimHolder struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+type ListClaimsQuery struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"pageSize" binding:"omitempty,min=1,max=100"`
+	Search   string `form:"search" binding:"max=30"`
+}
+
+type ListClaimsResponse struct {
+	Items    []GetClaimsResponse `json:"items"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"pageSize"`
 }
 
 type GetClaimsResponse struct {
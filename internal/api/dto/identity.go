@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+type IdentityResponse struct {
+	ID         uint       `json:"id"`
+	Provider   string     `json:"provider"`
+	Email      string     `json:"email"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+type LinkIdentityResponse struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+}
+
+type UnlinkIdentityResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+type ImpersonateUserRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ImpersonateUserResponse contains a short lived access token of the user, no refresh token is issued
+type ImpersonateUserResponse struct {
+	ImpersonationID uint      `json:"impersonationId"`
+	AccessToken     string    `json:"accessToken"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+type ImpersonationResponse struct {
+	ID              uint       `json:"id"`
+	ActorID         uint       `json:"actorId"`
+	ActorUsername   string     `json:"actorUsername"`
+	SubjectID       uint       `json:"subjectId"`
+	SubjectUsername string     `json:"subjectUsername"`
+	Reason          string     `json:"reason"`
+	IPAddress       string     `json:"ipAddress"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	ExpiresAt       time.Time  `json:"expiresAt"`
+	EndedAt         *time.Time `json:"endedAt"`
+}
+
+type EndImpersonationResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
@@ -11,8 +11,27 @@ type GoogleUserInfo struct {
 	Locale        string `json:"locale"`
 }
 
-type GoogleAuthResponse struct {
+// OAuthUserInfo is the normalized profile returned by every OAuth provider
+type OAuthUserInfo struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+type OAuthAuthResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	IsNewUser    bool   `json:"isNewUser"`
+	MFARequired  bool   `json:"mfaRequired,omitempty"`
+	MFAToken     string `json:"mfaToken,omitempty"`
+	// TwoFactorSetupRequired marks tokens restricted until the user enables two-factor authentication
+	TwoFactorSetupRequired bool `json:"twoFactorSetupRequired,omitempty"`
+}
+
+// VerifiedEmail reports whether the provider vouches for the ownership of the email
+func (u *OAuthUserInfo) VerifiedEmail() bool {
+	return u.Email != "" && u.EmailVerified
 }
@@ -0,0 +1,89 @@
+package dto
+
+import "time"
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=50"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=50"`
+}
+
+type OrganizationRole struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type OrganizationResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UserOrganizationResponse is an organization the user is a member of, with the role of the user in it
+type UserOrganizationResponse struct {
+	OrganizationResponse
+	Role OrganizationRole `json:"role"`
+}
+
+type DeleteOrganizationResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+type ListOrganizationMembersQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+type ListOrganizationMembersResponse struct {
+	Items    []OrganizationMemberResponse `json:"items"`
+	Total    int64                        `json:"total"`
+	Page     int                          `json:"page"`
+	PageSize int                          `json:"pageSize"`
+}
+
+type OrganizationMemberResponse struct {
+	UserID   uint   `json:"userId"`
+	Username string `json:"username"`
+	// Email is only returned to the members who manage the members of the organization
+	Email    string           `json:"email,omitempty"`
+	Role     OrganizationRole `json:"role"`
+	JoinedAt time.Time        `json:"joinedAt"`
+}
+
+type InviteOrganizationMemberRequest struct {
+	Email  string `json:"email" binding:"required,email"`
+	RoleID uint   `json:"roleId" binding:"required"`
+}
+
+// OrganizationInvitationResponse is a pending invitation as seen by the organization
+type OrganizationInvitationResponse struct {
+	ID        uint             `json:"id"`
+	Email     string           `json:"email"`
+	Role      OrganizationRole `json:"role"`
+	ExpiresAt time.Time        `json:"expiresAt"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+// UserInvitationResponse is a pending invitation as seen by the invited user
+type UserInvitationResponse struct {
+	ID           uint                 `json:"id"`
+	Organization OrganizationResponse `json:"organization"`
+	Role         OrganizationRole     `json:"role"`
+	ExpiresAt    time.Time            `json:"expiresAt"`
+	CreatedAt    time.Time            `json:"createdAt"`
+}
+
+type DeleteOrganizationInvitationResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+type SetOrganizationMemberRoleRequest struct {
+	RoleID uint `json:"roleId" binding:"required"`
+}
+
+type RemoveOrganizationMemberResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BeginPasskeyResponse carries the WebAuthn options for navigator.credentials and the ceremony key
+type BeginPasskeyResponse struct {
+	SessionKey string `json:"sessionKey"`
+	Options    any    `json:"options"`
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	SessionKey string          `json:"sessionKey" binding:"required"`
+	Name       string          `json:"name" binding:"max=50"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+type FinishPasskeyLoginRequest struct {
+	SessionKey string          `json:"sessionKey" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+	Remember   bool            `json:"remember" binding:"boolean"`
+}
+
+type PasskeyResponse struct {
+	ID             uint       `json:"id"`
+	Name           string     `json:"name"`
+	BackupEligible bool       `json:"backupEligible"`
+	CloneWarning   bool       `json:"cloneWarning"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	LastUsedAt     *time.Time `json:"lastUsedAt"`
+}
+
+type DeletePasskeyResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+import "time"
+
+type CreateRoleRequest struct {
+	Name   string   `json:"name" binding:"required,min=2,max=30"`
+	Claims []string `json:"claims" binding:"omitempty,dive,required"`
+	// RequiresTwoFactor restricts members without two-factor authentication to their own account
+	RequiresTwoFactor bool `json:"requiresTwoFactor" binding:"boolean"`
+}
+
+type UpdateRoleRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=30"`
+	// RequiresTwoFactor is left unchanged when omitted
+	RequiresTwoFactor *bool `json:"requiresTwoFactor"`
+}
+
+// RoleClaimsRequest attaches or detaches claims by name
+type RoleClaimsRequest struct {
+	Claims []string `json:"claims" binding:"required,min=1,dive,required"`
+}
+
+type RoleResponse struct {
+	ID                uint      `json:"id"`
+	Name              string    `json:"name"`
+	IsDefault         bool      `json:"isDefault"`
+	RequiresTwoFactor bool      `json:"requiresTwoFactor"`
+	Claims            []string  `json:"claims"`
+	MemberCount       int64     `json:"memberCount"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+type DeleteRoleResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+	// ReassignedUsers is the number of members moved to the replacement role
+	ReassignedUsers int64 `json:"reassignedUsers"`
+	// ReassignedOrganizationMembers is the number of organization memberships moved to the replacement role
+	ReassignedOrganizationMembers int64 `json:"reassignedOrganizationMembers"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// SessionInfo describes the client a session is created or refreshed from.
+// It is filled by the handlers from the request, not bound from the body.
+type SessionInfo struct {
+	UserAgent   string
+	IPAddress   string
+	DeviceLabel string
+}
+
+type SessionResponse struct {
+	ID          string    `json:"id"`
+	DeviceLabel string    `json:"deviceLabel"`
+	UserAgent   string    `json:"userAgent"`
+	IPAddress   string    `json:"ipAddress"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastUsedAt  time.Time `json:"lastUsedAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	IsCurrent   bool      `json:"isCurrent"`
+}
+
+type RevokeSessionResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+type RevokeOtherSessionsResponse struct {
+	RevokedCount int64 `json:"revokedCount"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+// Token type hints of RFC 7662 and RFC 7009
+const (
+	TokenTypeAccessToken  = "access_token"
+	TokenTypeRefreshToken = "refresh_token"
+)
+
+// TokenRequest is the form body of the introspection and revocation endpoints
+type TokenRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
+
+// IntrospectionResponse follows RFC 7662; an inactive token only has active set to false
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	TokenType string   `json:"token_type,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	RoleID    uint     `json:"role_id,omitempty"`
+	Claims    []string `json:"claims,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	// Actor is the admin impersonating the subject
+	Actor *IntrospectionActor `json:"act,omitempty"`
+}
+
+type IntrospectionActor struct {
+	Subject  string `json:"sub"`
+	Username string `json:"username,omitempty"`
+}
+
+// OAuthErrorResponse is the error format of RFC 6749 used by the introspection and revocation endpoints
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
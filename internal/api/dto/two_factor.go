@@ -0,0 +1,27 @@
+package dto
+
+type EnrollTwoFactorResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,min=6,max=11"`
+}
+
+type ConfirmTwoFactorResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type DisableTwoFactorResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type VerifyTwoFactorLoginRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required,min=6,max=11"`
+}
@@ -1,9 +1,11 @@
 package dto
 
+import "time"
+
 type CreateUserRequest struct {
 	Username string `json:"username" binding:"required,alphanumunicode,min=3,max=30"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8,max=72"`
+	Password string `json:"password" binding:"required,max=72"`
 }
 
 type CreateUserResponse struct {
@@ -13,14 +15,21 @@ type CreateUserResponse struct {
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8,max=72"`
-	Remember bool   `json:"remember" binding:"boolean"`
+	Email       string `json:"email" binding:"required,email"`
+	Password    string `json:"password" binding:"required,max=72"`
+	Remember    bool   `json:"remember" binding:"boolean"`
+	DeviceLabel string `json:"deviceLabel" binding:"max=100"`
 }
 
+// LoginResponse carries either the tokens or, for two-factor accounts, the MFA challenge token
 type LoginResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	MFARequired  bool   `json:"mfaRequired"`
+	MFAToken     string `json:"mfaToken,omitempty"`
+	// TwoFactorSetupRequired is set when the role requires two-factor authentication the user hasn't enabled,
+	// the tokens are restricted to the own account until it is
+	TwoFactorSetupRequired bool `json:"twoFactorSetupRequired,omitempty"`
 }
 
 type RefreshRequest struct {
@@ -28,7 +37,8 @@ type RefreshRequest struct {
 }
 
 type RefreshResponse struct {
-	AccessToken string `json:"refreshToken"`
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
 }
 
 type RequestPasswordResetRequest struct {
@@ -47,11 +57,91 @@ type LogoutResponse struct {
 	IsSuccess bool `json:"isSuccess"`
 }
 
+// SetClaimsRequest replaces the direct claims of a user, the user ID is taken from the path
 type SetClaimsRequest struct {
-	UserID   uint   `json:"user_id" binding:"required"`
+	UserID   uint   `json:"-"`
 	ClaimIDs []uint `json:"claim_ids" binding:"required"`
 }
 
 type SetClaimsResponse struct {
 	Message string `json:"message"`
 }
+
+type ValidatePasswordResetTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ValidatePasswordResetTokenResponse struct {
+	IsValid bool `json:"isValid"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,max=72"`
+}
+
+type ResetPasswordResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+// ChangePasswordRequest changes the password of the authenticated user.
+// CurrentPassword is not required when the user has no password yet, e.g. OAuth-only accounts.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"max=72"`
+	NewPassword     string `json:"newPassword" binding:"required,max=72"`
+}
+
+type ChangePasswordResponse struct {
+	IsSuccess       bool  `json:"isSuccess"`
+	RevokedSessions int64 `json:"revokedSessions"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type VerifyEmailResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+type RequestMagicLinkRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Remember bool   `json:"remember" binding:"boolean"`
+}
+
+type RequestMagicLinkResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+type VerifyMagicLinkRequest struct {
+	Token       string `json:"token" binding:"required"`
+	DeviceLabel string `json:"deviceLabel" binding:"max=100"`
+}
+
+type UnlockAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type UnlockAccountResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+// LockoutResponse is an account locked after too many failed logins
+type LockoutResponse struct {
+	UserID      uint      `json:"userId"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+type UnlockLockoutResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+type ResendVerificationEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResendVerificationEmailResponse struct {
+	IsSuccess bool `json:"isSuccess"`
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/api/validation"
+	"knowstack/internal/core/services"
+	"knowstack/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Export my data
+// @Description Downloads a machine-readable archive of the data held about the authenticated user
+// @Tags API User
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.UserDataExport
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /users/me/export [get]
+func (h *UserHandler) ExportData(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	h.writeDataExport(c, userID)
+}
+
+// @Summary Request the deletion of my account
+// @Description Schedules the deletion of the authenticated user after the grace period.
+// @Description Users with a password must confirm it. The account is anonymized or deleted and every token revoked once the grace period is over.
+// @Tags API User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param deletion body dto.RequestAccountDeletionRequest true "Password confirmation"
+// @Success 202 {object} dto.AccountDeletionResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Failure 429 {object} httperrors.HTTPError
+// @Router /users/me/deletion [post]
+func (h *UserHandler) RequestAccountDeletion(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	var req dto.RequestAccountDeletionRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.RequestAccountDeletionValidationMessages()); !ok {
+		return
+	}
+	res, err := h.UserService.RequestAccountDeletion(userID, req, sessionInfoFromRequest(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCurrentPassword) {
+			utils.WriteFieldError(c, "Password", "invalid_current_password", validation.RequestAccountDeletionValidationMessages())
+		} else if errors.Is(err, services.ErrTooManyLoginAttempts) {
+			retryAfter := int(h.UserService.AuthConfig.LoginThrottle.LockoutDuration.Seconds())
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			httperrors.ErrTooManyLoginAttempts.Write(c)
+		} else {
+			writeAccountDeletionError(c, err)
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, res)
+}
+
+// @Summary Cancel the deletion of my account
+// @Description Cancels the scheduled deletion of the authenticated user during the grace period
+// @Tags API User
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.CancelAccountDeletionResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /users/me/deletion [delete]
+func (h *UserHandler) CancelAccountDeletion(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.UserService.CancelAccountDeletion(userID)
+	if err != nil {
+		writeAccountDeletionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Export the data of a user
+// @Description Downloads a machine-readable archive of the data held about the user
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.UserDataExport
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /admin/users/{id}/export [get]
+func (h *UserHandler) AdminExportData(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return
+	}
+	h.writeDataExport(c, uint(userID))
+}
+
+// @Summary Delete the account of a user
+// @Description Schedules the deletion of the user after the grace period, or carries it out immediately
+// @Tags API Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param deletion body dto.AdminAccountDeletionRequest false "Deletion options"
+// @Success 200 {object} dto.AccountDeletionResponse
+// @Success 202 {object} dto.AccountDeletionResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Failure 410 {object} httperrors.HTTPError
+// @Router /admin/users/{id}/deletion [post]
+func (h *UserHandler) AdminDeleteAccount(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return
+	}
+	var req dto.AdminAccountDeletionRequest
+	// The body is optional, an empty body schedules the deletion
+	if c.Request.ContentLength != 0 {
+		if ok := utils.BindJSONAndValidate(c, &req, validation.AdminAccountDeletionValidationMessages()); !ok {
+			return
+		}
+	}
+	res, err := h.UserService.AdminDeleteAccount(uint(userID), req)
+	if err != nil {
+		writeAccountDeletionError(c, err)
+		return
+	}
+	if res.IsDeleted {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+	c.JSON(http.StatusAccepted, res)
+}
+
+// @Summary Cancel the deletion of a user
+// @Description Cancels the scheduled deletion of the user during the grace period
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.CancelAccountDeletionResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /admin/users/{id}/deletion [delete]
+func (h *UserHandler) AdminCancelAccountDeletion(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return
+	}
+	res, err := h.UserService.CancelAccountDeletion(uint(userID))
+	if err != nil {
+		writeAccountDeletionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *UserHandler) writeDataExport(c *gin.Context, userID uint) {
+	res, err := h.UserService.ExportUserData(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			httperrors.ErrUserNotFound.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="knowstack-export-%d.json"`, userID))
+	c.JSON(http.StatusOK, res)
+}
+
+func writeAccountDeletionError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrUserNotFound) {
+		httperrors.ErrUserNotFound.Write(c)
+	} else if errors.Is(err, services.ErrAccountDeleted) {
+		httperrors.ErrAccountDeleted.Write(c)
+	} else if errors.Is(err, services.ErrDeletionNotRequested) {
+		httperrors.ErrDeletionNotRequested.Write(c)
+	} else if errors.Is(err, services.ErrLastOrganizationOwner) {
+		httperrors.ErrLastOrganizationOwner.Write(c)
+	} else {
+		httperrors.ErrInternalServerError.Write(c)
+	}
+}
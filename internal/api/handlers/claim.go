@@ -1 +1,170 @@
 package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/api/validation"
+	"knowstack/internal/core/services"
+	"knowstack/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClaimHandler struct {
+	ClaimService *services.ClaimService
+}
+
+func NewClaimHandler(claimService *services.ClaimService) *ClaimHandler {
+	return &ClaimHandler{ClaimService: claimService}
+}
+
+// @Summary List claims
+// @Description Lists the claims page by page, optionally filtered by a part of the name
+// @Tags API Claim
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page, starting at 1"
+// @Param pageSize query int false "Page size, at most 100"
+// @Param search query string false "Part of the claim name"
+// @Success 200 {object} dto.ListClaimsResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Router /claims [get]
+func (h *ClaimHandler) ListClaims(c *gin.Context) {
+	var query dto.ListClaimsQuery
+	if ok := utils.BindQueryAndValidate(c, &query, validation.ListClaimsValidationMessages()); !ok {
+		return
+	}
+	res, err := h.ClaimService.GetClaims(query)
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Get a claim
+// @Tags API Claim
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Claim ID"
+// @Success 200 {object} dto.GetClaimsResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /claims/{id} [get]
+func (h *ClaimHandler) GetClaim(c *gin.Context) {
+	claimID, ok := claimIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.ClaimService.GetClaim(claimID)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Create a claim
+// @Tags API Claim
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param claim body dto.CreateClaimRequest true "Claim name"
+// @Success 201 {object} dto.CreateClaimResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /claims [post]
+func (h *ClaimHandler) CreateClaim(c *gin.Context) {
+	var req dto.CreateClaimRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.ClaimValidationMessages()); !ok {
+		return
+	}
+	res, err := h.ClaimService.CreateClaim(req)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary Rename a claim
+// @Tags API Claim
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Claim ID"
+// @Param claim body dto.UpdateClaimRequest true "New claim name"
+// @Success 200 {object} dto.UpdateClaimResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /claims/{id} [put]
+func (h *ClaimHandler) UpdateClaim(c *gin.Context) {
+	claimID, ok := claimIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.UpdateClaimRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.ClaimValidationMessages()); !ok {
+		return
+	}
+	req.ID = claimID
+	res, err := h.ClaimService.UpdateClaim(req)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Delete a claim
+// @Description Deletes the claim and reports the roles, users and personal access tokens that lost it
+// @Description A deleted seeded claim isn't seeded again on restart, creating a claim with its name restores it
+// @Tags API Claim
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Claim ID"
+// @Success 200 {object} dto.DeleteClaimResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /claims/{id} [delete]
+func (h *ClaimHandler) DeleteClaim(c *gin.Context) {
+	claimID, ok := claimIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.ClaimService.DeleteClaim(dto.DeleteClaimRequest{ID: claimID})
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func claimIDParam(c *gin.Context) (uint, bool) {
+	claimID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return 0, false
+	}
+	return uint(claimID), true
+}
+
+func writeClaimError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrClaimNotFound) {
+		httperrors.ErrClaimNotFound.Write(c)
+	} else if errors.Is(err, services.ErrClaimAlreadyExists) {
+		httperrors.ErrClaimAlreadyExists.Write(c)
+	} else if errors.Is(err, services.ErrWildcardClaimName) {
+		httperrors.ErrWildcardClaimName.Write(c)
+	} else {
+		httperrors.ErrInternalServerError.Write(c)
+	}
+}
@@ -1,11 +1,30 @@
 package handlers
 
-import "knowstack/internal/core/services"
+import (
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/middleware"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/core/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
 
 type Handlers struct {
-	HealthHandler *HealthHandler
-	UserHandler   *UserHandler
-	OAuthHandler  *OAuthHandler
+	HealthHandler              *HealthHandler
+	UserHandler                *UserHandler
+	OAuthHandler               *OAuthHandler
+	SessionHandler             *SessionHandler
+	TwoFactorHandler           *TwoFactorHandler
+	PasskeyHandler             *PasskeyHandler
+	IdentityHandler            *IdentityHandler
+	WellKnownHandler           *WellKnownHandler
+	TokenHandler               *TokenHandler
+	PersonalAccessTokenHandler *PersonalAccessTokenHandler
+	ImpersonationHandler       *ImpersonationHandler
+	RoleHandler                *RoleHandler
+	ClaimHandler               *ClaimHandler
+	OrganizationHandler        *OrganizationHandler
 }
 
 /*
@@ -14,8 +33,61 @@ Returns a pointer to the handlers instance
 */
 func NewHandlers(service *services.Service) *Handlers {
 	return &Handlers{
-		HealthHandler: NewHealthHandler(),
-		UserHandler:   NewUserHandler(service.UserService),
-		OAuthHandler: NewOAuthHandler(service.OAuthService),
+		HealthHandler:              NewHealthHandler(),
+		UserHandler:                NewUserHandler(service.UserService),
+		OAuthHandler:               NewOAuthHandler(service.OAuthService),
+		SessionHandler:             NewSessionHandler(service.SessionService),
+		TwoFactorHandler:           NewTwoFactorHandler(service.TwoFactorService),
+		PasskeyHandler:             NewPasskeyHandler(service.PasskeyService),
+		IdentityHandler:            NewIdentityHandler(service.IdentityService, service.OAuthService),
+		WellKnownHandler:           NewWellKnownHandler(),
+		TokenHandler:               NewTokenHandler(service.TokenIntrospectionService),
+		PersonalAccessTokenHandler: NewPersonalAccessTokenHandler(service.PersonalAccessTokenService),
+		ImpersonationHandler:       NewImpersonationHandler(service.ImpersonationService),
+		RoleHandler:                NewRoleHandler(service.RoleService),
+		ClaimHandler:               NewClaimHandler(service.ClaimService),
+		OrganizationHandler:        NewOrganizationHandler(service.OrganizationService),
+	}
+}
+
+/*
+Build the session info of the client from the request
+*/
+func sessionInfoFromRequest(c *gin.Context) dto.SessionInfo {
+	return dto.SessionInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
+/*
+Get the authenticated user ID and session ID from the access token claims
+Returns false if the request is not authenticated
+*/
+func authenticatedUser(c *gin.Context) (uint, string, bool) {
+	claims, ok := middleware.TokenClaimsFromContext(c)
+	if !ok {
+		return 0, "", false
+	}
+	userID, err := strconv.ParseUint(claims.UserID, 10, 32)
+	if err != nil {
+		return 0, "", false
+	}
+	return uint(userID), claims.SessionID, true
+}
+
+/*
+Get the authorization subject of the authenticated user from the access token claims
+Returns false if the request is not authenticated
+*/
+func authenticatedSubject(c *gin.Context) (authz.Subject, bool) {
+	claims, ok := middleware.TokenClaimsFromContext(c)
+	if !ok {
+		return authz.Subject{}, false
+	}
+	subject, err := authz.SubjectFromToken(claims)
+	if err != nil {
+		return authz.Subject{}, false
 	}
+	return subject, true
 }
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/core/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IdentityHandler struct {
+	IdentityService *services.IdentityService
+	OAuthService    *services.OAuthService
+}
+
+func NewIdentityHandler(identityService *services.IdentityService, oauthService *services.OAuthService) *IdentityHandler {
+	return &IdentityHandler{IdentityService: identityService, OAuthService: oauthService}
+}
+
+// @Summary List linked accounts
+// @Description Lists the external OAuth/OIDC accounts linked to the authenticated user
+// @Tags API Identity
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.IdentityResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Router /users/me/identities [get]
+func (h *IdentityHandler) ListIdentities(c *gin.Context) {
+	subject, ok := authenticatedSubject(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.IdentityService.ListIdentities(subject)
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Link an account
+// @Description Starts linking an account of the provider to the authenticated user.
+// @Description The client navigates to the returned URL; the request must be sent with credentials so the link cookies are stored.
+// @Tags API Identity
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Provider name"
+// @Success 200 {object} dto.LinkIdentityResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 502 {object} httperrors.HTTPError
+// @Router /users/me/identities/{provider}/link [post]
+func (h *IdentityHandler) LinkIdentity(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+
+	state := randomOAuthValue()
+	nonce := randomOAuthValue()
+
+	authURL, linkToken, err := h.OAuthService.StartLink(c.Request.Context(), userID, c.Param("provider"), state, nonce)
+	if err != nil {
+		if errors.Is(err, services.ErrOAuthProviderNotFound) {
+			httperrors.ErrOAuthProviderNotFound.Write(c)
+		} else {
+			httperrors.ErrOAuthProviderUnavailable.Write(c)
+		}
+		return
+	}
+
+	setOAuthCookies(c, state, nonce)
+	c.SetCookie("oauth_link", linkToken, 3600, "/", "", false, true)
+
+	c.JSON(http.StatusOK, dto.LinkIdentityResponse{AuthorizationURL: authURL})
+}
+
+// @Summary Unlink an account
+// @Description Unlinks an external account, unless it is the last way the user can log in
+// @Tags API Identity
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Identity ID"
+// @Success 200 {object} dto.UnlinkIdentityResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /users/me/identities/{id} [delete]
+func (h *IdentityHandler) UnlinkIdentity(c *gin.Context) {
+	subject, ok := authenticatedSubject(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	identityID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return
+	}
+	res, err := h.IdentityService.UnlinkIdentity(subject, uint(identityID))
+	if err != nil {
+		if errors.Is(err, services.ErrIdentityNotFound) {
+			httperrors.ErrIdentityNotFound.Write(c)
+		} else if errors.Is(err, authz.ErrDenied) {
+			httperrors.ErrForbidden.Write(c)
+		} else if errors.Is(err, services.ErrLastLoginMethod) {
+			httperrors.ErrLastLoginMethod.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
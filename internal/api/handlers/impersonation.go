@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/api/middleware"
+	"knowstack/internal/api/validation"
+	"knowstack/internal/core/services"
+	"knowstack/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImpersonationHandler struct {
+	ImpersonationService *services.ImpersonationService
+}
+
+func NewImpersonationHandler(impersonationService *services.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{ImpersonationService: impersonationService}
+}
+
+// @Summary Impersonate a user
+// @Description Issues a short lived access token of the user for the authenticated admin.
+// @Description The token records the admin as actor, can't change credentials and is audited.
+// @Tags API Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param reason body dto.ImpersonateUserRequest true "Reason of the impersonation"
+// @Success 201 {object} dto.ImpersonateUserResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /admin/users/{id}/impersonate [post]
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	claims, ok := middleware.TokenClaimsFromContext(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	subjectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return
+	}
+	var req dto.ImpersonateUserRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.ImpersonateUserValidationMessages()); !ok {
+		return
+	}
+	res, err := h.ImpersonationService.Impersonate(claims, uint(subjectID), req, sessionInfoFromRequest(c))
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			httperrors.ErrUserNotFound.Write(c)
+		} else if errors.Is(err, services.ErrImpersonationNotAllowed) {
+			httperrors.ErrImpersonationNotAllowed.Write(c)
+		} else if errors.Is(err, services.ErrAccountDisabled) {
+			httperrors.ErrAccountDisabled.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary End the impersonation
+// @Description Revokes the impersonation token the request is made with
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.EndImpersonationResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 401 {object} httperrors.HTTPError
+// @Router /users/me/impersonation/end [post]
+func (h *ImpersonationHandler) EndImpersonation(c *gin.Context) {
+	claims, ok := middleware.TokenClaimsFromContext(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.ImpersonationService.EndImpersonation(claims)
+	if err != nil {
+		if errors.Is(err, services.ErrNotImpersonating) {
+			httperrors.ErrNotImpersonating.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary List impersonations
+// @Description Lists the most recent impersonations for auditing
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.ImpersonationResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Router /admin/impersonations [get]
+func (h *ImpersonationHandler) ListImpersonations(c *gin.Context) {
+	res, err := h.ImpersonationService.ListImpersonations()
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/core/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List locked accounts
+// @Description Lists the accounts locked after too many failed logins
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.LockoutResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Router /admin/lockouts [get]
+func (h *UserHandler) ListLockouts(c *gin.Context) {
+	res, err := h.UserService.ListLockouts()
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Unlock a locked account
+// @Description Lifts the lockout of the account and resets its failed login counter
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.UnlockLockoutResponse
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /admin/lockouts/{id}/unlock [post]
+func (h *UserHandler) UnlockLockout(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return
+	}
+	if err := h.UserService.AdminUnlockAccount(uint(userID)); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			httperrors.ErrUserNotFound.Write(c)
+		} else if errors.Is(err, services.ErrAccountNotLocked) {
+			httperrors.ErrAccountNotLocked.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, dto.UnlockLockoutResponse{IsSuccess: true})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/api/validation"
+	"knowstack/internal/core/services"
+	"knowstack/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// magicLinkCookie binds a magic link to the browser that requested it
+const magicLinkCookie = "magic_link_binding"
+
+// @Summary Request a magic link
+// @Description Emails a single-use login link if an account exists for the email.
+// @Description When links are bound to the browser, the request must be sent with credentials so the binding cookie is stored.
+// @Tags API User
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.RequestMagicLinkResponse
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 429 {object} httperrors.HTTPError
+// @Router /users/magic-link [post]
+// @Param user body dto.RequestMagicLinkRequest true "Email to send the login link to"
+func (h *UserHandler) RequestMagicLink(c *gin.Context) {
+	var req dto.RequestMagicLinkRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.RequestMagicLinkValidationMessages()); !ok {
+		return
+	}
+
+	magicLink := h.UserService.AuthConfig.MagicLink
+	binding := ""
+	if magicLink.Enabled && magicLink.BindBrowser {
+		var err error
+		binding, err = utils.GenerateSecureToken()
+		if err != nil {
+			httperrors.ErrInternalServerError.Write(c)
+			return
+		}
+	}
+
+	res, err := h.UserService.RequestMagicLink(req, sessionInfoFromRequest(c), binding)
+	if err != nil {
+		if errors.Is(err, services.ErrMagicLinkDisabled) {
+			httperrors.ErrMagicLinkDisabled.Write(c)
+		} else if errors.Is(err, services.ErrTooManyLoginAttempts) {
+			retryAfter := int(h.UserService.AuthConfig.LoginThrottle.IPWindow.Seconds())
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			httperrors.ErrTooManyLoginAttempts.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+
+	if binding != "" {
+		c.SetCookie(magicLinkCookie, binding, int(magicLink.ExpiresIn.Seconds()), "/", "", magicLink.CookieSecure, true)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Log in with a magic link
+// @Description Redeems a magic link and returns the same response as the password login
+// @Tags API User
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 410 {object} httperrors.HTTPError
+// @Failure 429 {object} httperrors.HTTPError
+// @Router /users/magic-link/verify [post]
+// @Param token body dto.VerifyMagicLinkRequest true "Magic link token"
+func (h *UserHandler) VerifyMagicLink(c *gin.Context) {
+	var req dto.VerifyMagicLinkRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.VerifyMagicLinkValidationMessages()); !ok {
+		return
+	}
+
+	binding, _ := c.Cookie(magicLinkCookie)
+	res, err := h.UserService.VerifyMagicLink(req, sessionInfoFromRequest(c), binding)
+	if err != nil {
+		if errors.Is(err, services.ErrMagicLinkDisabled) {
+			httperrors.ErrMagicLinkDisabled.Write(c)
+		} else if errors.Is(err, services.ErrInvalidMagicLink) {
+			httperrors.ErrInvalidMagicLink.Write(c)
+		} else if errors.Is(err, services.ErrMagicLinkExpired) {
+			httperrors.ErrMagicLinkExpired.Write(c)
+		} else if errors.Is(err, services.ErrMagicLinkBrowserMismatch) {
+			httperrors.ErrMagicLinkBrowserMismatch.Write(c)
+		} else if errors.Is(err, services.ErrAccountDisabled) {
+			httperrors.ErrAccountDisabled.Write(c)
+		} else if errors.Is(err, services.ErrPasswordResetRequired) {
+			httperrors.ErrPasswordResetRequired.Write(c)
+		} else if errors.Is(err, services.ErrTooManyLoginAttempts) {
+			retryAfter := int(h.UserService.AuthConfig.LoginThrottle.IPWindow.Seconds())
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			httperrors.ErrTooManyLoginAttempts.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+
+	c.SetCookie(magicLinkCookie, "", -1, "/", "", h.UserService.AuthConfig.MagicLink.CookieSecure, true)
+	c.JSON(http.StatusOK, res)
+}
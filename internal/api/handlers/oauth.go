@@ -3,10 +3,13 @@ package handlers
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"knowstack/internal/api/httperrors"
 	"knowstack/internal/core/services"
 	"knowstack/internal/utils"
 	"net/http"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,32 +22,49 @@ func NewOAuthHandler(oauthService *services.OAuthService) *OAuthHandler {
 	return &OAuthHandler{OAuthService: oauthService}
 }
 
-// @Summary Google Login
-// @Description Redirects to Google OAuth login page
+// @Summary OAuth Login
+// @Description Redirects to the login page of the OAuth provider (google, github, microsoft, gitlab or a configured oidc provider)
 // @Tags OAuth
 // @Accept json
 // @Produce json
-// @Success 307 {string} string "Redirect to Google OAuth login page"
-// @Router /oauth/google/login [get]
-func (h *OAuthHandler) GoogleLogin(c *gin.Context) {
-	b := make([]byte, 32)
-	rand.Read(b)
-	state := base64.URLEncoding.EncodeToString(b)
+// @Param provider path string true "Provider name"
+// @Success 307 {string} string "Redirect to the OAuth login page"
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 502 {object} httperrors.HTTPError
+// @Router /oauth/{provider}/login [get]
+func (h *OAuthHandler) Login(c *gin.Context) {
+	provider := c.Param("provider")
 
-	c.SetCookie("oauth_state", state, 3600, "/", "", false, true)
+	state := randomOAuthValue()
+	nonce := randomOAuthValue()
+
+	loginURL, err := h.OAuthService.GetLoginURL(c.Request.Context(), provider, state, nonce)
+	if err != nil {
+		if errors.Is(err, services.ErrOAuthProviderNotFound) {
+			httperrors.ErrOAuthProviderNotFound.Write(c)
+		} else {
+			httperrors.ErrOAuthProviderUnavailable.Write(c)
+		}
+		return
+	}
+
+	setOAuthCookies(c, state, nonce)
 
-	url := h.OAuthService.GetGoogleLoginURL(state)
-	c.Redirect(http.StatusTemporaryRedirect, url)
+	c.Redirect(http.StatusTemporaryRedirect, loginURL)
 }
 
-// @Summary Google Callback
-// @Description Handles Google OAuth callback
+// @Summary OAuth Callback
+// @Description Handles the callback of the OAuth provider and redirects to the frontend with the tokens.
+// @Description A flow started from the link endpoint links the account to the user instead.
+// @Description Users with two-factor authentication get an MFA token to complete the login at /users/login/2fa.
 // @Tags OAuth
 // @Accept json
 // @Produce json
-// @Success 200 {object} dto.GoogleAuthResponse
-// @Router /oauth/google/callback [get]
-func (h *OAuthHandler) GoogleCallback(c *gin.Context) {
+// @Param provider path string true "Provider name"
+// @Success 200 {object} dto.OAuthAuthResponse
+// @Router /oauth/{provider}/callback [get]
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	provider := c.Param("provider")
 	code := c.Query("code")
 	state := c.Query("state")
 
@@ -52,32 +72,98 @@ func (h *OAuthHandler) GoogleCallback(c *gin.Context) {
 
 	savedState, err := c.Cookie("oauth_state")
 	if err != nil || savedState != state {
-		errorURL := fmt.Sprintf("%s/auth/error?message=%s", frontendURL, "Invalid state")
+		errorURL := fmt.Sprintf("%s/auth/error?message=%s", frontendURL, url.QueryEscape("Invalid state"))
 		c.Redirect(http.StatusTemporaryRedirect, errorURL)
 		return
 	}
+	nonce, _ := c.Cookie("oauth_nonce")
+	linkToken, _ := c.Cookie("oauth_link")
 
 	c.SetCookie("oauth_state", "", -1, "/", "", false, true)
+	c.SetCookie("oauth_nonce", "", -1, "/", "", false, true)
+	c.SetCookie("oauth_link", "", -1, "/", "", false, true)
 
 	if code == "" {
-		errorURL := fmt.Sprintf("%s/auth/error?message=%s", frontendURL, "Invalid code")
+		errorURL := fmt.Sprintf("%s/auth/error?message=%s", frontendURL, url.QueryEscape("Invalid code"))
 		c.Redirect(http.StatusTemporaryRedirect, errorURL)
 		return
 	}
 
-	response, err := h.OAuthService.HandleGoogleCallback(code)
+	if linkToken != "" {
+		h.linkCallback(c, frontendURL, linkToken, provider, state, code, nonce)
+		return
+	}
+
+	response, err := h.OAuthService.HandleCallback(c.Request.Context(), provider, code, nonce, sessionInfoFromRequest(c))
 	if err != nil {
-		errorURL := fmt.Sprintf("%s/auth/error?message=%s", frontendURL, "Failed to handle Google callback")
+		message := "Failed to handle OAuth callback"
+		if errors.Is(err, services.ErrOAuthProviderNotFound) {
+			message = "Unknown OAuth provider"
+		} else if errors.Is(err, services.ErrOAuthEmailNotVerified) {
+			message = "OAuth email not verified"
+		} else if errors.Is(err, services.ErrOAuthAccountExists) {
+			message = "An account with this email already exists, log in and link the provider"
+		} else if errors.Is(err, services.ErrAccountDisabled) {
+			message = "Account disabled"
+		} else if errors.Is(err, services.ErrPasswordResetRequired) {
+			message = "Reset your password before logging in"
+		}
+		errorURL := fmt.Sprintf("%s/auth/error?message=%s", frontendURL, url.QueryEscape(message))
 		c.Redirect(http.StatusTemporaryRedirect, errorURL)
 		return
 	}
 
-	redirectURL := fmt.Sprintf("%s/oauth/google/callback#access_token=%s&refresh_token=%s&isNewUser=%t",
+	if response.MFARequired {
+		// The frontend completes the login with the second factor, see TwoFactorHandler.VerifyLogin
+		redirectURL := fmt.Sprintf("%s/oauth/%s/callback#mfaRequired=true&mfaToken=%s",
+			frontendURL,
+			url.PathEscape(provider),
+			response.MFAToken,
+		)
+		c.Redirect(http.StatusTemporaryRedirect, redirectURL)
+		return
+	}
+
+	redirectURL := fmt.Sprintf("%s/oauth/%s/callback#access_token=%s&refresh_token=%s&isNewUser=%t&twoFactorSetupRequired=%t",
 		frontendURL,
+		url.PathEscape(provider),
 		response.AccessToken,
 		response.RefreshToken,
 		response.IsNewUser,
+		response.TwoFactorSetupRequired,
 	)
 
 	c.Redirect(http.StatusTemporaryRedirect, redirectURL)
 }
+
+// linkCallback finishes a link flow and redirects to the identity settings of the frontend
+func (h *OAuthHandler) linkCallback(c *gin.Context, frontendURL, linkToken, provider, state, code, nonce string) {
+	err := h.OAuthService.LinkIdentity(c.Request.Context(), linkToken, provider, state, code, nonce)
+	if err != nil {
+		message := "Failed to link account"
+		if errors.Is(err, services.ErrIdentityAlreadyLinked) {
+			message = "Account is already linked to another user"
+		} else if errors.Is(err, services.ErrInvalidOAuthLink) {
+			message = "Invalid link request"
+		}
+		errorURL := fmt.Sprintf("%s/auth/error?message=%s", frontendURL, url.QueryEscape(message))
+		c.Redirect(http.StatusTemporaryRedirect, errorURL)
+		return
+	}
+
+	redirectURL := fmt.Sprintf("%s/settings/identities?linked=%s", frontendURL, url.QueryEscape(provider))
+	c.Redirect(http.StatusTemporaryRedirect, redirectURL)
+}
+
+// setOAuthCookies binds the authorization request to the browser
+func setOAuthCookies(c *gin.Context, state, nonce string) {
+	c.SetCookie("oauth_state", state, 3600, "/", "", false, true)
+	c.SetCookie("oauth_nonce", nonce, 3600, "/", "", false, true)
+}
+
+// randomOAuthValue generates the value of the state and nonce parameters
+func randomOAuthValue() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.URLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/api/middleware"
+	"knowstack/internal/api/validation"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/core/services"
+	"knowstack/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationHandler struct {
+	OrganizationService *services.OrganizationService
+}
+
+func NewOrganizationHandler(organizationService *services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{OrganizationService: organizationService}
+}
+
+// @Summary List my organizations
+// @Description Lists the organizations the authenticated user is a member of with its role in them
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.UserOrganizationResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Router /organizations [get]
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.OrganizationService.ListUserOrganizations(userID)
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Create an organization
+// @Description Creates an organization with the authenticated user as its owner
+// @Tags API Organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param organization body dto.CreateOrganizationRequest true "Organization name"
+// @Success 201 {object} dto.UserOrganizationResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /organizations [post]
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	var req dto.CreateOrganizationRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.CreateOrganizationValidationMessages()); !ok {
+		return
+	}
+	res, err := h.OrganizationService.CreateOrganization(userID, req)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary Get an organization
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Param orgID path int true "Organization ID"
+// @Success 200 {object} dto.OrganizationResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /organizations/{orgID} [get]
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	claims, subject, ok := organizationCaller(c)
+	if !ok {
+		return
+	}
+	res, err := h.OrganizationService.GetOrganization(subject, claims.OrganizationID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Rename an organization
+// @Tags API Organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param orgID path int true "Organization ID"
+// @Param organization body dto.UpdateOrganizationRequest true "New organization name"
+// @Success 200 {object} dto.OrganizationResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /organizations/{orgID} [put]
+func (h *OrganizationHandler) RenameOrganization(c *gin.Context) {
+	claims, subject, ok := organizationCaller(c)
+	if !ok {
+		return
+	}
+	var req dto.UpdateOrganizationRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.UpdateOrganizationValidationMessages()); !ok {
+		return
+	}
+	res, err := h.OrganizationService.RenameOrganization(subject, claims.OrganizationID, req)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Delete an organization
+// @Description Deletes the organization with its memberships
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Param orgID path int true "Organization ID"
+// @Success 200 {object} dto.DeleteOrganizationResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /organizations/{orgID} [delete]
+func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
+	claims, subject, ok := organizationCaller(c)
+	if !ok {
+		return
+	}
+	res, err := h.OrganizationService.DeleteOrganization(subject, claims.OrganizationID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary List organization members
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Param orgID path int true "Organization ID"
+// @Param page query int false "Page, starting at 1"
+// @Param pageSize query int false "Page size, at most 100"
+// @Success 200 {object} dto.ListOrganizationMembersResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Router /organizations/{orgID}/members [get]
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	claims, subject, ok := organizationCaller(c)
+	if !ok {
+		return
+	}
+	var query dto.ListOrganizationMembersQuery
+	if ok := utils.BindQueryAndValidate(c, &query, validation.ListOrganizationMembersValidationMessages()); !ok {
+		return
+	}
+	res, err := h.OrganizationService.ListMembers(subject, claims.OrganizationID, query)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Invite an organization member
+// @Description Invites the owner of the email to the organization with the role, the membership is created when the user accepts.
+// @Description The caller must hold every claim of the role in the organization. Inviting the email again replaces the role and renews the invitation.
+// @Tags API Organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param orgID path int true "Organization ID"
+// @Param invitation body dto.InviteOrganizationMemberRequest true "Email and role"
+// @Success 201 {object} dto.OrganizationInvitationResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /organizations/{orgID}/invitations [post]
+func (h *OrganizationHandler) InviteMember(c *gin.Context) {
+	claims, subject, ok := organizationCaller(c)
+	if !ok {
+		return
+	}
+	var req dto.InviteOrganizationMemberRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.InviteOrganizationMemberValidationMessages()); !ok {
+		return
+	}
+	res, err := h.OrganizationService.InviteMember(subject, claims.OrganizationID, req)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary List organization invitations
+// @Description Lists the invitations of the organization that can still be accepted
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Param orgID path int true "Organization ID"
+// @Success 200 {array} dto.OrganizationInvitationResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Router /organizations/{orgID}/invitations [get]
+func (h *OrganizationHandler) ListInvitations(c *gin.Context) {
+	claims, subject, ok := organizationCaller(c)
+	if !ok {
+		return
+	}
+	res, err := h.OrganizationService.ListInvitations(subject, claims.OrganizationID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Revoke an organization invitation
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Param orgID path int true "Organization ID"
+// @Param invitationID path int true "Invitation ID"
+// @Success 200 {object} dto.DeleteOrganizationInvitationResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /organizations/{orgID}/invitations/{invitationID} [delete]
+func (h *OrganizationHandler) RevokeInvitation(c *gin.Context) {
+	claims, subject, ok := organizationCaller(c)
+	if !ok {
+		return
+	}
+	invitationID, ok := invitationIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.OrganizationService.RevokeInvitation(subject, claims.OrganizationID, invitationID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary List my organization invitations
+// @Description Lists the invitations sent to the email of the authenticated user that can still be accepted
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.UserInvitationResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Router /users/me/invitations [get]
+func (h *OrganizationHandler) ListUserInvitations(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.OrganizationService.ListUserInvitations(userID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Accept an organization invitation
+// @Description Makes the authenticated user a member of the organization with the role of the invitation. The email of the user must be verified.
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Param invitationID path int true "Invitation ID"
+// @Success 201 {object} dto.UserOrganizationResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /users/me/invitations/{invitationID}/accept [post]
+func (h *OrganizationHandler) AcceptInvitation(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	invitationID, ok := invitationIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.OrganizationService.AcceptInvitation(userID, invitationID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary Decline an organization invitation
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Param invitationID path int true "Invitation ID"
+// @Success 200 {object} dto.DeleteOrganizationInvitationResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /users/me/invitations/{invitationID} [delete]
+func (h *OrganizationHandler) DeclineInvitation(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	invitationID, ok := invitationIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.OrganizationService.DeclineInvitation(userID, invitationID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Change the role of an organization member
+// @Description Moves the member to another role. The caller must hold every claim of the role in the organization.
+// @Tags API Organization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param orgID path int true "Organization ID"
+// @Param userID path int true "User ID"
+// @Param role body dto.SetOrganizationMemberRoleRequest true "New role"
+// @Success 200 {object} dto.OrganizationMemberResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /organizations/{orgID}/members/{userID}/role [put]
+func (h *OrganizationHandler) SetMemberRole(c *gin.Context) {
+	claims, subject, ok := organizationCaller(c)
+	if !ok {
+		return
+	}
+	userID, ok := memberIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.SetOrganizationMemberRoleRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.SetOrganizationMemberRoleValidationMessages()); !ok {
+		return
+	}
+	res, err := h.OrganizationService.SetMemberRole(subject, claims.OrganizationID, userID, req)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Remove an organization member
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Param orgID path int true "Organization ID"
+// @Param userID path int true "User ID"
+// @Success 200 {object} dto.RemoveOrganizationMemberResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /organizations/{orgID}/members/{userID} [delete]
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	claims, subject, ok := organizationCaller(c)
+	if !ok {
+		return
+	}
+	userID, ok := memberIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.OrganizationService.RemoveMember(subject, claims.OrganizationID, userID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Leave an organization
+// @Description Removes the authenticated user from the organization. The last owner can't leave.
+// @Tags API Organization
+// @Produce json
+// @Security BearerAuth
+// @Param orgID path int true "Organization ID"
+// @Success 200 {object} dto.RemoveOrganizationMemberResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /organizations/{orgID}/leave [post]
+func (h *OrganizationHandler) LeaveOrganization(c *gin.Context) {
+	claims, subject, ok := organizationCaller(c)
+	if !ok {
+		return
+	}
+	res, err := h.OrganizationService.LeaveOrganization(subject, claims.OrganizationID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+/*
+Get the claims and the authorization subject of the member making the request in its active organization
+Writes an error response and returns false if the request is not authenticated or has no active organization
+*/
+func organizationCaller(c *gin.Context) (*utils.TokenClaims, authz.Subject, bool) {
+	claims, ok := middleware.TokenClaimsFromContext(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return nil, authz.Subject{}, false
+	}
+	subject, ok := authenticatedSubject(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return nil, authz.Subject{}, false
+	}
+	if claims.OrganizationID == 0 {
+		httperrors.ErrInvalidRequest.Write(c)
+		return nil, authz.Subject{}, false
+	}
+	return claims, subject, true
+}
+
+func memberIDParam(c *gin.Context) (uint, bool) {
+	userID, err := strconv.ParseUint(c.Param("userID"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+func invitationIDParam(c *gin.Context) (uint, bool) {
+	invitationID, err := strconv.ParseUint(c.Param("invitationID"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return 0, false
+	}
+	return uint(invitationID), true
+}
+
+func writeOrganizationError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrOrganizationNotFound) {
+		httperrors.ErrOrganizationNotFound.Write(c)
+	} else if errors.Is(err, services.ErrOrganizationAlreadyExists) {
+		httperrors.ErrOrganizationAlreadyExists.Write(c)
+	} else if errors.Is(err, services.ErrOrganizationMemberNotFound) {
+		httperrors.ErrOrganizationMemberNotFound.Write(c)
+	} else if errors.Is(err, services.ErrAlreadyOrganizationMember) {
+		httperrors.ErrAlreadyOrganizationMember.Write(c)
+	} else if errors.Is(err, services.ErrLastOrganizationOwner) {
+		httperrors.ErrLastOrganizationOwner.Write(c)
+	} else if errors.Is(err, services.ErrInvitationNotFound) {
+		httperrors.ErrInvitationNotFound.Write(c)
+	} else if errors.Is(err, services.ErrAccountDisabled) {
+		httperrors.ErrAccountDisabled.Write(c)
+	} else if errors.Is(err, services.ErrEmailNotVerified) {
+		httperrors.ErrEmailNotVerified.Write(c)
+	} else if errors.Is(err, services.ErrUserNotFound) {
+		httperrors.ErrUserNotFound.Write(c)
+	} else if errors.Is(err, services.ErrRoleNotFound) {
+		httperrors.ErrRoleNotFound.Write(c)
+	} else if errors.Is(err, services.ErrClaimNotGrantable) {
+		httperrors.ErrClaimNotGrantable.Write(c)
+	} else if errors.Is(err, services.ErrSelfAdministration) {
+		httperrors.ErrSelfAdministration.Write(c)
+	} else if errors.Is(err, authz.ErrDenied) {
+		httperrors.ErrForbidden.Write(c)
+	} else {
+		httperrors.ErrInternalServerError.Write(c)
+	}
+}
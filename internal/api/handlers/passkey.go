@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/api/validation"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/core/services"
+	"knowstack/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasskeyHandler struct {
+	PasskeyService *services.PasskeyService
+}
+
+func NewPasskeyHandler(passkeyService *services.PasskeyService) *PasskeyHandler {
+	return &PasskeyHandler{PasskeyService: passkeyService}
+}
+
+// @Summary Begin passkey registration
+// @Description Returns the options for navigator.credentials.create and the ceremony session key
+// @Tags API Passkey
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.BeginPasskeyResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Router /users/me/passkeys/register/begin [post]
+func (h *PasskeyHandler) BeginRegistration(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.PasskeyService.BeginRegistration(userID)
+	if err != nil {
+		writePasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Finish passkey registration
+// @Description Verifies the attestation of the authenticator and stores the passkey
+// @Tags API Passkey
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} dto.PasskeyResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 401 {object} httperrors.HTTPError
+// @Router /users/me/passkeys/register/finish [post]
+// @Param passkey body dto.FinishPasskeyRegistrationRequest true "Session key and the credential created by the authenticator"
+func (h *PasskeyHandler) FinishRegistration(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	var req dto.FinishPasskeyRegistrationRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.FinishPasskeyRegistrationValidationMessages()); !ok {
+		return
+	}
+	res, err := h.PasskeyService.FinishRegistration(userID, req)
+	if err != nil {
+		writePasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary List passkeys
+// @Description Lists the passkeys of the authenticated user
+// @Tags API Passkey
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.PasskeyResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Router /users/me/passkeys [get]
+func (h *PasskeyHandler) ListPasskeys(c *gin.Context) {
+	subject, ok := authenticatedSubject(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.PasskeyService.ListPasskeys(subject)
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Delete a passkey
+// @Description Deletes a passkey of the authenticated user
+// @Tags API Passkey
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Passkey ID"
+// @Success 200 {object} dto.DeletePasskeyResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /users/me/passkeys/{id} [delete]
+func (h *PasskeyHandler) DeletePasskey(c *gin.Context) {
+	subject, ok := authenticatedSubject(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	passkeyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return
+	}
+	res, err := h.PasskeyService.DeletePasskey(subject, uint(passkeyID))
+	if err != nil {
+		writePasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Begin passkey login
+// @Description Returns the options for navigator.credentials.get and the ceremony session key
+// @Tags API Passkey
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.BeginPasskeyResponse
+// @Router /users/passkeys/login/begin [post]
+func (h *PasskeyHandler) BeginLogin(c *gin.Context) {
+	res, err := h.PasskeyService.BeginLogin()
+	if err != nil {
+		writePasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Finish passkey login
+// @Description Verifies the assertion of the authenticator and logs the user in
+// @Tags API Passkey
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 429 {object} httperrors.HTTPError
+// @Router /users/passkeys/login/finish [post]
+// @Param passkey body dto.FinishPasskeyLoginRequest true "Session key and the assertion of the authenticator"
+func (h *PasskeyHandler) FinishLogin(c *gin.Context) {
+	var req dto.FinishPasskeyLoginRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.FinishPasskeyLoginValidationMessages()); !ok {
+		return
+	}
+	res, err := h.PasskeyService.FinishLogin(req, sessionInfoFromRequest(c))
+	if err != nil {
+		writePasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func writePasskeyError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrPasskeysUnavailable) {
+		httperrors.ErrPasskeysUnavailable.Write(c)
+	} else if errors.Is(err, services.ErrPasskeyNotFound) {
+		httperrors.ErrPasskeyNotFound.Write(c)
+	} else if errors.Is(err, authz.ErrDenied) {
+		httperrors.ErrForbidden.Write(c)
+	} else if errors.Is(err, services.ErrLastLoginMethod) {
+		httperrors.ErrLastLoginMethod.Write(c)
+	} else if errors.Is(err, services.ErrInvalidPasskeyChallenge) {
+		httperrors.ErrInvalidPasskeyChallenge.Write(c)
+	} else if errors.Is(err, services.ErrPasskeyVerification) || errors.Is(err, services.ErrUserNotFound) {
+		httperrors.ErrPasskeyVerification.Write(c)
+	} else if errors.Is(err, services.ErrAccountDisabled) {
+		httperrors.ErrAccountDisabled.Write(c)
+	} else if errors.Is(err, services.ErrPasswordResetRequired) {
+		httperrors.ErrPasswordResetRequired.Write(c)
+	} else if errors.Is(err, services.ErrTooManyLoginAttempts) {
+		httperrors.ErrTooManyLoginAttempts.Write(c)
+	} else {
+		httperrors.ErrInternalServerError.Write(c)
+	}
+}
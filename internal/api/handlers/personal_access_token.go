@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/api/middleware"
+	"knowstack/internal/api/validation"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/core/services"
+	"knowstack/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PersonalAccessTokenHandler struct {
+	PersonalAccessTokenService *services.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(personalAccessTokenService *services.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{PersonalAccessTokenService: personalAccessTokenService}
+}
+
+// @Summary List personal access tokens
+// @Description Lists the personal access tokens of the authenticated user, the tokens themselves are never returned
+// @Tags API Personal Access Token
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.PersonalAccessTokenResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Router /users/me/tokens [get]
+func (h *PersonalAccessTokenHandler) ListTokens(c *gin.Context) {
+	subject, ok := authenticatedSubject(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.PersonalAccessTokenService.ListTokens(subject)
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Create a personal access token
+// @Description Creates a personal access token limited to a subset of the claims of the authenticated user.
+// @Description The token is only returned in this response. Tokens can only be managed from a login session.
+// @Tags API Personal Access Token
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param token body dto.CreatePersonalAccessTokenRequest true "Token name, scopes and expiry"
+// @Success 201 {object} dto.CreatePersonalAccessTokenResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Router /users/me/tokens [post]
+func (h *PersonalAccessTokenHandler) CreateToken(c *gin.Context) {
+	claims, ok := middleware.TokenClaimsFromContext(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	var req dto.CreatePersonalAccessTokenRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.CreatePersonalAccessTokenValidationMessages()); !ok {
+		return
+	}
+	// Tokens only carry platform claims, the claims of an organization can't be scopes
+	res, err := h.PersonalAccessTokenService.CreateToken(userID, claims.Claims, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTokenScope) {
+			httperrors.ErrInvalidTokenScope.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary Delete a personal access token
+// @Description Revokes a personal access token of the authenticated user
+// @Tags API Personal Access Token
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Token ID"
+// @Success 200 {object} dto.DeletePersonalAccessTokenResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /users/me/tokens/{id} [delete]
+func (h *PersonalAccessTokenHandler) DeleteToken(c *gin.Context) {
+	subject, ok := authenticatedSubject(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return
+	}
+	res, err := h.PersonalAccessTokenService.DeleteToken(subject, uint(tokenID))
+	if err != nil {
+		if errors.Is(err, services.ErrPersonalAccessTokenNotFound) {
+			httperrors.ErrPersonalAccessTokenNotFound.Write(c)
+		} else if errors.Is(err, authz.ErrDenied) {
+			httperrors.ErrForbidden.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
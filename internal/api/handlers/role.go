@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/api/validation"
+	"knowstack/internal/core/services"
+	"knowstack/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	RoleService *services.RoleService
+}
+
+func NewRoleHandler(roleService *services.RoleService) *RoleHandler {
+	return &RoleHandler{RoleService: roleService}
+}
+
+// @Summary List roles
+// @Description Lists the roles with their claims and number of members
+// @Tags API Role
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.RoleResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Router /admin/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	res, err := h.RoleService.ListRoles()
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Get a role
+// @Tags API Role
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} dto.RoleResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /admin/roles/{id} [get]
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.RoleService.GetRole(roleID)
+	if err != nil {
+		writeRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Create a role
+// @Description Creates a role, optionally with its initial claims. The caller must hold every claim it grants.
+// @Tags API Role
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param role body dto.CreateRoleRequest true "Role name and claims"
+// @Success 201 {object} dto.RoleResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /admin/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	caller, _, ok := adminCaller(c)
+	if !ok {
+		return
+	}
+	var req dto.CreateRoleRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.CreateRoleValidationMessages()); !ok {
+		return
+	}
+	res, err := h.RoleService.CreateRole(caller.Claims, req)
+	if err != nil {
+		writeRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary Rename a role
+// @Tags API Role
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param role body dto.UpdateRoleRequest true "New role name"
+// @Success 200 {object} dto.RoleResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /admin/roles/{id} [put]
+func (h *RoleHandler) RenameRole(c *gin.Context) {
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.UpdateRoleRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.UpdateRoleValidationMessages()); !ok {
+		return
+	}
+	res, err := h.RoleService.RenameRole(roleID, req)
+	if err != nil {
+		writeRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Delete a role
+// @Description Deletes the role. A role with members is only deleted when reassignTo names the role its members move to.
+// @Description The default role and the organization roles can't be deleted.
+// @Tags API Role
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param reassignTo query int false "Role the members are moved to"
+// @Success 200 {object} dto.DeleteRoleResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /admin/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+	var reassignTo *uint
+	if value := c.Query("reassignTo"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			httperrors.ErrInvalidRequest.Write(c)
+			return
+		}
+		target := uint(id)
+		reassignTo = &target
+	}
+	res, err := h.RoleService.DeleteRole(roleID, reassignTo)
+	if err != nil {
+		writeRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Set the default role
+// @Description Makes the role the one assigned to newly registered users
+// @Tags API Role
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} dto.RoleResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /admin/roles/{id}/default [post]
+func (h *RoleHandler) SetDefaultRole(c *gin.Context) {
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.RoleService.SetDefaultRole(roleID)
+	if err != nil {
+		writeRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Attach claims to a role
+// @Description The caller must hold every claim it attaches
+// @Tags API Role
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param claims body dto.RoleClaimsRequest true "Claim names"
+// @Success 200 {object} dto.RoleResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /admin/roles/{id}/claims [post]
+func (h *RoleHandler) AttachClaims(c *gin.Context) {
+	caller, _, ok := adminCaller(c)
+	if !ok {
+		return
+	}
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.RoleClaimsRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.RoleClaimsValidationMessages()); !ok {
+		return
+	}
+	res, err := h.RoleService.AttachClaims(caller.Claims, roleID, req)
+	if err != nil {
+		writeRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Detach claims from a role
+// @Tags API Role
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param claims body dto.RoleClaimsRequest true "Claim names"
+// @Success 200 {object} dto.RoleResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /admin/roles/{id}/claims [delete]
+func (h *RoleHandler) DetachClaims(c *gin.Context) {
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.RoleClaimsRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.RoleClaimsValidationMessages()); !ok {
+		return
+	}
+	res, err := h.RoleService.DetachClaims(roleID, req)
+	if err != nil {
+		writeRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func roleIDParam(c *gin.Context) (uint, bool) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return 0, false
+	}
+	return uint(roleID), true
+}
+
+func writeRoleError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrRoleNotFound) {
+		httperrors.ErrRoleNotFound.Write(c)
+	} else if errors.Is(err, services.ErrRoleAlreadyExists) {
+		httperrors.ErrRoleAlreadyExists.Write(c)
+	} else if errors.Is(err, services.ErrRoleHasMembers) {
+		httperrors.ErrRoleHasMembers.Write(c)
+	} else if errors.Is(err, services.ErrDefaultRoleDeletion) {
+		httperrors.ErrDefaultRoleDeletion.Write(c)
+	} else if errors.Is(err, services.ErrInvalidReassignRole) {
+		httperrors.ErrInvalidReassignRole.Write(c)
+	} else if errors.Is(err, services.ErrReassignRoleNotFound) {
+		httperrors.ErrReassignRoleNotFound.Write(c)
+	} else if errors.Is(err, services.ErrProtectedRole) {
+		httperrors.ErrProtectedRole.Write(c)
+	} else if errors.Is(err, services.ErrClaimNotFound) {
+		httperrors.ErrClaimsNotFound.Write(c)
+	} else if errors.Is(err, services.ErrClaimNotGrantable) {
+		httperrors.ErrClaimNotGrantable.Write(c)
+	} else {
+		httperrors.ErrInternalServerError.Write(c)
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/core/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	SessionService *services.SessionService
+}
+
+func NewSessionHandler(sessionService *services.SessionService) *SessionHandler {
+	return &SessionHandler{SessionService: sessionService}
+}
+
+// @Summary List sessions
+// @Description Lists the active sessions (devices) of the authenticated user
+// @Tags API Session
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.SessionResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Router /users/me/sessions [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, sessionID, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.SessionService.ListSessions(userID, sessionID)
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Revoke a session
+// @Description Signs the authenticated user out of a single session
+// @Tags API Session
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} dto.RevokeSessionResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /users/me/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.SessionService.RevokeSession(userID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			httperrors.ErrSessionNotFound.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Revoke other sessions
+// @Description Signs the authenticated user out of every session except the current one
+// @Tags API Session
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.RevokeOtherSessionsResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Router /users/me/sessions/revoke-others [post]
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, sessionID, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.SessionService.RevokeOtherSessions(userID, sessionID)
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Revoke all sessions
+// @Description Signs the authenticated user out everywhere. The access tokens of every session, including the current one, stop working immediately.
+// @Tags API Session
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.RevokeOtherSessionsResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Router /users/me/sessions/revoke-all [post]
+func (h *SessionHandler) RevokeAllSessions(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.SessionService.RevokeAllSessions(userID)
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TokenHandler struct {
+	TokenIntrospectionService *services.TokenIntrospectionService
+}
+
+func NewTokenHandler(tokenIntrospectionService *services.TokenIntrospectionService) *TokenHandler {
+	return &TokenHandler{TokenIntrospectionService: tokenIntrospectionService}
+}
+
+// @Summary Introspect a token
+// @Description Reports whether an access or refresh token is active (RFC 7662). Requires client credentials with HTTP Basic or client_id/client_secret form fields.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 {object} dto.IntrospectionResponse
+// @Failure 400 {object} dto.OAuthErrorResponse
+// @Failure 401 {object} dto.OAuthErrorResponse
+// @Router /oauth/introspect [post]
+func (h *TokenHandler) Introspect(c *gin.Context) {
+	if !h.authenticateClient(c) {
+		return
+	}
+
+	var req dto.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, dto.OAuthErrorResponse{Error: "invalid_request", ErrorDescription: "token is required"})
+		return
+	}
+
+	res, err := h.TokenIntrospectionService.Introspect(req.Token, req.TokenTypeHint)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, dto.OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Revoke a token
+// @Description Revokes the session of an access or refresh token (RFC 7009). Unknown tokens are not an error. Requires client credentials.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token to revoke"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200
+// @Failure 400 {object} dto.OAuthErrorResponse
+// @Failure 401 {object} dto.OAuthErrorResponse
+// @Router /oauth/revoke [post]
+func (h *TokenHandler) Revoke(c *gin.Context) {
+	if !h.authenticateClient(c) {
+		return
+	}
+
+	var req dto.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, dto.OAuthErrorResponse{Error: "invalid_request", ErrorDescription: "token is required"})
+		return
+	}
+
+	if err := h.TokenIntrospectionService.Revoke(req.Token, req.TokenTypeHint); err != nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, dto.OAuthErrorResponse{Error: "temporarily_unavailable"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/*
+Authenticate the client with HTTP Basic (client_secret_basic) or
+the client_id and client_secret form fields (client_secret_post).
+Writes the invalid_client error when the credentials are wrong.
+*/
+func (h *TokenHandler) authenticateClient(c *gin.Context) bool {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if !ok {
+		clientID = c.PostForm("client_id")
+		clientSecret = c.PostForm("client_secret")
+	}
+
+	if !h.TokenIntrospectionService.AuthenticateClient(clientID, clientSecret) {
+		c.Header("WWW-Authenticate", `Basic realm="knowstack"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, dto.OAuthErrorResponse{Error: "invalid_client"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/api/validation"
+	"knowstack/internal/core/services"
+	"knowstack/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	TwoFactorService *services.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{TwoFactorService: twoFactorService}
+}
+
+// @Summary Enroll two-factor authentication
+// @Description Generates a TOTP secret and returns its otpauth URI, it has to be confirmed with a code
+// @Tags API Two Factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.EnrollTwoFactorResponse
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /users/me/2fa/enroll [post]
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	res, err := h.TwoFactorService.Enroll(userID)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Confirm two-factor authentication
+// @Description Enables two-factor authentication with the first code and returns the recovery codes
+// @Tags API Two Factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.ConfirmTwoFactorResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Router /users/me/2fa/confirm [post]
+// @Param code body dto.TwoFactorCodeRequest true "Code from the authenticator app"
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	var req dto.TwoFactorCodeRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.TwoFactorCodeValidationMessages()); !ok {
+		return
+	}
+	res, err := h.TwoFactorService.Confirm(userID, req)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Disable two-factor authentication
+// @Description Disables two-factor authentication with a TOTP or recovery code
+// @Tags API Two Factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.DisableTwoFactorResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 429 {object} httperrors.HTTPError
+// @Router /users/me/2fa/disable [post]
+// @Param code body dto.TwoFactorCodeRequest true "TOTP or recovery code"
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	var req dto.TwoFactorCodeRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.TwoFactorCodeValidationMessages()); !ok {
+		return
+	}
+	res, err := h.TwoFactorService.Disable(userID, req, sessionInfoFromRequest(c))
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Regenerate recovery codes
+// @Description Replaces the recovery codes, the old ones can't be used anymore
+// @Tags API Two Factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 429 {object} httperrors.HTTPError
+// @Router /users/me/2fa/recovery-codes [post]
+// @Param code body dto.TwoFactorCodeRequest true "TOTP or recovery code"
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return
+	}
+	var req dto.TwoFactorCodeRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.TwoFactorCodeValidationMessages()); !ok {
+		return
+	}
+	res, err := h.TwoFactorService.RegenerateRecoveryCodes(userID, req, sessionInfoFromRequest(c))
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Complete a two-factor login
+// @Description Exchanges the MFA challenge token returned by login and a TOTP or recovery code for tokens
+// @Tags API Two Factor
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 429 {object} httperrors.HTTPError
+// @Router /users/login/2fa [post]
+// @Param user body dto.VerifyTwoFactorLoginRequest true "MFA challenge token and code"
+func (h *TwoFactorHandler) VerifyLogin(c *gin.Context) {
+	var req dto.VerifyTwoFactorLoginRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.VerifyTwoFactorLoginValidationMessages()); !ok {
+		return
+	}
+	res, err := h.TwoFactorService.VerifyLogin(req, sessionInfoFromRequest(c))
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func writeTwoFactorError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrUserNotFound) {
+		httperrors.ErrUserNotFound.Write(c)
+	} else if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+		httperrors.ErrTwoFactorAlreadyEnabled.Write(c)
+	} else if errors.Is(err, services.ErrTwoFactorNotEnrolled) || errors.Is(err, services.ErrTwoFactorNotEnabled) {
+		httperrors.ErrTwoFactorNotEnabled.Write(c)
+	} else if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+		httperrors.ErrInvalidTwoFactorCode.Write(c)
+	} else if errors.Is(err, services.ErrMFAChallengeExpired) {
+		httperrors.ErrTokenExpired.Write(c)
+	} else if errors.Is(err, services.ErrInvalidMFAChallenge) {
+		httperrors.ErrInvalidMFAChallenge.Write(c)
+	} else if errors.Is(err, services.ErrAccountDisabled) {
+		httperrors.ErrAccountDisabled.Write(c)
+	} else if errors.Is(err, services.ErrPasswordResetRequired) {
+		httperrors.ErrPasswordResetRequired.Write(c)
+	} else if errors.Is(err, services.ErrTooManyLoginAttempts) {
+		httperrors.ErrTooManyLoginAttempts.Write(c)
+	} else {
+		httperrors.ErrInternalServerError.Write(c)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"knowstack/internal/core/services"
 	"knowstack/internal/utils"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -36,7 +37,10 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 	user, err := h.UserService.CreateUser(req)
 	if err != nil {
-		if errors.Is(err, services.ErrUsernameAlreadyExists) {
+		var policyErr *services.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			utils.WriteFieldError(c, "Password", policyErr.Rule, validation.CreateUserValidationMessages())
+		} else if errors.Is(err, services.ErrUsernameAlreadyExists) {
 			httperrors.ErrUsernameAlreadyExists.Write(c)
 		} else if errors.Is(err, services.ErrEmailAlreadyExists) {
 			httperrors.ErrEmailAlreadyExists.Write(c)
@@ -54,6 +58,8 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} dto.LoginResponse
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 429 {object} httperrors.HTTPError
 // @Router /users/login [post]
 // @Param user body dto.LoginRequest true "User to login"
 func (h *UserHandler) Login(c *gin.Context) {
@@ -61,12 +67,20 @@ func (h *UserHandler) Login(c *gin.Context) {
 	if ok := utils.BindJSONAndValidate(c, &req, validation.LoginValidationMessages()); !ok {
 		return
 	}
-	user, err := h.UserService.Login(req)
+	user, err := h.UserService.Login(req, sessionInfoFromRequest(c))
 	if err != nil {
-		if errors.Is(err, services.ErrUserNotFound) {
-			httperrors.ErrUserNotFound.Write(c)
-		} else if errors.Is(err, services.ErrInvalidPassword) {
-			httperrors.ErrInvalidPassword.Write(c)
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			httperrors.ErrInvalidCredentials.Write(c)
+		} else if errors.Is(err, services.ErrTooManyLoginAttempts) {
+			retryAfter := int(h.UserService.AuthConfig.LoginThrottle.IPWindow.Seconds())
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			httperrors.ErrTooManyLoginAttempts.Write(c)
+		} else if errors.Is(err, services.ErrEmailNotVerified) {
+			httperrors.ErrEmailNotVerified.Write(c)
+		} else if errors.Is(err, services.ErrAccountDisabled) {
+			httperrors.ErrAccountDisabled.Write(c)
+		} else if errors.Is(err, services.ErrPasswordResetRequired) {
+			httperrors.ErrPasswordResetRequired.Write(c)
 		} else {
 			httperrors.ErrInternalServerError.Write(c)
 		}
@@ -81,6 +95,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} dto.RefreshResponse
+// @Failure 401 {object} httperrors.HTTPError
 // @Router /users/refresh [post]
 // @Param user body dto.RefreshRequest true "User to refresh"
 func (h *UserHandler) Refresh(c *gin.Context) {
@@ -88,13 +103,22 @@ func (h *UserHandler) Refresh(c *gin.Context) {
 	if ok := utils.BindJSONAndValidate(c, &req, validation.RefreshValidationMessages()); !ok {
 		return
 	}
-	res, err := h.UserService.Refresh(req)
+	res, err := h.UserService.Refresh(req, sessionInfoFromRequest(c))
 	if err != nil {
-		if errors.Is(err, utils.ErrTokenExpired) {
+		if errors.Is(err, utils.ErrTokenExpired) || errors.Is(err, services.ErrRefreshTokenExpired) {
 			httperrors.ErrTokenExpired.Write(c)
+		} else if errors.Is(err, services.ErrRefreshTokenReused) {
+			httperrors.ErrRefreshTokenReused.Write(c)
+		} else if errors.Is(err, services.ErrTokenRevoked) {
+			httperrors.ErrTokenRevoked.Write(c)
+		} else if errors.Is(err, services.ErrAccountDisabled) {
+			httperrors.ErrAccountDisabled.Write(c)
+		} else if errors.Is(err, services.ErrPasswordResetRequired) {
+			httperrors.ErrPasswordResetRequired.Write(c)
 		} else {
-			c.AbortWithStatus(http.StatusUnauthorized)
+			httperrors.ErrInvalidRefreshToken.Write(c)
 		}
+		return
 	}
 	c.JSON(http.StatusOK, res)
 }
@@ -139,31 +163,186 @@ func (h *UserHandler) RequestPasswordReset(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
-// @Summary Set claims for a user
-// @Description Sets claims for a user
+// @Summary Validate a password reset token
+// @Description Checks whether a password reset token can still be redeemed
+// @Tags API User
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.ValidatePasswordResetTokenResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 410 {object} httperrors.HTTPError
+// @Router /users/validate-reset-token [post]
+// @Param token body dto.ValidatePasswordResetTokenRequest true "Password reset token to validate"
+func (h *UserHandler) ValidatePasswordResetToken(c *gin.Context) {
+	var req dto.ValidatePasswordResetTokenRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.ValidatePasswordResetTokenValidationMessages()); !ok {
+		return
+	}
+	res, err := h.UserService.ValidatePasswordResetToken(req)
+	if err != nil {
+		writePasswordResetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Reset password of a user
+// @Description Sets a new password using a password reset token and revokes all sessions of the user
+// @Tags API User
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.ResetPasswordResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 410 {object} httperrors.HTTPError
+// @Router /users/reset-password [post]
+// @Param user body dto.ResetPasswordRequest true "Password reset token and the new password"
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.ResetPasswordValidationMessages()); !ok {
+		return
+	}
+	res, err := h.UserService.ResetPassword(req)
+	if err != nil {
+		var policyErr *services.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			utils.WriteFieldError(c, "Password", policyErr.Rule, validation.ResetPasswordValidationMessages())
+			return
+		}
+		writePasswordResetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func writePasswordResetError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrResetTokenNotFound) {
+		httperrors.ErrInvalidResetToken.Write(c)
+	} else if errors.Is(err, services.ErrResetTokenExpired) {
+		httperrors.ErrResetTokenExpired.Write(c)
+	} else if errors.Is(err, services.ErrResetTokenUsed) {
+		httperrors.ErrResetTokenUsed.Write(c)
+	} else {
+		httperrors.ErrInternalServerError.Write(c)
+	}
+}
+
+// @Summary Verify the email of a user
+// @Description Verifies the email address using the token sent on registration
+// @Tags API User
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.VerifyEmailResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Failure 410 {object} httperrors.HTTPError
+// @Router /users/verify-email [post]
+// @Param token body dto.VerifyEmailRequest true "Email verification token"
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.VerifyEmailValidationMessages()); !ok {
+		return
+	}
+	res, err := h.UserService.VerifyEmail(req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			httperrors.ErrInvalidVerifyToken.Write(c)
+		} else if errors.Is(err, services.ErrVerificationTokenExpired) {
+			httperrors.ErrVerifyTokenExpired.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Unlock a locked account
+// @Description Lifts the lockout using the token emailed when the account was locked
+// @Tags API User
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.UnlockAccountResponse
+// @Failure 400 {object} httperrors.HTTPError
+// @Router /users/unlock [post]
+// @Param token body dto.UnlockAccountRequest true "Account unlock token"
+func (h *UserHandler) UnlockAccount(c *gin.Context) {
+	var req dto.UnlockAccountRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.UnlockAccountValidationMessages()); !ok {
+		return
+	}
+	res, err := h.UserService.UnlockAccount(req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUnlockToken) {
+			httperrors.ErrInvalidUnlockToken.Write(c)
+		} else {
+			httperrors.ErrInternalServerError.Write(c)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Resend the verification email
+// @Description Sends a new verification email if the account is not verified yet
+// @Tags API User
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.ResendVerificationEmailResponse
+// @Router /users/resend-verification [post]
+// @Param user body dto.ResendVerificationEmailRequest true "Email to send the verification to"
+func (h *UserHandler) ResendVerificationEmail(c *gin.Context) {
+	var req dto.ResendVerificationEmailRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.ResendVerificationEmailValidationMessages()); !ok {
+		return
+	}
+	res, err := h.UserService.ResendVerificationEmail(req)
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Change the password
+// @Description Changes the password of the authenticated user and signs out the other sessions.
+// @Description Users without a password, e.g. OAuth-only accounts, can set their first password without the current one.
 // @Tags API User
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} dto.SetClaimsResponse
-// @Router /users/claims [post]
-// @Param user body dto.SetClaimsRequest true "User to set claims for"
-func (h *UserHandler) SetClaims(c *gin.Context) {
-	var req dto.SetClaimsRequest
-	if ok := utils.BindJSONAndValidate(c, &req, validation.SetClaimsValidationMessages()); !ok {
+// @Param password body dto.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} dto.ChangePasswordResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 429 {object} httperrors.HTTPError
+// @Router /users/me/password [post]
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID, sessionID, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
 		return
 	}
-	err := h.UserService.SetClaims(req.UserID, req.ClaimIDs)
+	var req dto.ChangePasswordRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.ChangePasswordValidationMessages()); !ok {
+		return
+	}
+	res, err := h.UserService.ChangePassword(userID, sessionID, req, sessionInfoFromRequest(c))
 	if err != nil {
-		if errors.Is(err, services.ErrUserNotFound) {
+		var policyErr *services.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			utils.WriteFieldError(c, "NewPassword", policyErr.Rule, validation.ChangePasswordValidationMessages())
+		} else if errors.Is(err, services.ErrInvalidCurrentPassword) {
+			utils.WriteFieldError(c, "CurrentPassword", "invalid_current_password", validation.ChangePasswordValidationMessages())
+		} else if errors.Is(err, services.ErrTooManyLoginAttempts) {
+			retryAfter := int(h.UserService.AuthConfig.LoginThrottle.LockoutDuration.Seconds())
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			httperrors.ErrTooManyLoginAttempts.Write(c)
+		} else if errors.Is(err, services.ErrUserNotFound) {
 			httperrors.ErrUserNotFound.Write(c)
-		} else if errors.Is(err, services.ErrClaimsNotFound) {
-			httperrors.ErrClaimsNotFound.Write(c)
 		} else {
 			httperrors.ErrInternalServerError.Write(c)
 		}
 		return
 	}
-
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusOK, res)
 }
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/api/middleware"
+	"knowstack/internal/api/validation"
+	"knowstack/internal/core/services"
+	"knowstack/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List users
+// @Description Lists the users page by page. Username and email match partially, role and provider exactly.
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page, starting at 1"
+// @Param pageSize query int false "Page size, at most 100"
+// @Param username query string false "Part of the username"
+// @Param email query string false "Part of the email"
+// @Param role query string false "Role name"
+// @Param provider query string false "Login provider, e.g. local or google"
+// @Success 200 {object} dto.ListUsersResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 401 {object} httperrors.HTTPError
+// @Failure 403 {object} httperrors.HTTPError
+// @Router /admin/users [get]
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	var query dto.ListUsersQuery
+	if ok := utils.BindQueryAndValidate(c, &query, validation.ListUsersValidationMessages()); !ok {
+		return
+	}
+	res, err := h.UserService.ListUsers(query)
+	if err != nil {
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Get a user
+// @Description Returns the user with its role, direct and effective claims
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.AdminUserDetailResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /admin/users/{id} [get]
+func (h *UserHandler) GetUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.UserService.GetUser(userID)
+	if err != nil {
+		writeUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Change the role of a user
+// @Description Moves the user to another role. The caller must hold every claim of the role.
+// @Tags API Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param role body dto.SetUserRoleRequest true "New role"
+// @Success 200 {object} dto.AdminUserDetailResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /admin/users/{id}/role [put]
+func (h *UserHandler) SetRole(c *gin.Context) {
+	caller, callerID, ok := adminCaller(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.SetUserRoleRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.SetUserRoleValidationMessages()); !ok {
+		return
+	}
+	res, err := h.UserService.SetRole(callerID, caller.Claims, userID, req)
+	if err != nil {
+		writeUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Set claims for a user
+// @Description Replaces the direct claims of a user. The caller must hold every assigned claim.
+// @Tags API Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param user body dto.SetClaimsRequest true "Claims of the user"
+// @Success 200 {object} dto.SetClaimsResponse
+// @Failure 400 {object} httperrors.HTTPValidationError
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /admin/users/{id}/claims [put]
+func (h *UserHandler) SetClaims(c *gin.Context) {
+	caller, callerID, ok := adminCaller(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	var req dto.SetClaimsRequest
+	if ok := utils.BindJSONAndValidate(c, &req, validation.SetClaimsValidationMessages()); !ok {
+		return
+	}
+	req.UserID = userID
+	if err := h.UserService.SetClaims(callerID, caller.Claims, req.UserID, req.ClaimIDs); err != nil {
+		writeUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.SetClaimsResponse{Message: "Claims set successfully"})
+}
+
+// @Summary Disable a user
+// @Description Blocks every login of the user and ends its sessions
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.AdminUserDetailResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /admin/users/{id}/disable [post]
+func (h *UserHandler) DisableUser(c *gin.Context) {
+	_, callerID, ok := adminCaller(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.UserService.DisableUser(callerID, userID)
+	if err != nil {
+		writeUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Enable a user
+// @Description Lifts the disabling of the user
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.AdminUserDetailResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Failure 409 {object} httperrors.HTTPError
+// @Router /admin/users/{id}/enable [post]
+func (h *UserHandler) EnableUser(c *gin.Context) {
+	_, callerID, ok := adminCaller(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.UserService.EnableUser(callerID, userID)
+	if err != nil {
+		writeUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Force a password reset
+// @Description Ends the sessions of the user, blocks every login method until the password is reset and emails a reset link
+// @Tags API Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.ForcePasswordResetResponse
+// @Failure 403 {object} httperrors.HTTPError
+// @Failure 404 {object} httperrors.HTTPError
+// @Router /admin/users/{id}/password-reset [post]
+func (h *UserHandler) ForcePasswordReset(c *gin.Context) {
+	_, callerID, ok := adminCaller(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	res, err := h.UserService.ForcePasswordReset(callerID, userID)
+	if err != nil {
+		writeUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+/*
+Get the claims and the user ID of the admin making the request
+Writes an unauthorized response and returns false if the request is not authenticated
+*/
+func adminCaller(c *gin.Context) (*utils.TokenClaims, uint, bool) {
+	claims, ok := middleware.TokenClaimsFromContext(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return nil, 0, false
+	}
+	callerID, _, ok := authenticatedUser(c)
+	if !ok {
+		httperrors.ErrUnauthorized.Write(c)
+		return nil, 0, false
+	}
+	return claims, callerID, true
+}
+
+func userIDParam(c *gin.Context) (uint, bool) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httperrors.ErrInvalidRequest.Write(c)
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+func writeUserAdminError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrUserNotFound) {
+		httperrors.ErrUserNotFound.Write(c)
+	} else if errors.Is(err, services.ErrRoleNotFound) {
+		httperrors.ErrRoleNotFound.Write(c)
+	} else if errors.Is(err, services.ErrClaimsNotFound) {
+		httperrors.ErrClaimsNotFound.Write(c)
+	} else if errors.Is(err, services.ErrClaimNotGrantable) {
+		httperrors.ErrClaimNotGrantable.Write(c)
+	} else if errors.Is(err, services.ErrSelfAdministration) {
+		httperrors.ErrSelfAdministration.Write(c)
+	} else if errors.Is(err, services.ErrAccountAlreadyDisabled) {
+		httperrors.ErrAccountAlreadyDisabled.Write(c)
+	} else if errors.Is(err, services.ErrAccountNotDisabled) {
+		httperrors.ErrAccountNotDisabled.Write(c)
+	} else {
+		httperrors.ErrInternalServerError.Write(c)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"knowstack/internal/api/httperrors"
+	"knowstack/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WellKnownHandler struct {
+}
+
+func NewWellKnownHandler() *WellKnownHandler {
+	return &WellKnownHandler{}
+}
+
+/*
+Publish the public keys access tokens are verified with (RFC 7517).
+Retiring keys stay in the set until they are removed from the keyset,
+so downstream services can verify tokens issued before a rotation.
+The route is served outside of /api/v1 and is not part of the swagger document.
+*/
+func (h *WellKnownHandler) JWKS(c *gin.Context) {
+	ks, err := utils.CurrentKeySet()
+	if err != nil {
+		utils.LogErrorWithErr("Failed to load the signing keyset", err)
+		httperrors.ErrInternalServerError.Write(c)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, ks.JWKS())
+}
@@ -5,12 +5,72 @@ import (
 )
 
 var (
-	ErrInvalidRequest        = NewHTTPError(http.StatusBadRequest, "invalid_request", "Geçersiz istek")
-	ErrInternalServerError   = NewHTTPError(http.StatusInternalServerError, "internal_server_error", "Sunucu hatası")
-	ErrUsernameAlreadyExists = NewHTTPError(http.StatusConflict, "username_already_exists", "Kullanıcı adı zaten kullanılıyor")
-	ErrEmailAlreadyExists    = NewHTTPError(http.StatusConflict, "email_already_exists", "E-posta zaten kullanılıyor")
-	ErrUserNotFound          = NewHTTPError(http.StatusNotFound, "user_not_found", "Kullanıcı bulunamadı")
-	ErrInvalidPassword       = NewHTTPError(http.StatusBadRequest, "invalid_password", "Geçersiz şifre")
-	ErrClaimsNotFound        = NewHTTPError(http.StatusNotFound, "claims_not_found", "Yetkinlikler bulunamadı")
-	ErrTokenExpired          = NewHTTPError(http.StatusUnauthorized, "token_expired", "Token süresi dolmuş.")
+	ErrInvalidRequest              = NewHTTPError(http.StatusBadRequest, "invalid_request", "Geçersiz istek")
+	ErrInternalServerError         = NewHTTPError(http.StatusInternalServerError, "internal_server_error", "Sunucu hatası")
+	ErrUnauthorized                = NewHTTPError(http.StatusUnauthorized, "unauthorized", "Yetkisiz erişim")
+	ErrForbidden                   = NewHTTPError(http.StatusForbidden, "forbidden", "Bu işlem için yetkiniz yok")
+	ErrUsernameAlreadyExists       = NewHTTPError(http.StatusConflict, "username_already_exists", "Kullanıcı adı zaten kullanılıyor")
+	ErrEmailAlreadyExists          = NewHTTPError(http.StatusConflict, "email_already_exists", "E-posta zaten kullanılıyor")
+	ErrUserNotFound                = NewHTTPError(http.StatusNotFound, "user_not_found", "Kullanıcı bulunamadı")
+	ErrInvalidPassword             = NewHTTPError(http.StatusBadRequest, "invalid_password", "Geçersiz şifre")
+	ErrInvalidCredentials          = NewHTTPError(http.StatusUnauthorized, "invalid_credentials", "Geçersiz e-posta veya şifre")
+	ErrTooManyLoginAttempts        = NewHTTPError(http.StatusTooManyRequests, "too_many_login_attempts", "Çok fazla başarısız giriş denemesi, lütfen daha sonra tekrar deneyin")
+	ErrInvalidUnlockToken          = NewHTTPError(http.StatusBadRequest, "invalid_unlock_token", "Geçersiz veya süresi dolmuş hesap kilidi açma bağlantısı")
+	ErrAccountNotLocked            = NewHTTPError(http.StatusConflict, "account_not_locked", "Hesap kilitli değil")
+	ErrClaimsNotFound              = NewHTTPError(http.StatusNotFound, "claims_not_found", "Yetkinlikler bulunamadı")
+	ErrTokenExpired                = NewHTTPError(http.StatusUnauthorized, "token_expired", "Token süresi dolmuş.")
+	ErrInvalidRefreshToken         = NewHTTPError(http.StatusUnauthorized, "invalid_refresh_token", "Geçersiz refresh token")
+	ErrRefreshTokenReused          = NewHTTPError(http.StatusUnauthorized, "refresh_token_reused", "Refresh token tekrar kullanıldı, tüm oturumlar sonlandırıldı")
+	ErrTokenRevoked                = NewHTTPError(http.StatusUnauthorized, "token_revoked", "Token iptal edilmiş")
+	ErrEmailNotVerified            = NewHTTPError(http.StatusForbidden, "email_not_verified", "E-posta adresi doğrulanmamış")
+	ErrInvalidVerifyToken          = NewHTTPError(http.StatusBadRequest, "invalid_verification_token", "Geçersiz e-posta doğrulama bağlantısı")
+	ErrVerifyTokenExpired          = NewHTTPError(http.StatusGone, "verification_token_expired", "E-posta doğrulama bağlantısının süresi dolmuş")
+	ErrTwoFactorAlreadyEnabled     = NewHTTPError(http.StatusConflict, "two_factor_already_enabled", "İki adımlı doğrulama zaten etkin")
+	ErrTwoFactorNotEnabled         = NewHTTPError(http.StatusBadRequest, "two_factor_not_enabled", "İki adımlı doğrulama etkin değil")
+	ErrInvalidTwoFactorCode        = NewHTTPError(http.StatusBadRequest, "invalid_two_factor_code", "Geçersiz doğrulama kodu")
+	ErrInvalidMFAChallenge         = NewHTTPError(http.StatusUnauthorized, "invalid_mfa_challenge", "Geçersiz MFA tokenı")
+	ErrPasskeysUnavailable         = NewHTTPError(http.StatusServiceUnavailable, "passkeys_unavailable", "Passkey girişi yapılandırılmamış")
+	ErrPasskeyNotFound             = NewHTTPError(http.StatusNotFound, "passkey_not_found", "Passkey bulunamadı")
+	ErrInvalidPasskeyChallenge     = NewHTTPError(http.StatusBadRequest, "invalid_passkey_challenge", "Geçersiz veya süresi dolmuş passkey oturumu")
+	ErrPasskeyVerification         = NewHTTPError(http.StatusUnauthorized, "passkey_verification_failed", "Passkey doğrulanamadı")
+	ErrOAuthProviderNotFound       = NewHTTPError(http.StatusNotFound, "oauth_provider_not_found", "OAuth sağlayıcısı bulunamadı")
+	ErrOAuthProviderUnavailable    = NewHTTPError(http.StatusBadGateway, "oauth_provider_unavailable", "OAuth sağlayıcısına ulaşılamıyor")
+	ErrIdentityNotFound            = NewHTTPError(http.StatusNotFound, "identity_not_found", "Bağlı hesap bulunamadı")
+	ErrLastLoginMethod             = NewHTTPError(http.StatusConflict, "last_login_method", "Son giriş yöntemi kaldırılamaz")
+	ErrPersonalAccessTokenNotFound = NewHTTPError(http.StatusNotFound, "personal_access_token_not_found", "Erişim tokenı bulunamadı")
+	ErrInvalidTokenScope           = NewHTTPError(http.StatusBadRequest, "invalid_token_scope", "İstenen yetki bilinmiyor veya kullanıcıya tanımlı değil")
+	ErrImpersonationNotAllowed     = NewHTTPError(http.StatusForbidden, "impersonation_not_allowed", "Bu kullanıcının yerine geçilemez")
+	ErrNotImpersonating            = NewHTTPError(http.StatusBadRequest, "not_impersonating", "Token bir kullanıcı yerine geçme tokenı değil")
+	ErrMagicLinkDisabled           = NewHTTPError(http.StatusNotFound, "magic_link_disabled", "Bağlantı ile giriş etkin değil")
+	ErrInvalidMagicLink            = NewHTTPError(http.StatusBadRequest, "invalid_magic_link", "Geçersiz veya kullanılmış giriş bağlantısı")
+	ErrMagicLinkExpired            = NewHTTPError(http.StatusGone, "magic_link_expired", "Giriş bağlantısının süresi dolmuş")
+	ErrMagicLinkBrowserMismatch    = NewHTTPError(http.StatusForbidden, "magic_link_browser_mismatch", "Giriş bağlantısı istendiği tarayıcıda açılmalıdır")
+	ErrAccountDeleted              = NewHTTPError(http.StatusGone, "account_deleted", "Hesap silinmiş")
+	ErrDeletionNotRequested        = NewHTTPError(http.StatusConflict, "deletion_not_requested", "Hesap silme talebi bulunmuyor")
+	ErrAccountDisabled             = NewHTTPError(http.StatusForbidden, "account_disabled", "Hesap devre dışı bırakılmış")
+	ErrPasswordResetRequired       = NewHTTPError(http.StatusForbidden, "password_reset_required", "Giriş yapmadan önce şifrenizi sıfırlamanız gerekiyor")
+	ErrAccountAlreadyDisabled      = NewHTTPError(http.StatusConflict, "account_already_disabled", "Hesap zaten devre dışı")
+	ErrAccountNotDisabled          = NewHTTPError(http.StatusConflict, "account_not_disabled", "Hesap devre dışı değil")
+	ErrClaimNotGrantable           = NewHTTPError(http.StatusForbidden, "claim_not_grantable", "Sahip olmadığınız bir yetkinlik atanamaz")
+	ErrSelfAdministration          = NewHTTPError(http.StatusForbidden, "self_administration", "Kendi hesabınızda bu işlem yapılamaz")
+	ErrClaimNotFound               = NewHTTPError(http.StatusNotFound, "claim_not_found", "Yetkinlik bulunamadı")
+	ErrClaimAlreadyExists          = NewHTTPError(http.StatusConflict, "claim_already_exists", "Yetkinlik adı zaten kullanılıyor")
+	ErrWildcardClaimName           = NewHTTPError(http.StatusBadRequest, "wildcard_claim_name", "Joker karakterli yetkinlikler oluşturulamaz veya yeniden adlandırılamaz")
+	ErrRoleNotFound                = NewHTTPError(http.StatusNotFound, "role_not_found", "Rol bulunamadı")
+	ErrRoleAlreadyExists           = NewHTTPError(http.StatusConflict, "role_already_exists", "Rol adı zaten kullanılıyor")
+	ErrRoleHasMembers              = NewHTTPError(http.StatusConflict, "role_has_members", "Rolün üyeleri var, kullanıcıları başka bir role aktarın")
+	ErrDefaultRoleDeletion         = NewHTTPError(http.StatusConflict, "default_role_deletion", "Varsayılan rol silinemez")
+	ErrInvalidReassignRole         = NewHTTPError(http.StatusBadRequest, "invalid_reassign_role", "Kullanıcılar silinen role aktarılamaz")
+	ErrReassignRoleNotFound        = NewHTTPError(http.StatusNotFound, "reassign_role_not_found", "Kullanıcıların aktarılacağı rol bulunamadı")
+	ErrProtectedRole               = NewHTTPError(http.StatusConflict, "protected_role", "Organizasyon rolleri yeniden adlandırılamaz veya silinemez")
+	ErrSessionNotFound             = NewHTTPError(http.StatusNotFound, "session_not_found", "Oturum bulunamadı")
+	ErrInvalidResetToken           = NewHTTPError(http.StatusBadRequest, "invalid_reset_token", "Geçersiz şifre sıfırlama bağlantısı")
+	ErrResetTokenExpired           = NewHTTPError(http.StatusGone, "reset_token_expired", "Şifre sıfırlama bağlantısının süresi dolmuş")
+	ErrResetTokenUsed              = NewHTTPError(http.StatusGone, "reset_token_used", "Şifre sıfırlama bağlantısı zaten kullanılmış")
+	ErrOrganizationNotFound        = NewHTTPError(http.StatusNotFound, "organization_not_found", "Organizasyon bulunamadı")
+	ErrOrganizationAlreadyExists   = NewHTTPError(http.StatusConflict, "organization_already_exists", "Organizasyon adı zaten kullanılıyor")
+	ErrOrganizationMemberNotFound  = NewHTTPError(http.StatusNotFound, "organization_member_not_found", "Kullanıcı organizasyonun üyesi değil")
+	ErrAlreadyOrganizationMember   = NewHTTPError(http.StatusConflict, "already_organization_member", "Kullanıcı zaten organizasyonun üyesi")
+	ErrLastOrganizationOwner       = NewHTTPError(http.StatusConflict, "last_organization_owner", "Organizasyonun son sahibi kaldırılamaz")
+	ErrInvitationNotFound          = NewHTTPError(http.StatusNotFound, "organization_invitation_not_found", "Davet bulunamadı")
 )
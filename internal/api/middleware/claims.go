@@ -1,40 +1,41 @@
 package middleware
 
 import (
-	"knowstack/internal/utils"
 	"net/http"
 
+	"knowstack/internal/core/authz"
+	"knowstack/internal/utils"
+
 	"github.com/gin-gonic/gin"
 )
 
-// RequireClaims ensures the authenticated user has ALL of the required claim IDs.
+// RequireClaims ensures the authenticated user has ALL of the required claims.
+// Wildcard grants like "user:*" and implied claims count, see authz.ClaimSet.
+// In an organization the claims of the user's role in it count as well.
 // It assumes JWTMiddleware has already set "claims" in the context.
 func RequireClaims(required ...string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		raw, exists := c.Get("claims")
-		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
+	return Require(authz.AllClaims(required...))
+}
+
+// RequireAnyClaim ensures the authenticated user has at least one of the claims.
+func RequireAnyClaim(names ...string) gin.HandlerFunc {
+	return Require(authz.AnyClaim(names...))
+}
 
-		tokenClaims, ok := raw.(*utils.TokenClaims)
+// Require ensures the claims of the authenticated user satisfy the requirement,
+// e.g. Require(authz.AnyOf(authz.Claim("user:read"), authz.AllClaims("role:read", "claim:read"))).
+func Require(requirement authz.Requirement) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenClaims, ok := TokenClaimsFromContext(c)
 		if !ok {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
 
-		// Build a set for quick lookup
-		claimSet := make(map[string]struct{}, len(tokenClaims.Claims))
-		for _, name := range tokenClaims.Claims {
-			claimSet[name] = struct{}{}
-		}
-
-		// Verify all required claims are present
-		for _, need := range required {
-			if _, ok := claimSet[need]; !ok {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
-				return
-			}
+		if !requirement.SatisfiedBy(authz.NewClaimSet(tokenClaims.GrantedClaims())) {
+			utils.LogInfo("Claims requirement not satisfied", "userID", tokenClaims.UserID, "requirement", requirement.String())
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
 		}
 
 		c.Next()
@@ -1,17 +1,43 @@
 package middleware
 
 import (
+	"strconv"
+	"strings"
+
 	"knowstack/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func JWTMiddleware() gin.HandlerFunc {
+// TokenRevocationChecker reports whether an access token was revoked by its jti
+type TokenRevocationChecker interface {
+	IsRevoked(jti string) (bool, error)
+}
+
+// PersonalAccessTokenVerifier authenticates a personal access token and returns the claims of its scopes
+type PersonalAccessTokenVerifier interface {
+	VerifyPersonalAccessToken(token string) (*utils.TokenClaims, error)
+}
+
+// OrganizationHeader names the active organization of a request on the routes using ActiveOrganization
+const OrganizationHeader = "X-Organization-ID"
+
+// OrganizationParam is the path parameter naming the active organization
+const OrganizationParam = "orgID"
+
+// OrganizationResolver grants the request the claims of the user's role in the organization
+type OrganizationResolver interface {
+	// ResolveOrganization returns the claims in the organization, or false if the user is not a member of it
+	ResolveOrganization(claims *utils.TokenClaims, organizationID uint) (*utils.TokenClaims, bool, error)
+}
+
+// JWTMiddleware authenticates the request with an access JWT or a personal access token.
+// Personal access tokens only carry their scopes as claims, which RequireClaims enforces.
+func JWTMiddleware(revocations TokenRevocationChecker, personalAccessTokens PersonalAccessTokenVerifier) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		utils.LogInfo("JWT Middleware")
 
 		token := ctx.GetHeader("Authorization")
-		utils.LogInfo("Token: %+v", token)
 
 		if token == "" {
 			utils.LogInfo("token is empty")
@@ -24,13 +50,162 @@ func JWTMiddleware() gin.HandlerFunc {
 			ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
 			return
 		}
+		if strings.HasPrefix(token, utils.PersonalAccessTokenPrefix) {
+			claims, err := personalAccessTokens.VerifyPersonalAccessToken(token)
+			if err != nil {
+				utils.LogErrorWithErr("Failed to verify personal access token", err)
+				ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+				return
+			}
+			ctx.Set("claims", claims)
+			ctx.Next()
+			return
+		}
 		claims, err := utils.VerifyAccessToken(token)
 		if err != nil {
 			utils.LogErrorWithErr("Failed to verify JWT", err)
 			ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
 			return
 		}
+		revoked, err := revocations.IsRevoked(claims.ID)
+		if err != nil {
+			utils.LogErrorWithErr("Failed to check token revocation", err)
+			ctx.AbortWithStatusJSON(503, gin.H{"error": "Service Unavailable"})
+			return
+		}
+		if revoked {
+			utils.LogInfo("Revoked token used", "userID", claims.UserID, "jti", claims.ID)
+			ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+			return
+		}
 		ctx.Set("claims", claims)
 		ctx.Next()
 	}
 }
+
+// ActiveOrganization resolves the active organization of the request from the path or the
+// X-Organization-ID header, so RequireClaims also evaluates the claims granted in it.
+// It must follow JWTMiddleware and only guard the routes acting in an organization,
+// the other routes ignore the header.
+func ActiveOrganization(organizations OrganizationResolver) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, ok := TokenClaimsFromContext(ctx)
+		if !ok {
+			ctx.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+			return
+		}
+		organizationID, ok := requestedOrganization(ctx)
+		if !ok {
+			ctx.AbortWithStatusJSON(400, gin.H{"error": "Invalid organization"})
+			return
+		}
+		if organizationID == 0 {
+			ctx.Next()
+			return
+		}
+
+		resolved, member, err := organizations.ResolveOrganization(claims, organizationID)
+		if err != nil {
+			utils.LogErrorWithErr("Failed to resolve organization", err)
+			ctx.AbortWithStatusJSON(503, gin.H{"error": "Service Unavailable"})
+			return
+		}
+		if !member {
+			utils.LogInfo("Organization of a non member requested", "userID", claims.UserID, "organizationID", organizationID)
+			ctx.AbortWithStatusJSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		ctx.Set("claims", resolved)
+		ctx.Next()
+	}
+}
+
+// requestedOrganization reads the active organization from the path, or else from the header.
+// Returns zero when the request names no organization and false when it names an invalid one
+// or the path and the header disagree.
+func requestedOrganization(ctx *gin.Context) (uint, bool) {
+	var organizationID uint
+	for _, value := range []string{ctx.Param(OrganizationParam), ctx.GetHeader(OrganizationHeader)} {
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || id == 0 {
+			return 0, false
+		}
+		if organizationID != 0 && organizationID != uint(id) {
+			return 0, false
+		}
+		organizationID = uint(id)
+	}
+	return organizationID, true
+}
+
+// TokenClaimsFromContext returns the access token claims set by JWTMiddleware.
+func TokenClaimsFromContext(c *gin.Context) (*utils.TokenClaims, bool) {
+	raw, exists := c.Get("claims")
+	if !exists {
+		return nil, false
+	}
+	tokenClaims, ok := raw.(*utils.TokenClaims)
+	return tokenClaims, ok
+}
+
+// RequireSession rejects requests authenticated with a personal access token.
+// It guards account management, which must not be reachable with automation tokens.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenClaims, ok := TokenClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+			return
+		}
+		if tokenClaims.PersonalAccessTokenID != 0 {
+			c.AbortWithStatusJSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// PlatformScope drops the active organization of the request, so only the claims granted
+// outside of the organizations count. It guards the administration of the deployment itself.
+func PlatformScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenClaims, ok := TokenClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+			return
+		}
+		if tokenClaims.OrganizationID != 0 {
+			platformClaims := *tokenClaims
+			platformClaims.OrganizationID = 0
+			platformClaims.OrganizationRoleID = 0
+			platformClaims.OrganizationClaims = nil
+			c.Set("claims", &platformClaims)
+		}
+		c.Next()
+	}
+}
+
+// DenyImpersonation rejects requests made with an impersonation token.
+// It guards credential and account changes that only the user may make.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenClaims, ok := TokenClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+			return
+		}
+		if tokenClaims.Actor != nil {
+			utils.LogWarn("Impersonation token used on a protected endpoint",
+				"actorID", tokenClaims.Actor.UserID,
+				"subjectID", tokenClaims.UserID,
+				"path", c.FullPath(),
+			)
+			c.AbortWithStatusJSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"knowstack/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeOrganizations grants the claims of a single membership
+type fakeOrganizations struct {
+	organizationID uint
+	claims         []string
+	resolved       []uint
+}
+
+func (f *fakeOrganizations) ResolveOrganization(claims *utils.TokenClaims, organizationID uint) (*utils.TokenClaims, bool, error) {
+	f.resolved = append(f.resolved, organizationID)
+	if organizationID != f.organizationID {
+		return nil, false, nil
+	}
+	resolved := *claims
+	resolved.OrganizationID = organizationID
+	resolved.OrganizationClaims = f.claims
+	return &resolved, true, nil
+}
+
+// newOrganizationTestRouter mirrors the router: ActiveOrganization only guards the organization routes
+func newOrganizationTestRouter(organizations OrganizationResolver) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	authenticated := engine.Group("", func(c *gin.Context) {
+		c.Set("claims", &utils.TokenClaims{UserID: "1", Claims: []string{"user:read"}})
+	})
+
+	report := func(c *gin.Context) {
+		claims, _ := TokenClaimsFromContext(c)
+		c.JSON(http.StatusOK, gin.H{
+			"organization": claims.OrganizationID,
+			"claims":       claims.GrantedClaims(),
+		})
+	}
+	authenticated.GET("/users/me", report)
+	authenticated.GET("/organizations/:"+OrganizationParam, ActiveOrganization(organizations), report)
+	return engine
+}
+
+func TestActiveOrganization(t *testing.T) {
+	tests := []struct {
+		name             string
+		path             string
+		header           string
+		wantStatus       int
+		wantOrganization uint
+		wantResolved     bool
+	}{
+		{name: "organization of the path", path: "/organizations/7", wantStatus: http.StatusOK, wantOrganization: 7, wantResolved: true},
+		{name: "header matching the path", path: "/organizations/7", header: "7", wantStatus: http.StatusOK, wantOrganization: 7, wantResolved: true},
+		{name: "header naming another organization", path: "/organizations/7", header: "8", wantStatus: http.StatusBadRequest},
+		{name: "invalid organization", path: "/organizations/acme", wantStatus: http.StatusBadRequest},
+		{name: "not a member", path: "/organizations/9", wantStatus: http.StatusForbidden, wantResolved: true},
+		// Outside of the organization routes the header is ignored, the request has platform claims only
+		{name: "header on another route", path: "/users/me", header: "7", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			organizations := &fakeOrganizations{organizationID: 7, claims: []string{"organization:read"}}
+			router := newOrganizationTestRouter(organizations)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(OrganizationHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if resolved := len(organizations.resolved) > 0; resolved != tt.wantResolved {
+				t.Errorf("organization resolved = %t, want %t", resolved, tt.wantResolved)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Organization uint     `json:"organization"`
+				Claims       []string `json:"claims"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Organization != tt.wantOrganization {
+				t.Errorf("organization = %d, want %d", body.Organization, tt.wantOrganization)
+			}
+			if granted := slices.Contains(body.Claims, "organization:read"); granted != (tt.wantOrganization != 0) {
+				t.Errorf("claims = %v, organization claims granted = %t", body.Claims, granted)
+			}
+		})
+	}
+}
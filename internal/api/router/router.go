@@ -13,8 +13,11 @@ import (
 )
 
 type Router struct {
-	Handlers *handlers.Handlers
-	Gin      *gin.Engine
+	Handlers             *handlers.Handlers
+	Gin                  *gin.Engine
+	TokenDenylist        middleware.TokenRevocationChecker
+	PersonalAccessTokens middleware.PersonalAccessTokenVerifier
+	Organizations        middleware.OrganizationResolver
 }
 
 /*
@@ -22,8 +25,11 @@ Creates a new router instance
 */
 func NewRouter(service *services.Service) *Router {
 	return &Router{
-		Handlers: handlers.NewHandlers(service),
-		Gin:      gin.New(),
+		Handlers:             handlers.NewHandlers(service),
+		Gin:                  gin.New(),
+		TokenDenylist:        service.TokenDenylist,
+		PersonalAccessTokens: service.PersonalAccessTokenService,
+		Organizations:        service.OrganizationService,
 	}
 }
 
@@ -46,7 +52,21 @@ func (r *Router) Setup() error {
 	// Setup the routes for the API version 1
 	r.setupHealthRoutes(v1)
 	r.setupUserRoutes(v1)
+	r.setupAccountRoutes(v1)
 	r.setupOAuthRoutes(v1)
+	r.setupSessionRoutes(v1)
+	r.setupTwoFactorRoutes(v1)
+	r.setupPasskeyRoutes(v1)
+	r.setupIdentityRoutes(v1)
+	r.setupPersonalAccessTokenRoutes(v1)
+	r.setupAdminRoutes(v1)
+	r.setupImpersonationRoutes(v1)
+	r.setupRoleRoutes(v1)
+	r.setupClaimRoutes(v1)
+	r.setupOrganizationRoutes(v1)
+
+	// Setup the well-known routes at the root, outside of the API versioning
+	r.setupWellKnownRoutes(&r.Gin.RouterGroup)
 
 	// Setup the swagger routes
 	r.Gin.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -74,6 +94,24 @@ func (r *Router) setupUserRoutes(rg *gin.RouterGroup) {
 	user.POST("/refresh", r.Handlers.UserHandler.Refresh)
 	user.POST("/logout", r.Handlers.UserHandler.Logout)
 	user.POST("/request-password-reset", r.Handlers.UserHandler.RequestPasswordReset)
+	user.POST("/validate-reset-token", r.Handlers.UserHandler.ValidatePasswordResetToken)
+	user.POST("/reset-password", r.Handlers.UserHandler.ResetPassword)
+	user.POST("/verify-email", r.Handlers.UserHandler.VerifyEmail)
+	user.POST("/resend-verification", r.Handlers.UserHandler.ResendVerificationEmail)
+	user.POST("/unlock", r.Handlers.UserHandler.UnlockAccount)
+	user.POST("/magic-link", r.Handlers.UserHandler.RequestMagicLink)
+	user.POST("/magic-link/verify", r.Handlers.UserHandler.VerifyMagicLink)
+}
+
+/*
+Setup the account routes of the authenticated user for the API version 1
+*/
+func (r *Router) setupAccountRoutes(rg *gin.RouterGroup) {
+	account := rg.Group("/users/me", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens), middleware.RequireSession(), middleware.DenyImpersonation())
+	account.POST("/password", r.Handlers.UserHandler.ChangePassword)
+	account.GET("/export", r.Handlers.UserHandler.ExportData)
+	account.POST("/deletion", r.Handlers.UserHandler.RequestAccountDeletion)
+	account.DELETE("/deletion", r.Handlers.UserHandler.CancelAccountDeletion)
 }
 
 /*
@@ -81,6 +119,175 @@ Setup the oauth routes for the API version 1
 */
 func (r *Router) setupOAuthRoutes(rg *gin.RouterGroup) {
 	oauth := rg.Group("/oauth")
-	oauth.GET("/google/login", r.Handlers.OAuthHandler.GoogleLogin)
-	oauth.GET("/google/callback", r.Handlers.OAuthHandler.GoogleCallback)
+	oauth.GET("/:provider/login", r.Handlers.OAuthHandler.Login)
+	oauth.GET("/:provider/callback", r.Handlers.OAuthHandler.Callback)
+	oauth.POST("/introspect", r.Handlers.TokenHandler.Introspect)
+	oauth.POST("/revoke", r.Handlers.TokenHandler.Revoke)
+}
+
+/*
+Setup the session routes of the authenticated user for the API version 1
+*/
+func (r *Router) setupSessionRoutes(rg *gin.RouterGroup) {
+	sessions := rg.Group("/users/me/sessions", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens), middleware.RequireSession(), middleware.DenyImpersonation())
+	sessions.GET("", r.Handlers.SessionHandler.ListSessions)
+	sessions.DELETE("/:id", r.Handlers.SessionHandler.RevokeSession)
+	sessions.POST("/revoke-others", r.Handlers.SessionHandler.RevokeOtherSessions)
+	sessions.POST("/revoke-all", r.Handlers.SessionHandler.RevokeAllSessions)
+}
+
+/*
+Setup the two-factor authentication routes for the API version 1
+*/
+func (r *Router) setupTwoFactorRoutes(rg *gin.RouterGroup) {
+	rg.POST("/users/login/2fa", r.Handlers.TwoFactorHandler.VerifyLogin)
+
+	twoFactor := rg.Group("/users/me/2fa", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens), middleware.RequireSession(), middleware.DenyImpersonation())
+	twoFactor.POST("/enroll", r.Handlers.TwoFactorHandler.Enroll)
+	twoFactor.POST("/confirm", r.Handlers.TwoFactorHandler.Confirm)
+	twoFactor.POST("/disable", r.Handlers.TwoFactorHandler.Disable)
+	twoFactor.POST("/recovery-codes", r.Handlers.TwoFactorHandler.RegenerateRecoveryCodes)
+}
+
+/*
+Setup the passkey routes for the API version 1
+*/
+func (r *Router) setupPasskeyRoutes(rg *gin.RouterGroup) {
+	rg.POST("/users/passkeys/login/begin", r.Handlers.PasskeyHandler.BeginLogin)
+	rg.POST("/users/passkeys/login/finish", r.Handlers.PasskeyHandler.FinishLogin)
+
+	passkeys := rg.Group("/users/me/passkeys", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens), middleware.RequireSession(), middleware.DenyImpersonation())
+	passkeys.GET("", r.Handlers.PasskeyHandler.ListPasskeys)
+	passkeys.DELETE("/:id", r.Handlers.PasskeyHandler.DeletePasskey)
+	passkeys.POST("/register/begin", r.Handlers.PasskeyHandler.BeginRegistration)
+	passkeys.POST("/register/finish", r.Handlers.PasskeyHandler.FinishRegistration)
+}
+
+/*
+Setup the linked identity routes for the API version 1
+*/
+func (r *Router) setupIdentityRoutes(rg *gin.RouterGroup) {
+	identities := rg.Group("/users/me/identities", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens), middleware.RequireSession(), middleware.DenyImpersonation())
+	identities.GET("", r.Handlers.IdentityHandler.ListIdentities)
+	identities.POST("/:provider/link", r.Handlers.IdentityHandler.LinkIdentity)
+	identities.DELETE("/:id", r.Handlers.IdentityHandler.UnlinkIdentity)
+}
+
+/*
+Setup the personal access token routes for the API version 1
+*/
+func (r *Router) setupPersonalAccessTokenRoutes(rg *gin.RouterGroup) {
+	tokens := rg.Group("/users/me/tokens", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens), middleware.RequireSession(), middleware.DenyImpersonation())
+	tokens.GET("", r.Handlers.PersonalAccessTokenHandler.ListTokens)
+	tokens.POST("", r.Handlers.PersonalAccessTokenHandler.CreateToken)
+	tokens.DELETE("/:id", r.Handlers.PersonalAccessTokenHandler.DeleteToken)
+}
+
+/*
+Setup the admin routes for the API version 1
+*/
+func (r *Router) setupAdminRoutes(rg *gin.RouterGroup) {
+	admin := rg.Group("/admin", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens), middleware.PlatformScope())
+
+	lockouts := admin.Group("/lockouts", middleware.RequireClaims("user:update"))
+	lockouts.GET("", r.Handlers.UserHandler.ListLockouts)
+	lockouts.POST("/:id/unlock", r.Handlers.UserHandler.UnlockLockout)
+
+	users := admin.Group("/users")
+	users.GET("", middleware.RequireClaims("user:read"), r.Handlers.UserHandler.ListUsers)
+	users.GET("/:id", middleware.RequireClaims("user:read"), r.Handlers.UserHandler.GetUser)
+	users.PUT("/:id/role", middleware.RequireClaims("user:update"), r.Handlers.UserHandler.SetRole)
+	users.PUT("/:id/claims", middleware.RequireClaims("user:update"), r.Handlers.UserHandler.SetClaims)
+	users.POST("/:id/disable", middleware.RequireClaims("user:update"), r.Handlers.UserHandler.DisableUser)
+	users.POST("/:id/enable", middleware.RequireClaims("user:update"), r.Handlers.UserHandler.EnableUser)
+	users.POST("/:id/password-reset", middleware.RequireClaims("user:update"), r.Handlers.UserHandler.ForcePasswordReset)
+
+	admin.GET("/users/:id/export", middleware.RequireClaims("user:export"), r.Handlers.UserHandler.AdminExportData)
+	admin.POST("/users/:id/deletion", middleware.RequireClaims("user:delete"), r.Handlers.UserHandler.AdminDeleteAccount)
+	admin.DELETE("/users/:id/deletion", middleware.RequireClaims("user:delete"), r.Handlers.UserHandler.AdminCancelAccountDeletion)
+
+	// Impersonation must be started from the admin's own login session
+	admin.POST("/users/:id/impersonate",
+		middleware.RequireSession(),
+		middleware.DenyImpersonation(),
+		middleware.RequireClaims(services.ImpersonateClaim),
+		r.Handlers.ImpersonationHandler.Impersonate,
+	)
+	admin.GET("/impersonations", middleware.RequireClaims(services.ImpersonateClaim), r.Handlers.ImpersonationHandler.ListImpersonations)
+}
+
+/*
+Setup the impersonation routes of the authenticated user for the API version 1
+*/
+func (r *Router) setupImpersonationRoutes(rg *gin.RouterGroup) {
+	impersonation := rg.Group("/users/me/impersonation", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens))
+	impersonation.POST("/end", r.Handlers.ImpersonationHandler.EndImpersonation)
+}
+
+/*
+Setup the well-known routes
+*/
+func (r *Router) setupWellKnownRoutes(rg *gin.RouterGroup) {
+	wellKnown := rg.Group("/.well-known")
+	wellKnown.GET("/jwks.json", r.Handlers.WellKnownHandler.JWKS)
+}
+
+/*
+Setup the role management routes for the API version 1
+*/
+func (r *Router) setupRoleRoutes(rg *gin.RouterGroup) {
+	roles := rg.Group("/admin/roles", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens), middleware.PlatformScope())
+	roles.GET("", middleware.RequireClaims("role:read"), r.Handlers.RoleHandler.ListRoles)
+	roles.GET("/:id", middleware.RequireClaims("role:read"), r.Handlers.RoleHandler.GetRole)
+	roles.POST("", middleware.RequireClaims("role:write"), r.Handlers.RoleHandler.CreateRole)
+	roles.PUT("/:id", middleware.RequireClaims("role:update"), r.Handlers.RoleHandler.RenameRole)
+	roles.DELETE("/:id", middleware.RequireClaims("role:delete"), r.Handlers.RoleHandler.DeleteRole)
+	roles.POST("/:id/default", middleware.RequireClaims("role:update"), r.Handlers.RoleHandler.SetDefaultRole)
+	roles.POST("/:id/claims", middleware.RequireClaims("role:update"), r.Handlers.RoleHandler.AttachClaims)
+	roles.DELETE("/:id/claims", middleware.RequireClaims("role:update"), r.Handlers.RoleHandler.DetachClaims)
+}
+
+/*
+Setup the claim routes for the API version 1
+*/
+func (r *Router) setupClaimRoutes(rg *gin.RouterGroup) {
+	claims := rg.Group("/claims", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens), middleware.PlatformScope())
+	claims.GET("", middleware.RequireClaims("claim:read"), r.Handlers.ClaimHandler.ListClaims)
+	claims.GET("/:id", middleware.RequireClaims("claim:read"), r.Handlers.ClaimHandler.GetClaim)
+	claims.POST("", middleware.RequireClaims("claim:write"), r.Handlers.ClaimHandler.CreateClaim)
+	claims.PUT("/:id", middleware.RequireClaims("claim:update"), r.Handlers.ClaimHandler.UpdateClaim)
+	claims.DELETE("/:id", middleware.RequireClaims("claim:delete"), r.Handlers.ClaimHandler.DeleteClaim)
+}
+
+/*
+Setup the organization routes for the API version 1
+The organization of the path is the active organization, the organization policies
+of services.NewPolicies evaluate the claims granted in it
+*/
+func (r *Router) setupOrganizationRoutes(rg *gin.RouterGroup) {
+	organizations := rg.Group("/organizations", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens))
+	organizations.GET("", r.Handlers.OrganizationHandler.ListOrganizations)
+	organizations.POST("", middleware.DenyImpersonation(), middleware.RequireClaims("organization:create"), r.Handlers.OrganizationHandler.CreateOrganization)
+
+	organization := organizations.Group("/:"+middleware.OrganizationParam, middleware.ActiveOrganization(r.Organizations))
+	organization.GET("", r.Handlers.OrganizationHandler.GetOrganization)
+	organization.PUT("", r.Handlers.OrganizationHandler.RenameOrganization)
+	organization.DELETE("", r.Handlers.OrganizationHandler.DeleteOrganization)
+	organization.POST("/leave", middleware.DenyImpersonation(), r.Handlers.OrganizationHandler.LeaveOrganization)
+
+	members := organization.Group("/members")
+	members.GET("", r.Handlers.OrganizationHandler.ListMembers)
+	members.PUT("/:userID/role", r.Handlers.OrganizationHandler.SetMemberRole)
+	members.DELETE("/:userID", r.Handlers.OrganizationHandler.RemoveMember)
+
+	invitations := organization.Group("/invitations")
+	invitations.GET("", r.Handlers.OrganizationHandler.ListInvitations)
+	invitations.POST("", r.Handlers.OrganizationHandler.InviteMember)
+	invitations.DELETE("/:invitationID", r.Handlers.OrganizationHandler.RevokeInvitation)
+
+	// Invitations are answered from the account of the invited user
+	userInvitations := rg.Group("/users/me/invitations", middleware.JWTMiddleware(r.TokenDenylist, r.PersonalAccessTokens), middleware.RequireSession(), middleware.DenyImpersonation())
+	userInvitations.GET("", r.Handlers.OrganizationHandler.ListUserInvitations)
+	userInvitations.POST("/:invitationID/accept", r.Handlers.OrganizationHandler.AcceptInvitation)
+	userInvitations.DELETE("/:invitationID", r.Handlers.OrganizationHandler.DeclineInvitation)
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"knowstack/internal/api/router"
@@ -35,8 +36,22 @@ func NewServer(config config.Server) *Server {
 		utils.LogFatalWithErr("Failed to auto migrate the database", err)
 	}
 
+	// Load the signing keys so a misconfigured keyset fails at startup
+	keySet, err := utils.CurrentKeySet()
+	if err != nil {
+		utils.LogFatalWithErr("Failed to load the JWT signing keys", err)
+	}
+	if keySet.Active == nil {
+		utils.LogWarn("No JWT signing keys configured, access tokens are signed with HS256")
+	} else {
+		utils.LogInfo("JWT signing keys loaded", "activeKeyID", keySet.Active.ID, "keys", len(keySet.Keys))
+	}
+
 	// Create a new service instance
-	serviceInstance := services.NewService(db.GetDB(), config.OAuth)
+	serviceInstance := services.NewService(db.GetDB(), config)
+
+	// Carry out the account deletions whose grace period is over
+	go serviceInstance.UserService.RunAccountDeletionPurger(context.Background())
 
 	// Create a new router instance and setup the routes
 	r := router.NewRouter(serviceInstance)
@@ -15,11 +15,7 @@ func CreateUserValidationMessages() utils.FieldErrorMessages {
 			"required": "E-posta zorunludur.",
 			"email":    "Geçerli bir e-posta adresi giriniz.",
 		},
-		"Password": {
-			"required": "Parola zorunludur.",
-			"min":      "Parola en az 8 karakter olmalıdır.",
-			"max":      "Parola en fazla 72 karakter olabilir.",
-		},
+		"Password": passwordValidationMessages(),
 	}
 }
 
@@ -32,7 +28,6 @@ func LoginValidationMessages() utils.FieldErrorMessages {
 		},
 		"Password": {
 			"required": "Parola zorunludur.",
-			"min":      "Parola en az 8 karakter olmalıdır.",
 			"max":      "Parola en fazla 72 karakter olabilir.",
 		},
 		"Remember": {
@@ -68,11 +63,333 @@ func RequestPasswordResetValidationMessages() utils.FieldErrorMessages {
 
 func SetClaimsValidationMessages() utils.FieldErrorMessages {
 	return utils.FieldErrorMessages{
-		"UserID": {
-			"required": "Kullanıcı ID zorunludur.",
-		},
 		"ClaimIDs": {
 			"required": "Claims zorunludur.",
 		},
 	}
-}
\ No newline at end of file
+}
+
+func ValidatePasswordResetTokenValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Token": {
+			"required": "Şifre sıfırlama tokenı zorunludur.",
+		},
+	}
+}
+
+func ResetPasswordValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Token": {
+			"required": "Şifre sıfırlama tokenı zorunludur.",
+		},
+		"Password": passwordValidationMessages(),
+	}
+}
+
+func ChangePasswordValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"CurrentPassword": {
+			"max":                      "Parola en fazla 72 karakter olabilir.",
+			"invalid_current_password": "Mevcut parola hatalı.",
+		},
+		"NewPassword": passwordValidationMessages(),
+	}
+}
+
+func RequestAccountDeletionValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Password": {
+			"max":                      "Parola en fazla 72 karakter olabilir.",
+			"invalid_current_password": "Parola hatalı.",
+		},
+	}
+}
+
+func AdminAccountDeletionValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Immediate": {
+			"boolean": "Immediate bir boolean olmalıdır",
+		},
+	}
+}
+
+func VerifyEmailValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Token": {
+			"required": "Doğrulama tokenı zorunludur.",
+		},
+	}
+}
+
+func RequestMagicLinkValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Email": {
+			"required": "E-posta zorunludur.",
+			"email":    "Geçerli bir e-posta adresi giriniz.",
+		},
+		"Remember": {
+			"boolean": "Remember me bir boolean olmalıdır",
+		},
+	}
+}
+
+func VerifyMagicLinkValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Token": {
+			"required": "Giriş bağlantısı tokenı zorunludur.",
+		},
+		"DeviceLabel": {
+			"max": "Cihaz adı en fazla 100 karakter olabilir.",
+		},
+	}
+}
+
+func UnlockAccountValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Token": {
+			"required": "Kilit açma tokenı zorunludur.",
+		},
+	}
+}
+
+func ResendVerificationEmailValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Email": {
+			"required": "E-posta zorunludur.",
+			"email":    "Geçerli bir e-posta adresi giriniz.",
+		},
+	}
+}
+
+func TwoFactorCodeValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Code": {
+			"required": "Doğrulama kodu zorunludur.",
+			"min":      "Doğrulama kodu en az 6 karakter olmalıdır.",
+			"max":      "Doğrulama kodu en fazla 11 karakter olabilir.",
+		},
+	}
+}
+
+func VerifyTwoFactorLoginValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"MFAToken": {
+			"required": "MFA tokenı zorunludur.",
+		},
+		"Code": {
+			"required": "Doğrulama kodu zorunludur.",
+			"min":      "Doğrulama kodu en az 6 karakter olmalıdır.",
+			"max":      "Doğrulama kodu en fazla 11 karakter olabilir.",
+		},
+	}
+}
+
+func FinishPasskeyRegistrationValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"SessionKey": {
+			"required": "Oturum anahtarı zorunludur.",
+		},
+		"Name": {
+			"max": "Passkey adı en fazla 50 karakter olabilir.",
+		},
+		"Credential": {
+			"required": "Passkey bilgisi zorunludur.",
+		},
+	}
+}
+
+func FinishPasskeyLoginValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"SessionKey": {
+			"required": "Oturum anahtarı zorunludur.",
+		},
+		"Credential": {
+			"required": "Passkey bilgisi zorunludur.",
+		},
+		"Remember": {
+			"boolean": "Remember me bir boolean olmalıdır",
+		},
+	}
+}
+
+func CreatePersonalAccessTokenValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Name": {
+			"required": "Token adı zorunludur.",
+			"max":      "Token adı en fazla 100 karakter olabilir.",
+		},
+		"Scopes": {
+			"required": "En az bir yetki seçilmelidir.",
+			"min":      "En az bir yetki seçilmelidir.",
+		},
+		"ExpiresInDays": {
+			"required": "Geçerlilik süresi zorunludur.",
+			"min":      "Geçerlilik süresi en az 1 gün olmalıdır.",
+			"max":      "Geçerlilik süresi en fazla 365 gün olabilir.",
+		},
+	}
+}
+
+func ClaimValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Name": {
+			"required": "Yetkinlik adı zorunludur.",
+			"min":      "Yetkinlik adı en az 3 karakter olmalıdır.",
+			"max":      "Yetkinlik adı en fazla 30 karakter olabilir.",
+		},
+	}
+}
+
+func ListClaimsValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Page": {
+			"min": "Sayfa en az 1 olmalıdır.",
+		},
+		"PageSize": {
+			"min": "Sayfa boyutu en az 1 olmalıdır.",
+			"max": "Sayfa boyutu en fazla 100 olabilir.",
+		},
+		"Search": {
+			"max": "Arama en fazla 30 karakter olabilir.",
+		},
+	}
+}
+
+func ListUsersValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Page": {
+			"min": "Sayfa en az 1 olmalıdır.",
+		},
+		"PageSize": {
+			"min": "Sayfa boyutu en az 1 olmalıdır.",
+			"max": "Sayfa boyutu en fazla 100 olabilir.",
+		},
+		"Username": {
+			"max": "Kullanıcı adı en fazla 50 karakter olabilir.",
+		},
+		"Email": {
+			"max": "E-posta en fazla 100 karakter olabilir.",
+		},
+		"Role": {
+			"max": "Rol adı en fazla 30 karakter olabilir.",
+		},
+		"Provider": {
+			"max": "Sağlayıcı en fazla 30 karakter olabilir.",
+		},
+	}
+}
+
+func SetUserRoleValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"RoleID": {
+			"required": "Rol ID zorunludur.",
+		},
+	}
+}
+
+func CreateRoleValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Name": roleNameValidationMessages(),
+	}
+}
+
+func UpdateRoleValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Name": roleNameValidationMessages(),
+	}
+}
+
+func RoleClaimsValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Claims": {
+			"required": "En az bir yetkinlik seçilmelidir.",
+			"min":      "En az bir yetkinlik seçilmelidir.",
+		},
+	}
+}
+
+func ImpersonateUserValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Reason": {
+			"required": "Gerekçe zorunludur.",
+			"max":      "Gerekçe en fazla 500 karakter olabilir.",
+		},
+	}
+}
+
+func CreateOrganizationValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Name": organizationNameValidationMessages(),
+	}
+}
+
+func UpdateOrganizationValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Name": organizationNameValidationMessages(),
+	}
+}
+
+func ListOrganizationMembersValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Page": {
+			"min": "Sayfa en az 1 olmalıdır.",
+		},
+		"PageSize": {
+			"min": "Sayfa boyutu en az 1 olmalıdır.",
+			"max": "Sayfa boyutu en fazla 100 olabilir.",
+		},
+	}
+}
+
+func InviteOrganizationMemberValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"Email": {
+			"required": "E-posta zorunludur.",
+			"email":    "Geçerli bir e-posta adresi giriniz.",
+		},
+		"RoleID": {
+			"required": "Rol ID zorunludur.",
+		},
+	}
+}
+
+func SetOrganizationMemberRoleValidationMessages() utils.FieldErrorMessages {
+	return utils.FieldErrorMessages{
+		"RoleID": {
+			"required": "Rol ID zorunludur.",
+		},
+	}
+}
+
+// passwordValidationMessages returns the messages of a new password, including the rules
+// of the password policy that are checked by the services
+func passwordValidationMessages() map[string]string {
+	return map[string]string{
+		"required":           "Parola zorunludur.",
+		"max":                "Parola en fazla 72 karakter olabilir.",
+		"password_length":    "Parola, parola politikasının gerektirdiği uzunlukta değil.",
+		"password_uppercase": "Parola en az bir büyük harf içermelidir.",
+		"password_lowercase": "Parola en az bir küçük harf içermelidir.",
+		"password_digit":     "Parola en az bir rakam içermelidir.",
+		"password_symbol":    "Parola en az bir özel karakter içermelidir.",
+		"password_identity":  "Parola kullanıcı adınızı veya e-posta adresinizi içeremez.",
+		"password_breached":  "Bu parola bilinen bir veri sızıntısında yer alıyor, lütfen başka bir parola seçin.",
+		"password_reused":    "Bu parola daha önce kullanıldı, lütfen yeni bir parola seçin.",
+	}
+}
+
+func roleNameValidationMessages() map[string]string {
+	return map[string]string{
+		"required": "Rol adı zorunludur.",
+		"min":      "Rol adı en az 2 karakter olmalıdır.",
+		"max":      "Rol adı en fazla 30 karakter olabilir.",
+	}
+}
+
+func organizationNameValidationMessages() map[string]string {
+	return map[string]string{
+		"required": "Organizasyon adı zorunludur.",
+		"min":      "Organizasyon adı en az 2 karakter olmalıdır.",
+		"max":      "Organizasyon adı en fazla 50 karakter olabilir.",
+	}
+}
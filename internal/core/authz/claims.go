@@ -0,0 +1,105 @@
+// Package authz decides whether the claims granted to a user satisfy a requirement,
+// and through policies whether a user may perform an action on a particular resource.
+// It is used by the Gin middlewares and by the services alike.
+package authz
+
+import "strings"
+
+// Wildcard grants every claim on its own, and every claim of a namespace as its last segment (e.g. "user:*")
+const Wildcard = "*"
+
+// Separator splits a claim into its namespace and action, e.g. "user:read"
+const Separator = ":"
+
+// ImpliedActions lists the actions granted along with an action of the same namespace,
+// e.g. "user:write" also grants "user:read". Implications are transitive.
+var ImpliedActions = map[string][]string{
+	"write":  {"read"},
+	"update": {"read"},
+	"delete": {"read"},
+}
+
+// IsWildcard reports whether the claim grants other claims by its name, "*" or a namespace wildcard
+func IsWildcard(claim string) bool {
+	return claim == Wildcard || strings.HasSuffix(claim, Separator+Wildcard)
+}
+
+// ClaimSet is a set of granted claims with the wildcards and implied claims resolved
+type ClaimSet struct {
+	all      bool
+	exact    map[string]struct{}
+	prefixes []string
+}
+
+// NewClaimSet builds the set of the granted claims
+func NewClaimSet(claims []string) ClaimSet {
+	set := ClaimSet{exact: make(map[string]struct{}, len(claims))}
+	for _, claim := range claims {
+		set.add(claim)
+	}
+	return set
+}
+
+func (s *ClaimSet) add(claim string) {
+	if _, ok := s.exact[claim]; ok {
+		return
+	}
+	s.exact[claim] = struct{}{}
+
+	if claim == Wildcard {
+		s.all = true
+		return
+	}
+	if IsWildcard(claim) {
+		s.prefixes = append(s.prefixes, strings.TrimSuffix(claim, Wildcard))
+		return
+	}
+
+	namespace, action, ok := cutClaim(claim)
+	if !ok {
+		return
+	}
+	for _, implied := range ImpliedActions[action] {
+		s.add(namespace + Separator + implied)
+	}
+}
+
+// Has reports whether the claim is granted directly, through a wildcard or by implication
+func (s ClaimSet) Has(claim string) bool {
+	if s.all {
+		return true
+	}
+	if _, ok := s.exact[claim]; ok {
+		return true
+	}
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(claim, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAll reports whether every claim is granted
+func (s ClaimSet) HasAll(claims ...string) bool {
+	for _, claim := range claims {
+		if !s.Has(claim) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasClaims reports whether the granted claims include every required claim
+func HasClaims(granted []string, required ...string) bool {
+	return NewClaimSet(granted).HasAll(required...)
+}
+
+// cutClaim splits the claim at its last separator, so nested namespaces keep their action last
+func cutClaim(claim string) (namespace, action string, ok bool) {
+	i := strings.LastIndex(claim, Separator)
+	if i <= 0 || i == len(claim)-1 {
+		return "", "", false
+	}
+	return claim[:i], claim[i+1:], true
+}
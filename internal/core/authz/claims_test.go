@@ -0,0 +1,85 @@
+package authz
+
+import "testing"
+
+func TestClaimSetHas(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []string
+		claim   string
+		want    bool
+	}{
+		{name: "exact claim", granted: []string{"user:read"}, claim: "user:read", want: true},
+		{name: "missing claim", granted: []string{"user:read"}, claim: "user:write", want: false},
+		{name: "no claims", granted: nil, claim: "user:read", want: false},
+
+		{name: "wildcard grants any claim", granted: []string{"*"}, claim: "role:delete", want: true},
+		{name: "wildcard grants nested claims", granted: []string{"*"}, claim: "organization:member:write", want: true},
+		{name: "wildcard grants the wildcard", granted: []string{"*"}, claim: "*", want: true},
+
+		{name: "namespace wildcard grants its claims", granted: []string{"user:*"}, claim: "user:impersonate", want: true},
+		{name: "namespace wildcard grants nested claims", granted: []string{"organization:*"}, claim: "organization:member:delete", want: true},
+		{name: "namespace wildcard grants itself", granted: []string{"user:*"}, claim: "user:*", want: true},
+		{name: "namespace wildcard doesn't grant other namespaces", granted: []string{"user:*"}, claim: "role:read", want: false},
+		{name: "namespace wildcard doesn't grant a namespace sharing its prefix", granted: []string{"user:*"}, claim: "username:read", want: false},
+		{name: "namespace wildcard doesn't grant the namespace", granted: []string{"user:*"}, claim: "user", want: false},
+		{name: "namespace wildcard doesn't grant the wildcard", granted: []string{"user:*"}, claim: "*", want: false},
+		{name: "nested wildcard grants its namespace", granted: []string{"organization:member:*"}, claim: "organization:member:write", want: true},
+		{name: "nested wildcard doesn't grant the parent namespace", granted: []string{"organization:member:*"}, claim: "organization:read", want: false},
+		{name: "wildcard within a segment is literal", granted: []string{"user*"}, claim: "user:read", want: false},
+
+		{name: "write implies read", granted: []string{"user:write"}, claim: "user:read", want: true},
+		{name: "update implies read", granted: []string{"role:update"}, claim: "role:read", want: true},
+		{name: "delete implies read", granted: []string{"claim:delete"}, claim: "claim:read", want: true},
+		{name: "read implies nothing", granted: []string{"user:read"}, claim: "user:write", want: false},
+		{name: "implication stays in the namespace", granted: []string{"user:write"}, claim: "role:read", want: false},
+		{name: "implication in a nested namespace", granted: []string{"organization:member:write"}, claim: "organization:member:read", want: true},
+		{name: "nested implication doesn't reach the parent", granted: []string{"organization:member:write"}, claim: "organization:read", want: false},
+		{name: "unknown action implies nothing", granted: []string{"user:impersonate"}, claim: "user:read", want: false},
+		{name: "claim without namespace implies nothing", granted: []string{"write"}, claim: "read", want: false},
+		{name: "claim without action implies nothing", granted: []string{"user:"}, claim: "user:read", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewClaimSet(tt.granted).Has(tt.claim); got != tt.want {
+				t.Errorf("NewClaimSet(%v).Has(%q) = %v, want %v", tt.granted, tt.claim, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimSetHasAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []string
+		claims  []string
+		want    bool
+	}{
+		{name: "every claim granted", granted: []string{"user:write", "role:*"}, claims: []string{"user:read", "role:delete"}, want: true},
+		{name: "one claim missing", granted: []string{"user:write"}, claims: []string{"user:read", "role:read"}, want: false},
+		{name: "no claims required", granted: nil, claims: nil, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasClaims(tt.granted, tt.claims...); got != tt.want {
+				t.Errorf("HasClaims(%v, %v) = %v, want %v", tt.granted, tt.claims, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImpliedActionsAreTransitive(t *testing.T) {
+	previous := ImpliedActions
+	ImpliedActions = map[string][]string{
+		"admin": {"write"},
+		"write": {"read"},
+	}
+	t.Cleanup(func() { ImpliedActions = previous })
+
+	claims := NewClaimSet([]string{"user:admin"})
+	if !claims.HasAll("user:write", "user:read") {
+		t.Errorf("user:admin doesn't grant user:write and user:read")
+	}
+}
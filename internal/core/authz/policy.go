@@ -0,0 +1,197 @@
+package authz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"knowstack/internal/utils"
+)
+
+// ErrDenied is returned by Authorize when no policy allows the action, match it with errors.Is
+var ErrDenied = errors.New("access denied")
+
+// Actions performed on resources
+const (
+	ActionRead   = "read"
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Subject is the user asking to perform an action
+type Subject struct {
+	UserID uint
+	RoleID uint
+	// OrganizationID is the active organization of the request, zero outside of organizations
+	OrganizationID uint
+	// OrganizationRoleID is the role of the user in the active organization
+	OrganizationRoleID uint
+	// Claims include the claims granted in the active organization
+	Claims ClaimSet
+	// Attributes are other facts about the request, e.g. "impersonated"
+	Attributes map[string]any
+}
+
+// SubjectFromToken builds the subject of an authenticated request
+func SubjectFromToken(claims *utils.TokenClaims) (Subject, error) {
+	userID, err := strconv.ParseUint(claims.UserID, 10, 32)
+	if err != nil {
+		return Subject{}, err
+	}
+	return Subject{
+		UserID:             uint(userID),
+		RoleID:             claims.RoleID,
+		OrganizationID:     claims.OrganizationID,
+		OrganizationRoleID: claims.OrganizationRoleID,
+		Claims:             NewClaimSet(claims.GrantedClaims()),
+		Attributes: map[string]any{
+			"impersonated":        claims.Actor != nil,
+			"personalAccessToken": claims.PersonalAccessTokenID != 0,
+		},
+	}, nil
+}
+
+// Resource is the record an action is performed on
+type Resource struct {
+	Type string
+	ID   uint
+	// OwnerID is the user the record belongs to, zero when nobody owns it
+	OwnerID uint
+	// OrganizationID is the organization the record belongs to, zero outside of organizations
+	OrganizationID uint
+	Attributes     map[string]any
+}
+
+// Condition decides whether the subject may act on the resource
+type Condition func(subject Subject, resource Resource) bool
+
+// Owner allows the user the resource belongs to
+func Owner() Condition {
+	return func(subject Subject, resource Resource) bool {
+		return resource.OwnerID != 0 && resource.OwnerID == subject.UserID
+	}
+}
+
+// SameOrganization allows the subjects acting in the organization the resource belongs to
+func SameOrganization() Condition {
+	return func(subject Subject, resource Resource) bool {
+		return resource.OrganizationID != 0 && resource.OrganizationID == subject.OrganizationID
+	}
+}
+
+// HasRole allows the subjects in one of the roles, either outside of the organizations or in the active one
+func HasRole(roleIDs ...uint) Condition {
+	return func(subject Subject, _ Resource) bool {
+		for _, roleID := range roleIDs {
+			if subject.RoleID == roleID || (subject.OrganizationRoleID != 0 && subject.OrganizationRoleID == roleID) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Satisfies allows the subjects whose claims satisfy the requirement
+func Satisfies(requirement Requirement) Condition {
+	return func(subject Subject, _ Resource) bool {
+		return requirement.SatisfiedBy(subject.Claims)
+	}
+}
+
+// AttributeEquals allows the resources whose attribute has the value
+func AttributeEquals(key string, value any) Condition {
+	return func(_ Subject, resource Resource) bool {
+		actual, ok := resource.Attributes[key]
+		return ok && reflect.DeepEqual(actual, value)
+	}
+}
+
+// SubjectAttributeEquals allows the subjects whose attribute has the value
+func SubjectAttributeEquals(key string, value any) Condition {
+	return func(subject Subject, _ Resource) bool {
+		actual, ok := subject.Attributes[key]
+		return ok && reflect.DeepEqual(actual, value)
+	}
+}
+
+// Every allows when all of the conditions do, an empty Every always does
+func Every(conditions ...Condition) Condition {
+	return func(subject Subject, resource Resource) bool {
+		for _, condition := range conditions {
+			if !condition(subject, resource) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Some allows when at least one of the conditions does, an empty Some never does
+func Some(conditions ...Condition) Condition {
+	return func(subject Subject, resource Resource) bool {
+		for _, condition := range conditions {
+			if condition(subject, resource) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not inverts the condition
+func Not(condition Condition) Condition {
+	return func(subject Subject, resource Resource) bool {
+		return !condition(subject, resource)
+	}
+}
+
+// Policy maps the actions on a resource type to the condition allowing them.
+// Actions missing from the policy are denied.
+type Policy map[string]Condition
+
+// Policies holds the policy of every resource type, resource types without a policy are denied
+type Policies struct {
+	policies map[string]Policy
+}
+
+func NewPolicies() *Policies {
+	return &Policies{policies: make(map[string]Policy)}
+}
+
+// Register sets the policy of the resource type, replacing the previous one
+func (p *Policies) Register(resourceType string, policy Policy) {
+	p.policies[resourceType] = policy
+}
+
+// Can reports whether the subject may perform the action on the resource
+func (p *Policies) Can(subject Subject, action string, resource Resource) bool {
+	condition, ok := p.policies[resource.Type][action]
+	return ok && condition(subject, resource)
+}
+
+// Authorize returns a DeniedError matching ErrDenied unless the subject may perform the action on the resource
+func (p *Policies) Authorize(subject Subject, action string, resource Resource) error {
+	if p.Can(subject, action, resource) {
+		return nil
+	}
+	utils.LogWarn("Security event: access to a resource denied",
+		"userID", subject.UserID, "action", action, "resource", resource.Type, "resourceID", resource.ID)
+	return &DeniedError{Action: action, ResourceType: resource.Type, ResourceID: resource.ID}
+}
+
+// DeniedError describes a denied action
+type DeniedError struct {
+	Action       string
+	ResourceType string
+	ResourceID   uint
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("%s %s %d: %s", e.Action, e.ResourceType, e.ResourceID, ErrDenied)
+}
+
+func (e *DeniedError) Is(target error) bool {
+	return target == ErrDenied
+}
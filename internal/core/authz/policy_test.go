@@ -0,0 +1,136 @@
+package authz
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConditions(t *testing.T) {
+	alice := Subject{
+		UserID:             1,
+		RoleID:             10,
+		OrganizationID:     100,
+		OrganizationRoleID: 20,
+		Claims:             NewClaimSet([]string{"organization:member:*"}),
+		Attributes:         map[string]any{"impersonated": false},
+	}
+	outsider := Subject{UserID: 2, RoleID: 10}
+	allow := func(Subject, Resource) bool { return true }
+	deny := func(Subject, Resource) bool { return false }
+
+	tests := []struct {
+		name      string
+		condition Condition
+		subject   Subject
+		resource  Resource
+		want      bool
+	}{
+		{name: "owner", condition: Owner(), subject: alice, resource: Resource{OwnerID: 1}, want: true},
+		{name: "not the owner", condition: Owner(), subject: alice, resource: Resource{OwnerID: 2}, want: false},
+		{name: "resource without owner", condition: Owner(), subject: Subject{}, resource: Resource{}, want: false},
+
+		{name: "same organization", condition: SameOrganization(), subject: alice, resource: Resource{OrganizationID: 100}, want: true},
+		{name: "other organization", condition: SameOrganization(), subject: alice, resource: Resource{OrganizationID: 200}, want: false},
+		{name: "outside of organizations", condition: SameOrganization(), subject: outsider, resource: Resource{}, want: false},
+
+		{name: "platform role", condition: HasRole(10), subject: alice, want: true},
+		{name: "organization role", condition: HasRole(30, 20), subject: alice, want: true},
+		{name: "role missing", condition: HasRole(30), subject: alice, want: false},
+		{name: "organization role outside of organizations", condition: HasRole(0), subject: outsider, want: false},
+
+		{name: "claims satisfied", condition: Satisfies(Claim("organization:member:write")), subject: alice, want: true},
+		{name: "claims not satisfied", condition: Satisfies(Claim("organization:delete")), subject: alice, want: false},
+
+		{name: "resource attribute", condition: AttributeEquals("role", "owner"), resource: Resource{Attributes: map[string]any{"role": "owner"}}, want: true},
+		{name: "resource attribute differs", condition: AttributeEquals("role", "owner"), resource: Resource{Attributes: map[string]any{"role": "member"}}, want: false},
+		{name: "resource attribute missing", condition: AttributeEquals("role", nil), resource: Resource{}, want: false},
+
+		{name: "subject attribute", condition: SubjectAttributeEquals("impersonated", false), subject: alice, want: true},
+		{name: "subject attribute differs", condition: SubjectAttributeEquals("impersonated", true), subject: alice, want: false},
+		{name: "subject attribute of another type", condition: SubjectAttributeEquals("impersonated", 0), subject: alice, want: false},
+		{name: "subject attribute missing", condition: SubjectAttributeEquals("impersonated", nil), subject: outsider, want: false},
+
+		{name: "every allows", condition: Every(allow, allow), want: true},
+		{name: "every denies", condition: Every(allow, deny), want: false},
+		{name: "empty every", condition: Every(), want: true},
+		{name: "some allows", condition: Some(deny, allow), want: true},
+		{name: "some denies", condition: Some(deny, deny), want: false},
+		{name: "empty some", condition: Some(), want: false},
+		{name: "not allows", condition: Not(deny), want: true},
+		{name: "not denies", condition: Not(allow), want: false},
+
+		{
+			name:      "nested conditions",
+			condition: Every(SameOrganization(), Some(Owner(), Satisfies(Claim("organization:member:delete")))),
+			subject:   alice,
+			resource:  Resource{OwnerID: 2, OrganizationID: 100},
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.condition(tt.subject, tt.resource); got != tt.want {
+				t.Errorf("condition(%+v, %+v) = %v, want %v", tt.subject, tt.resource, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoliciesCan(t *testing.T) {
+	policies := NewPolicies()
+	policies.Register("note", Policy{
+		ActionRead:   Owner(),
+		ActionDelete: Every(Owner(), Not(SubjectAttributeEquals("impersonated", true))),
+	})
+
+	owner := Subject{UserID: 1, Attributes: map[string]any{"impersonated": false}}
+	impersonated := Subject{UserID: 1, Attributes: map[string]any{"impersonated": true}}
+	note := Resource{Type: "note", ID: 7, OwnerID: 1}
+
+	tests := []struct {
+		name     string
+		subject  Subject
+		action   string
+		resource Resource
+		want     bool
+	}{
+		{name: "allowed action", subject: owner, action: ActionRead, resource: note, want: true},
+		{name: "condition denies", subject: Subject{UserID: 2}, action: ActionRead, resource: note, want: false},
+		{name: "attribute condition denies", subject: impersonated, action: ActionDelete, resource: note, want: false},
+		{name: "attribute condition allows", subject: owner, action: ActionDelete, resource: note, want: true},
+		{name: "action missing from the policy", subject: owner, action: ActionUpdate, resource: note, want: false},
+		{name: "resource type without a policy", subject: owner, action: ActionRead, resource: Resource{Type: "other", OwnerID: 1}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policies.Can(tt.subject, tt.action, tt.resource); got != tt.want {
+				t.Errorf("Can(%s %s) = %v, want %v", tt.action, tt.resource.Type, got, tt.want)
+			}
+
+			err := policies.Authorize(tt.subject, tt.action, tt.resource)
+			if tt.want && err != nil {
+				t.Errorf("Authorize() error = %v, want nil", err)
+			}
+			if !tt.want && !errors.Is(err, ErrDenied) {
+				t.Errorf("Authorize() error = %v, want %v", err, ErrDenied)
+			}
+		})
+	}
+}
+
+func TestPoliciesRegisterReplaces(t *testing.T) {
+	policies := NewPolicies()
+	policies.Register("note", Policy{ActionRead: Owner()})
+	policies.Register("note", Policy{ActionDelete: Owner()})
+
+	subject := Subject{UserID: 1}
+	note := Resource{Type: "note", OwnerID: 1}
+	if policies.Can(subject, ActionRead, note) {
+		t.Errorf("Can(read) = true after the policy was replaced")
+	}
+	if !policies.Can(subject, ActionDelete, note) {
+		t.Errorf("Can(delete) = false, want true")
+	}
+}
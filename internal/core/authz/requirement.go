@@ -0,0 +1,90 @@
+package authz
+
+import "strings"
+
+// Requirement is a boolean combination of claims, declared once and evaluated per request
+type Requirement interface {
+	SatisfiedBy(claims ClaimSet) bool
+	String() string
+}
+
+type claimRequirement string
+
+// Claim requires a single claim
+func Claim(name string) Requirement {
+	return claimRequirement(name)
+}
+
+func (r claimRequirement) SatisfiedBy(claims ClaimSet) bool {
+	return claims.Has(string(r))
+}
+
+func (r claimRequirement) String() string {
+	return string(r)
+}
+
+type allOf []Requirement
+
+// AllOf is satisfied when every requirement is, an empty AllOf always is
+func AllOf(requirements ...Requirement) Requirement {
+	return allOf(requirements)
+}
+
+func (r allOf) SatisfiedBy(claims ClaimSet) bool {
+	for _, requirement := range r {
+		if !requirement.SatisfiedBy(claims) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r allOf) String() string {
+	return join(r, " AND ")
+}
+
+type anyOf []Requirement
+
+// AnyOf is satisfied when at least one requirement is, an empty AnyOf never is
+func AnyOf(requirements ...Requirement) Requirement {
+	return anyOf(requirements)
+}
+
+func (r anyOf) SatisfiedBy(claims ClaimSet) bool {
+	for _, requirement := range r {
+		if requirement.SatisfiedBy(claims) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r anyOf) String() string {
+	return join(r, " OR ")
+}
+
+// AllClaims requires every claim
+func AllClaims(names ...string) Requirement {
+	return AllOf(claims(names)...)
+}
+
+// AnyClaim requires at least one of the claims
+func AnyClaim(names ...string) Requirement {
+	return AnyOf(claims(names)...)
+}
+
+func claims(names []string) []Requirement {
+	requirements := make([]Requirement, len(names))
+	for i, name := range names {
+		requirements[i] = Claim(name)
+	}
+	return requirements
+}
+
+func join(requirements []Requirement, operator string) string {
+	parts := make([]string, len(requirements))
+	for i, requirement := range requirements {
+		parts[i] = requirement.String()
+	}
+	return "(" + strings.Join(parts, operator) + ")"
+}
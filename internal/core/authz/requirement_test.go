@@ -0,0 +1,55 @@
+package authz
+
+import "testing"
+
+func TestRequirementSatisfiedBy(t *testing.T) {
+	tests := []struct {
+		name        string
+		requirement Requirement
+		granted     []string
+		want        bool
+	}{
+		{name: "claim granted", requirement: Claim("user:read"), granted: []string{"user:read"}, want: true},
+		{name: "claim granted through a wildcard", requirement: Claim("user:read"), granted: []string{"user:*"}, want: true},
+		{name: "claim granted by implication", requirement: Claim("user:read"), granted: []string{"user:delete"}, want: true},
+		{name: "claim missing", requirement: Claim("user:read"), granted: []string{"role:read"}, want: false},
+
+		{name: "all of granted", requirement: AllClaims("user:read", "role:read"), granted: []string{"user:read", "role:update"}, want: true},
+		{name: "all of partly granted", requirement: AllClaims("user:read", "role:read"), granted: []string{"user:read"}, want: false},
+		{name: "all of granted through the wildcard", requirement: AllClaims("user:read", "role:delete"), granted: []string{"*"}, want: true},
+		{name: "empty all of", requirement: AllOf(), granted: nil, want: true},
+
+		{name: "any of granted", requirement: AnyClaim("user:read", "role:read"), granted: []string{"role:read"}, want: true},
+		{name: "any of granted by implication", requirement: AnyClaim("user:read", "role:read"), granted: []string{"role:write"}, want: true},
+		{name: "any of missing", requirement: AnyClaim("user:read", "role:read"), granted: []string{"claim:read"}, want: false},
+		{name: "empty any of", requirement: AnyOf(), granted: []string{"*"}, want: false},
+
+		{
+			name:        "nested requirements granted",
+			requirement: AllOf(Claim("user:read"), AnyClaim("role:update", "organization:member:write")),
+			granted:     []string{"user:read", "organization:member:*"},
+			want:        true,
+		},
+		{
+			name:        "nested requirements missing",
+			requirement: AllOf(Claim("user:read"), AnyClaim("role:update", "organization:member:write")),
+			granted:     []string{"user:read", "organization:read"},
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.requirement.SatisfiedBy(NewClaimSet(tt.granted)); got != tt.want {
+				t.Errorf("%s satisfied by %v = %v, want %v", tt.requirement, tt.granted, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequirementString(t *testing.T) {
+	requirement := AllOf(Claim("user:read"), AnyClaim("role:update", "role:delete"))
+	if got, want := requirement.String(), "(user:read AND (role:update OR role:delete))"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
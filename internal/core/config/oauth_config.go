@@ -0,0 +1,122 @@
+package config
+
+import (
+	"knowstack/internal/utils"
+	"strings"
+)
+
+// Supported OAuth provider types
+const (
+	OAuthProviderGoogle = "google"
+	OAuthProviderGitHub = "github"
+	OAuthProviderOIDC   = "oidc"
+)
+
+type OAuthProvider struct {
+	// Name of the provider in the /oauth/{provider} routes
+	Name string
+	// Type selects the implementation: google, github or oidc
+	Type         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// AuthURL, TokenURL and UserInfoURL override the default endpoints of google and github
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	// IssuerURL is used for the discovery document of oidc providers
+	IssuerURL string
+}
+
+/*
+Load the OAuth providers from the .env file.
+A provider is enabled only when its client ID is set.
+*/
+func oauthProvidersFromEnv() []OAuthProvider {
+	candidates := []OAuthProvider{
+		{
+			Name:         OAuthProviderGoogle,
+			Type:         OAuthProviderGoogle,
+			ClientID:     utils.GetEnv("GOOGLE_CLIENT_ID", ""),
+			ClientSecret: utils.GetEnv("GOOGLE_CLIENT_SECRET", ""),
+			RedirectURL:  utils.GetEnv("GOOGLE_REDIRECT_URL", ""),
+			Scopes: splitAndTrim(utils.GetEnv("GOOGLE_SCOPES",
+				"https://www.googleapis.com/auth/userinfo.email,https://www.googleapis.com/auth/userinfo.profile")),
+			AuthURL:     utils.GetEnv("GOOGLE_AUTH_URL", ""),
+			TokenURL:    utils.GetEnv("GOOGLE_TOKEN_URL", ""),
+			UserInfoURL: utils.GetEnv("GOOGLE_USERINFO_URL", ""),
+		},
+		{
+			Name:         OAuthProviderGitHub,
+			Type:         OAuthProviderGitHub,
+			ClientID:     utils.GetEnv("GITHUB_CLIENT_ID", ""),
+			ClientSecret: utils.GetEnv("GITHUB_CLIENT_SECRET", ""),
+			RedirectURL:  utils.GetEnv("GITHUB_REDIRECT_URL", ""),
+			Scopes:       splitAndTrim(utils.GetEnv("GITHUB_SCOPES", "read:user,user:email")),
+			AuthURL:      utils.GetEnv("GITHUB_AUTH_URL", ""),
+			TokenURL:     utils.GetEnv("GITHUB_TOKEN_URL", ""),
+			UserInfoURL:  utils.GetEnv("GITHUB_API_URL", ""),
+		},
+		{
+			Name:         "microsoft",
+			Type:         OAuthProviderOIDC,
+			ClientID:     utils.GetEnv("MICROSOFT_CLIENT_ID", ""),
+			ClientSecret: utils.GetEnv("MICROSOFT_CLIENT_SECRET", ""),
+			RedirectURL:  utils.GetEnv("MICROSOFT_REDIRECT_URL", ""),
+			Scopes:       splitAndTrim(utils.GetEnv("MICROSOFT_SCOPES", "openid,email,profile")),
+			IssuerURL: utils.GetEnv("MICROSOFT_ISSUER_URL",
+				"https://login.microsoftonline.com/"+utils.GetEnv("MICROSOFT_TENANT", "common")+"/v2.0"),
+		},
+		{
+			Name:         "gitlab",
+			Type:         OAuthProviderOIDC,
+			ClientID:     utils.GetEnv("GITLAB_CLIENT_ID", ""),
+			ClientSecret: utils.GetEnv("GITLAB_CLIENT_SECRET", ""),
+			RedirectURL:  utils.GetEnv("GITLAB_REDIRECT_URL", ""),
+			Scopes:       splitAndTrim(utils.GetEnv("GITLAB_SCOPES", "openid,email,profile")),
+			IssuerURL:    utils.GetEnv("GITLAB_ISSUER_URL", "https://gitlab.com"),
+		},
+		{
+			Name:         utils.GetEnv("OIDC_PROVIDER_NAME", OAuthProviderOIDC),
+			Type:         OAuthProviderOIDC,
+			ClientID:     utils.GetEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: utils.GetEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  utils.GetEnv("OIDC_REDIRECT_URL", ""),
+			Scopes:       splitAndTrim(utils.GetEnv("OIDC_SCOPES", "openid,email,profile")),
+			IssuerURL:    utils.GetEnv("OIDC_ISSUER_URL", ""),
+		},
+	}
+
+	providers := make([]OAuthProvider, 0, len(candidates))
+	for _, provider := range candidates {
+		if provider.ClientID != "" {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
+// OAuthClient is a client that authenticates to the token introspection and revocation endpoints
+type OAuthClient struct {
+	ID     string
+	Secret string
+}
+
+/*
+Load the introspection and revocation clients from OAUTH_CLIENTS,
+a comma separated list of "client_id:client_secret" entries
+*/
+func oauthClientsFromEnv() []OAuthClient {
+	entries := splitAndTrim(utils.GetEnv("OAUTH_CLIENTS", ""))
+	clients := make([]OAuthClient, 0, len(entries))
+	for _, entry := range entries {
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || secret == "" {
+			utils.LogWarn("Ignoring invalid OAUTH_CLIENTS entry", "clientID", id)
+			continue
+		}
+		clients = append(clients, OAuthClient{ID: id, Secret: secret})
+	}
+	return clients
+}
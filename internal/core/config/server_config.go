@@ -2,9 +2,8 @@ package config
 
 import (
 	"knowstack/internal/utils"
-
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
+	"strings"
+	"time"
 )
 
 type Server struct {
@@ -13,7 +12,11 @@ type Server struct {
 	Database Database
 	Logger   Logger
 	JWT      JWT
-	OAuth    *oauth2.Config
+	Auth     Auth
+	WebAuthn WebAuthn
+	OAuth    []OAuthProvider
+	// OAuthClients may introspect and revoke tokens
+	OAuthClients []OAuthClient
 }
 
 type Logger struct {
@@ -22,6 +25,90 @@ type Logger struct {
 	Environment string
 }
 
+type Auth struct {
+	// RequireEmailVerification makes login refuse local accounts with an unverified email
+	RequireEmailVerification bool
+	// OAuthAutoLinkVerifiedEmail links an OAuth login to the existing user with the same, verified email
+	OAuthAutoLinkVerifiedEmail bool
+	// TokenDenylistBackend stores revoked access tokens: memory or database
+	TokenDenylistBackend string
+	LoginThrottle        LoginThrottle
+	MagicLink            MagicLink
+	PasswordPolicy       PasswordPolicy
+	AccountDeletion      AccountDeletion
+}
+
+// AccountDeletion configures how requested account deletions are carried out
+type AccountDeletion struct {
+	// GracePeriod before a requested deletion is carried out, it can be cancelled meanwhile
+	GracePeriod time.Duration
+	// Mode is anonymize, keeping an anonymous user row, or delete.
+	// Users referenced by the impersonation audit trail are always anonymized.
+	Mode string
+	// PurgeInterval is how often due deletions are carried out
+	PurgeInterval time.Duration
+}
+
+// Account deletion modes
+const (
+	AccountDeletionAnonymize = "anonymize"
+	AccountDeletionDelete    = "delete"
+)
+
+// PasswordPolicy are the rules new passwords must satisfy
+type PasswordPolicy struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// RejectIdentity rejects passwords containing the username or the local part of the email
+	RejectIdentity bool
+	// HistorySize is the number of previous passwords that can't be reused, 0 disables the check
+	HistorySize int
+	// BreachedCorpusPath is a HIBP SHA-1 corpus: a directory of range files named by the
+	// 5 character hash prefix, or a single file of full hashes. Empty disables the check.
+	BreachedCorpusPath string
+}
+
+// MagicLink configures the passwordless email login
+type MagicLink struct {
+	Enabled   bool
+	ExpiresIn time.Duration
+	// BindBrowser only accepts the link in the browser that requested it
+	BindBrowser bool
+	// CookieSecure sends the browser binding cookie over HTTPS only
+	CookieSecure bool
+	// MaxRequests links are sent per account and per IP address within RequestWindow
+	MaxRequests   int
+	RequestWindow time.Duration
+}
+
+// LoginThrottle limits failed password logins per account and per IP address
+type LoginThrottle struct {
+	// MaxAccountFailures consecutive failures lock the account for LockoutDuration
+	MaxAccountFailures int
+	LockoutDuration    time.Duration
+	// MaxIPFailures failures from an IP address within IPWindow block its logins
+	MaxIPFailures int
+	IPWindow      time.Duration
+	// Every failure doubles the response delay, starting at BaseDelay up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Token denylist backends
+const (
+	TokenDenylistMemory   = "memory"
+	TokenDenylistDatabase = "database"
+)
+
+type WebAuthn struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+}
+
 type JWT struct {
 	Secret                       string
 	Issuer                       string
@@ -63,15 +150,60 @@ func DefaultServerConfigFromEnv() Server {
 			RefreshExpiresInDaysRemember: utils.GetEnvAsInt("JWT_REFRESH_EXPIRES_IN_DAYS_REMEMBER", 30),
 			ExpiresInMinutes:             utils.GetEnvAsInt("JWT_EXPIRES_IN_MIN", 60),
 		},
-		OAuth: &oauth2.Config{
-			ClientID:     utils.GetEnv("GOOGLE_CLIENT_ID", ""),
-			ClientSecret: utils.GetEnv("GOOGLE_CLIENT_SECRET", ""),
-			RedirectURL:  utils.GetEnv("GOOGLE_REDIRECT_URL", ""),
-			Scopes: []string{
-				"https://www.googleapis.com/auth/userinfo.email",
-				"https://www.googleapis.com/auth/userinfo.profile",
+		Auth: Auth{
+			RequireEmailVerification:   utils.GetEnvAsBool("AUTH_REQUIRE_EMAIL_VERIFICATION", false),
+			OAuthAutoLinkVerifiedEmail: utils.GetEnvAsBool("AUTH_OAUTH_AUTO_LINK_VERIFIED_EMAIL", false),
+			TokenDenylistBackend:       utils.GetEnv("AUTH_TOKEN_DENYLIST_BACKEND", TokenDenylistMemory),
+			LoginThrottle: LoginThrottle{
+				MaxAccountFailures: utils.GetEnvAsInt("LOGIN_MAX_ACCOUNT_FAILURES", 5),
+				LockoutDuration:    time.Duration(utils.GetEnvAsInt("LOGIN_LOCKOUT_MIN", 15)) * time.Minute,
+				MaxIPFailures:      utils.GetEnvAsInt("LOGIN_MAX_IP_FAILURES", 20),
+				IPWindow:           time.Duration(utils.GetEnvAsInt("LOGIN_IP_WINDOW_MIN", 15)) * time.Minute,
+				BaseDelay:          time.Duration(utils.GetEnvAsInt("LOGIN_DELAY_BASE_MS", 200)) * time.Millisecond,
+				MaxDelay:           time.Duration(utils.GetEnvAsInt("LOGIN_DELAY_MAX_MS", 3000)) * time.Millisecond,
+			},
+			MagicLink: MagicLink{
+				Enabled:       utils.GetEnvAsBool("AUTH_MAGIC_LINK_ENABLED", false),
+				ExpiresIn:     time.Duration(utils.GetEnvAsInt("MAGIC_LINK_EXPIRES_IN_MIN", 15)) * time.Minute,
+				BindBrowser:   utils.GetEnvAsBool("AUTH_MAGIC_LINK_BIND_BROWSER", true),
+				CookieSecure:  utils.GetEnvAsBool("AUTH_MAGIC_LINK_COOKIE_SECURE", true),
+				MaxRequests:   utils.GetEnvAsInt("MAGIC_LINK_MAX_REQUESTS", 3),
+				RequestWindow: time.Duration(utils.GetEnvAsInt("MAGIC_LINK_REQUEST_WINDOW_MIN", 15)) * time.Minute,
 			},
-			Endpoint: google.Endpoint,
+			PasswordPolicy: PasswordPolicy{
+				MinLength:          utils.GetEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+				RequireUppercase:   utils.GetEnvAsBool("PASSWORD_REQUIRE_UPPERCASE", false),
+				RequireLowercase:   utils.GetEnvAsBool("PASSWORD_REQUIRE_LOWERCASE", false),
+				RequireDigit:       utils.GetEnvAsBool("PASSWORD_REQUIRE_DIGIT", false),
+				RequireSymbol:      utils.GetEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+				RejectIdentity:     utils.GetEnvAsBool("PASSWORD_REJECT_IDENTITY", true),
+				HistorySize:        utils.GetEnvAsInt("PASSWORD_HISTORY_SIZE", 5),
+				BreachedCorpusPath: utils.GetEnv("PASSWORD_BREACHED_CORPUS_PATH", ""),
+			},
+			AccountDeletion: AccountDeletion{
+				GracePeriod:   time.Duration(utils.GetEnvAsInt("ACCOUNT_DELETION_GRACE_DAYS", 30)) * 24 * time.Hour,
+				Mode:          utils.GetEnv("ACCOUNT_DELETION_MODE", AccountDeletionAnonymize),
+				PurgeInterval: time.Duration(utils.GetEnvAsInt("ACCOUNT_DELETION_PURGE_INTERVAL_MIN", 60)) * time.Minute,
+			},
+		},
+		WebAuthn: WebAuthn{
+			RPID:          utils.GetEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPDisplayName: utils.GetEnv("WEBAUTHN_RP_NAME", "Knowstack"),
+			RPOrigins:     splitAndTrim(utils.GetEnv("WEBAUTHN_RP_ORIGINS", "http://localhost:3000")),
 		},
+		OAuth:        oauthProvidersFromEnv(),
+		OAuthClients: oauthClientsFromEnv(),
+	}
+}
+
+// splitAndTrim splits a comma separated setting into its trimmed, non-empty values
+func splitAndTrim(value string) []string {
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
 	}
+	return result
 }
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDeletionNotRequested = errors.New("account deletion not requested")
+	ErrAccountDeleted       = errors.New("account already deleted")
+)
+
+// defaultPurgeInterval is used when the configured purge interval isn't positive
+const defaultPurgeInterval = time.Hour
+
+// RequestAccountDeletion schedules the deletion of the authenticated user after the grace period.
+// Users with a password must confirm it; the attempt counts towards the login throttle like a login.
+func (s *UserService) RequestAccountDeletion(userID uint, req dto.RequestAccountDeletionRequest, client dto.SessionInfo) (*dto.AccountDeletionResponse, error) {
+	utils.LogInfo("Requesting account deletion", "userID", userID)
+
+	user, err := s.findDeletableUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Password != "" && user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		utils.LogInfo("Account deletion request on locked account", "userID", user.ID)
+		return nil, ErrTooManyLoginAttempts
+	}
+	if user.Password != "" && !utils.VerifyPassword(req.Password, user.Password) {
+		utils.LogInfo("Invalid password", "userID", user.ID)
+		failures := s.recordLoginFailure(user, client.IPAddress)
+		time.Sleep(s.loginDelay(failures))
+		return nil, ErrInvalidCurrentPassword
+	}
+
+	return s.scheduleAccountDeletion(user)
+}
+
+// AdminDeleteAccount schedules the deletion of the user, or deletes it immediately
+func (s *UserService) AdminDeleteAccount(userID uint, req dto.AdminAccountDeletionRequest) (*dto.AccountDeletionResponse, error) {
+	utils.LogInfo("Deleting account by admin", "userID", userID, "immediate", req.Immediate)
+
+	user, err := s.findDeletableUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.Immediate {
+		return s.scheduleAccountDeletion(user)
+	}
+
+	if err := s.deleteAccount(user.ID); err != nil {
+		return nil, err
+	}
+	return &dto.AccountDeletionResponse{IsDeleted: true}, nil
+}
+
+// CancelAccountDeletion cancels a scheduled deletion during the grace period
+func (s *UserService) CancelAccountDeletion(userID uint) (*dto.CancelAccountDeletionResponse, error) {
+	utils.LogInfo("Cancelling account deletion", "userID", userID)
+
+	result := s.DB.Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL AND anonymized_at IS NULL", userID).
+		Update("deletion_scheduled_at", nil)
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to cancel account deletion", result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := s.findDeletableUser(userID); err != nil {
+			return nil, err
+		}
+		return nil, ErrDeletionNotRequested
+	}
+
+	return &dto.CancelAccountDeletionResponse{IsSuccess: true}, nil
+}
+
+// RunAccountDeletionPurger carries out the due deletions every purge interval until the context is done
+func (s *UserService) RunAccountDeletionPurger(ctx context.Context) {
+	interval := s.AuthConfig.AccountDeletion.PurgeInterval
+	if interval <= 0 {
+		utils.LogWarn("Invalid account deletion purge interval, using the default", "interval", interval, "default", defaultPurgeInterval)
+		interval = defaultPurgeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeDueAccounts(); err != nil {
+			utils.LogErrorWithErr("Failed to purge deleted accounts", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDueAccounts deletes the accounts whose grace period is over and returns their number
+func (s *UserService) PurgeDueAccounts() (int, error) {
+	var userIDs []uint
+	if err := s.DB.Model(&models.User{}).
+		Where("deletion_scheduled_at <= ? AND anonymized_at IS NULL", time.Now()).
+		Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, userID := range userIDs {
+		if err := s.deleteAccount(userID); err != nil {
+			utils.LogErrorWithErr("Failed to delete account", err, "userID", userID)
+			continue
+		}
+		purged++
+	}
+	if purged > 0 {
+		utils.LogInfo("Purged deleted accounts", "count", purged)
+	}
+	return purged, nil
+}
+
+func (s *UserService) findDeletableUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+	if user.AnonymizedAt != nil {
+		return nil, ErrAccountDeleted
+	}
+	return &user, nil
+}
+
+func (s *UserService) scheduleAccountDeletion(user *models.User) (*dto.AccountDeletionResponse, error) {
+	if user.DeletionScheduledAt != nil {
+		return &dto.AccountDeletionResponse{ScheduledAt: user.DeletionScheduledAt}, nil
+	}
+	if err := ensureNotLastOwner(s.DB, user.ID); err != nil {
+		return nil, err
+	}
+
+	scheduledAt := time.Now().Add(s.AuthConfig.AccountDeletion.GracePeriod)
+	if err := s.DB.Model(user).Update("deletion_scheduled_at", scheduledAt).Error; err != nil {
+		utils.LogErrorWithErr("Failed to schedule account deletion", err, "userID", user.ID)
+		return nil, err
+	}
+
+	utils.LogInfo("Account deletion scheduled", "userID", user.ID, "scheduledAt", scheduledAt)
+
+	frontendURL := utils.GetEnv("FRONTEND_URL", "http://localhost:3000")
+	body := fmt.Sprintf(
+		"Your account will be deleted on %s. Until then you can cancel the deletion in your account settings: %s/settings/account",
+		scheduledAt.UTC().Format(time.RFC1123), frontendURL,
+	)
+	if err := utils.SendEmailWithContext(context.Background(), user.Email, "Your account will be deleted", body, false); err != nil {
+		utils.LogErrorWithErr("Failed to send account deletion email", err, "userID", user.ID)
+	}
+
+	return &dto.AccountDeletionResponse{ScheduledAt: &scheduledAt}, nil
+}
+
+// deleteAccount revokes every token of the user, removes its credentials and personal data and
+// then anonymizes or deletes the user row depending on the configured mode
+func (s *UserService) deleteAccount(userID uint) error {
+	var email string
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+		if user.AnonymizedAt != nil {
+			return nil
+		}
+		email = user.Email
+
+		if err := ensureNotLastOwner(tx, userID); err != nil {
+			return err
+		}
+		if _, err := revokeRefreshTokens(tx, s.TokenDenylist, "user_id = ?", userID); err != nil {
+			return err
+		}
+		if err := s.denyImpersonationTokens(tx, userID); err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM personal_access_token_claims WHERE personal_access_token_id IN (?)",
+			tx.Model(&models.PersonalAccessToken{}).Select("id").Where("user_id = ?", userID)).Error; err != nil {
+			return err
+		}
+		for _, model := range []any{
+			&models.RefreshToken{},
+			&models.PasswordResetToken{},
+			&models.RecoveryCode{},
+			&models.MFAChallenge{},
+			&models.PasskeyCredential{},
+			&models.PasskeyChallenge{},
+			&models.UserIdentity{},
+			&models.MagicLinkToken{},
+			&models.PasswordHistory{},
+			&models.PersonalAccessToken{},
+			&models.LoginAttempt{},
+			&models.OrganizationMembership{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				utils.LogErrorWithErr("Failed to delete user data", err, "userID", userID)
+				return err
+			}
+		}
+		if err := tx.Where("email = ?", user.Email).Delete(&models.OrganizationInvitation{}).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete organization invitations", err, "userID", userID)
+			return err
+		}
+		if err := tx.Model(&user).Association("Claims").Clear(); err != nil {
+			return err
+		}
+
+		if s.AuthConfig.AccountDeletion.Mode == config.AccountDeletionDelete {
+			// Impersonations are kept for auditing, so a user referenced by them is anonymized instead
+			var impersonations int64
+			if err := tx.Model(&models.Impersonation{}).
+				Where("subject_id = ? OR actor_id = ?", userID, userID).
+				Count(&impersonations).Error; err != nil {
+				return err
+			}
+			if impersonations == 0 {
+				return tx.Delete(&user).Error
+			}
+			utils.LogInfo("Anonymizing account referenced by the impersonation audit trail", "userID", userID)
+		}
+
+		now := time.Now()
+		return tx.Model(&user).Updates(map[string]any{
+			"username":              fmt.Sprintf("deleted-%d", user.ID),
+			"email":                 fmt.Sprintf("deleted-%d@deleted.invalid", user.ID),
+			"email_verified":        false,
+			"email_verified_at":     nil,
+			"password":              "",
+			"profile_image":         "",
+			"totp_secret":           "",
+			"totp_enabled":          false,
+			"passkey_handle":        nil,
+			"failed_login_count":    0,
+			"locked_until":          nil,
+			"deletion_scheduled_at": nil,
+			"anonymized_at":         now,
+		}).Error
+	})
+	if err != nil {
+		utils.LogErrorWithErr("Failed to delete account", err, "userID", userID)
+		return err
+	}
+	if email == "" {
+		return nil
+	}
+
+	utils.LogWarn("Account deleted", "userID", userID, "mode", s.AuthConfig.AccountDeletion.Mode)
+
+	if err := utils.SendEmailWithContext(context.Background(), email, "Your account has been deleted",
+		"Your account and its personal data have been deleted.", false); err != nil {
+		utils.LogErrorWithErr("Failed to send account deleted email", err, "userID", userID)
+	}
+	return nil
+}
+
+// denyImpersonationTokens revokes the unexpired impersonation tokens issued for the user
+func (s *UserService) denyImpersonationTokens(tx *gorm.DB, userID uint) error {
+	var impersonations []models.Impersonation
+	if err := tx.Where("subject_id = ? AND ended_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Find(&impersonations).Error; err != nil {
+		return err
+	}
+	for _, impersonation := range impersonations {
+		if err := s.TokenDenylist.Revoke(impersonation.TokenJTI, impersonation.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"testing"
+	"time"
+)
+
+func newTestAccountDeletionService(t *testing.T, mode string) *UserService {
+	t.Helper()
+
+	return NewUserService(newTestDB(t), config.Auth{
+		AccountDeletion: config.AccountDeletion{GracePeriod: time.Hour, Mode: mode},
+	}, NewMemoryTokenDenylist())
+}
+
+func TestAccountDeletionKeepsOrganizationOwner(t *testing.T) {
+	service := newTestAccountDeletionService(t, config.AccountDeletionDelete)
+	owner := createTestUser(t, service.DB, "alice")
+	if err := service.DB.Create(&models.Role{Name: OrganizationOwnerRole}).Error; err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+	if _, err := NewOrganizationService(service.DB, NewPolicies()).CreateOrganization(owner.ID, dto.CreateOrganizationRequest{Name: "acme"}); err != nil {
+		t.Fatalf("CreateOrganization() error = %v", err)
+	}
+
+	if _, err := service.RequestAccountDeletion(owner.ID, dto.RequestAccountDeletionRequest{Password: "Password123!"}, dto.SessionInfo{}); !errors.Is(err, ErrLastOrganizationOwner) {
+		t.Errorf("RequestAccountDeletion() error = %v, want %v", err, ErrLastOrganizationOwner)
+	}
+	if _, err := service.AdminDeleteAccount(owner.ID, dto.AdminAccountDeletionRequest{Immediate: true}); !errors.Is(err, ErrLastOrganizationOwner) {
+		t.Errorf("AdminDeleteAccount() error = %v, want %v", err, ErrLastOrganizationOwner)
+	}
+
+	var memberships int64
+	service.DB.Model(&models.OrganizationMembership{}).Where("user_id = ?", owner.ID).Count(&memberships)
+	if memberships != 1 {
+		t.Errorf("memberships = %d, want 1", memberships)
+	}
+}
+
+func TestAccountDeletionKeepsImpersonationAudit(t *testing.T) {
+	service := newTestAccountDeletionService(t, config.AccountDeletionDelete)
+	admin := createTestUser(t, service.DB, "admin")
+	subject := createTestUser(t, service.DB, "alice")
+	if err := service.DB.Create(&models.Impersonation{
+		ActorID:   admin.ID,
+		SubjectID: subject.ID,
+		Reason:    "support ticket",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}).Error; err != nil {
+		t.Fatalf("create impersonation: %v", err)
+	}
+
+	if _, err := service.AdminDeleteAccount(admin.ID, dto.AdminAccountDeletionRequest{Immediate: true}); err != nil {
+		t.Fatalf("AdminDeleteAccount() error = %v", err)
+	}
+
+	if _, err := service.AdminDeleteAccount(subject.ID, dto.AdminAccountDeletionRequest{Immediate: true}); err != nil {
+		t.Fatalf("AdminDeleteAccount() error = %v", err)
+	}
+
+	var impersonations int64
+	service.DB.Model(&models.Impersonation{}).Count(&impersonations)
+	if impersonations != 1 {
+		t.Errorf("impersonations = %d, want 1", impersonations)
+	}
+	for _, deleted := range []*models.User{admin, subject} {
+		var user models.User
+		if err := service.DB.First(&user, deleted.ID).Error; err != nil {
+			t.Fatalf("find user: %v", err)
+		}
+		if user.AnonymizedAt == nil || user.Email == deleted.Email {
+			t.Errorf("user referenced by the audit trail is not anonymized: %+v", user)
+		}
+	}
+
+	// Accounts without impersonations are still deleted
+	other := createTestUser(t, service.DB, "bob")
+	if _, err := service.AdminDeleteAccount(other.ID, dto.AdminAccountDeletionRequest{Immediate: true}); err != nil {
+		t.Fatalf("AdminDeleteAccount() error = %v", err)
+	}
+	if err := service.DB.First(&models.User{}, other.ID).Error; err == nil {
+		t.Errorf("user without impersonations is not deleted")
+	}
+}
+
+func TestAccountDeletionPurgerInvalidInterval(t *testing.T) {
+	service := newTestAccountDeletionService(t, config.AccountDeletionAnonymize)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Returns instead of panicking on the zero interval
+	service.RunAccountDeletionPurger(ctx)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// breachedPasswordCorpus looks passwords up in a local copy of the HIBP Pwned Passwords
+// SHA-1 corpus, the network is never used. Two layouts are supported:
+//   - a directory of range files named by the 5 character hash prefix (e.g. "5BAA6" or "5BAA6.txt"),
+//     each line being "SUFFIX:COUNT" as returned by the range API
+//   - a single file of "HASH:COUNT" or "HASH" lines, scanned for every lookup, for small corpora
+type breachedPasswordCorpus struct {
+	path string
+}
+
+func newBreachedPasswordCorpus(path string) *breachedPasswordCorpus {
+	return &breachedPasswordCorpus{path: path}
+}
+
+// Contains reports whether the password appears in the corpus
+func (c *breachedPasswordCorpus) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return scanBreachedHashes(c.path, hash)
+	}
+
+	prefix, suffix := hash[:5], hash[5:]
+	for _, name := range []string{prefix, prefix + ".txt"} {
+		found, err := scanBreachedHashes(filepath.Join(c.path, name), suffix)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return found, err
+	}
+	// A missing range file means no password with the prefix was breached
+	return false, nil
+}
+
+// scanBreachedHashes looks for the hash in a file of "HASH:COUNT" lines.
+// Lines with a zero count are padding entries of the range API and are ignored.
+func scanBreachedHashes(path, hash string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !strings.EqualFold(entry, hash) {
+			continue
+		}
+		if strings.TrimSpace(count) == "0" {
+			return false, nil
+		}
+		return true, nil
+	}
+	return false, scanner.Err()
+}
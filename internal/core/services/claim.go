@@ -3,8 +3,10 @@ package services
 import (
 	"errors"
 	"knowstack/internal/api/dto"
+	"knowstack/internal/core/authz"
 	"knowstack/internal/data/models"
 	"knowstack/internal/utils"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -12,8 +14,11 @@ import (
 var (
 	ErrClaimAlreadyExists = errors.New("claim already exists")
 	ErrClaimNotFound      = errors.New("claim not found")
+	ErrWildcardClaimName  = errors.New("wildcard claim names can't be created or renamed")
 )
 
+const defaultPageSize = 20
+
 type ClaimService struct {
 	DB *gorm.DB
 }
@@ -25,11 +30,30 @@ func NewClaimService(db *gorm.DB) *ClaimService {
 func (s *ClaimService) CreateClaim(req dto.CreateClaimRequest) (*dto.CreateClaimResponse, error) {
 	utils.LogInfo("Creating claim: %+v", req)
 
+	// A wildcard grants every claim it matches, which the caller may not hold or be allowed to grant
+	if authz.IsWildcard(req.Name) {
+		utils.LogInfo("Wildcard claim name rejected", "name", req.Name)
+		return nil, ErrWildcardClaimName
+	}
+
 	if err := s.DB.Where("name = ?", req.Name).First(&models.Claim{}).Error; err == nil {
 		utils.LogInfo("Claim already exists: %+v", req.Name)
 		return nil, ErrClaimAlreadyExists
 	}
 
+	// The name stays unique for deleted claims, so a deleted claim is restored instead
+	var deleted models.Claim
+	if err := s.DB.Unscoped().Where("name = ? AND deleted_at IS NOT NULL", req.Name).First(&deleted).Error; err == nil {
+		if err := s.DB.Unscoped().Model(&deleted).Update("deleted_at", nil).Error; err != nil {
+			utils.LogErrorWithErr("Failed to restore claim", err)
+			return nil, err
+		}
+		return &dto.CreateClaimResponse{
+			ID:   deleted.ID,
+			Name: deleted.Name,
+		}, nil
+	}
+
 	claim := &models.Claim{Name: req.Name}
 	if err := s.DB.Create(claim).Error; err != nil {
 		utils.LogErrorWithErr("Failed to create claim", err)
@@ -42,47 +66,141 @@ func (s *ClaimService) CreateClaim(req dto.CreateClaimRequest) (*dto.CreateClaim
 	}, nil
 }
 
+// DeleteClaim deletes the claim and removes it from the roles, users and personal access tokens holding it.
+// Seeded claims stay deleted across restarts, CreateClaim with the same name restores them.
 func (s *ClaimService) DeleteClaim(req dto.DeleteClaimRequest) (*dto.DeleteClaimResponse, error) {
 	utils.LogInfo("Deleting claim: %+v", req)
 
-	var claim models.Claim
-	if err := s.DB.Where("id = ?", req.ID).First(&claim).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.LogInfo("Claim not found: %+v", req.ID)
-			return nil, ErrClaimNotFound
+	response := &dto.DeleteClaimResponse{Message: "Claim deleted successfully, it isn't seeded again on restart and can be restored by creating it"}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var claim models.Claim
+		if err := tx.Preload("Roles").Preload("Users").Where("id = ?", req.ID).First(&claim).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.LogInfo("Claim not found: %+v", req.ID)
+				return ErrClaimNotFound
+			}
+			utils.LogErrorWithErr("Failed to find claim", err)
+			return err
 		}
-		utils.LogErrorWithErr("Failed to find claim", err)
-		return nil, err
-	}
 
-	if err := s.DB.Delete(&claim).Error; err != nil {
-		utils.LogErrorWithErr("Failed to delete claim", err)
+		response.Roles = make([]string, len(claim.Roles))
+		for i, role := range claim.Roles {
+			response.Roles[i] = role.Name
+		}
+		response.Users = claimHolders(claim.Users)
+
+		roleIDs := make([]uint, len(claim.Roles))
+		for i, role := range claim.Roles {
+			roleIDs[i] = role.ID
+		}
+		var roleMembers []models.User
+		if len(roleIDs) > 0 {
+			if err := tx.Select("id", "username").Where("role_id IN ?", roleIDs).Order("id").Find(&roleMembers).Error; err != nil {
+				utils.LogErrorWithErr("Failed to find role members", err)
+				return err
+			}
+		}
+		response.RoleMembers = claimHolders(roleMembers)
+
+		// The claim is soft deleted, the associations are removed so it isn't granted anymore
+		for _, association := range []string{"Roles", "Users"} {
+			if err := tx.Model(&claim).Association(association).Clear(); err != nil {
+				utils.LogErrorWithErr("Failed to clear claim associations", err)
+				return err
+			}
+		}
+		result := tx.Exec("DELETE FROM personal_access_token_claims WHERE claim_id = ?", claim.ID)
+		if result.Error != nil {
+			utils.LogErrorWithErr("Failed to remove claim from personal access tokens", result.Error)
+			return result.Error
+		}
+		response.PersonalAccessTokens = result.RowsAffected
+
+		if err := tx.Delete(&claim).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete claim", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return &dto.DeleteClaimResponse{
-		Message: "Claim deleted successfully",
-	}, nil
+	utils.LogInfo("Claim deleted", "claimID", req.ID, "roles", len(response.Roles), "users", len(response.Users), "roleMembers", len(response.RoleMembers))
+	return response, nil
 }
 
-func (s *ClaimService) GetClaims() ([]dto.GetClaimsResponse, error) {
+func claimHolders(users []models.User) []dto.ClaimHolder {
+	holders := make([]dto.ClaimHolder, len(users))
+	for i, user := range users {
+		holders[i] = dto.ClaimHolder{ID: user.ID, Username: user.Username}
+	}
+	return holders
+}
+
+// GetClaims returns a page of the claims, optionally filtered by a part of the name
+func (s *ClaimService) GetClaims(query dto.ListClaimsQuery) (*dto.ListClaimsResponse, error) {
 	utils.LogInfo("Getting claims")
 
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = defaultPageSize
+	}
+
+	db := s.DB.Model(&models.Claim{})
+	if query.Search != "" {
+		db = db.Where("name ILIKE ?", "%"+escapeLike(query.Search)+"%")
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count claims", err)
+		return nil, err
+	}
+
 	var claims []models.Claim
-	if err := s.DB.Where("deleted_at IS NULL").Find(&claims).Error; err != nil {
+	if err := db.Order("name").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&claims).Error; err != nil {
 		utils.LogErrorWithErr("Failed to get claims", err)
 		return nil, err
 	}
 
-	response := make([]dto.GetClaimsResponse, len(claims))
+	items := make([]dto.GetClaimsResponse, len(claims))
 	for i, claim := range claims {
-		response[i] = dto.GetClaimsResponse{
+		items[i] = dto.GetClaimsResponse{
 			ID:   claim.ID,
 			Name: claim.Name,
 		}
 	}
 
-	return response, nil
+	return &dto.ListClaimsResponse{
+		Items:    items,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
+
+func (s *ClaimService) GetClaim(claimID uint) (*dto.GetClaimsResponse, error) {
+	utils.LogInfo("Getting claim", "claimID", claimID)
+
+	var claim models.Claim
+	if err := s.DB.Where("id = ?", claimID).First(&claim).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClaimNotFound
+		}
+		utils.LogErrorWithErr("Failed to find claim", err)
+		return nil, err
+	}
+
+	return &dto.GetClaimsResponse{
+		ID:   claim.ID,
+		Name: claim.Name,
+	}, nil
 }
 
 func (s *ClaimService) UpdateClaim(req dto.UpdateClaimRequest) (*dto.UpdateClaimResponse, error) {
@@ -98,7 +216,14 @@ func (s *ClaimService) UpdateClaim(req dto.UpdateClaimRequest) (*dto.UpdateClaim
 		return nil, err
 	}
 
-	if err := s.DB.Where("name = ? AND id != ?", req.Name, req.ID).First(&models.Claim{}).Error; err == nil {
+	// Renamed to a wildcard the claim would grant its holders every claim the wildcard matches,
+	// and the seeded wildcards lose their meaning when renamed
+	if authz.IsWildcard(req.Name) || authz.IsWildcard(claim.Name) {
+		utils.LogInfo("Wildcard claim rename rejected", "claimID", claim.ID, "name", req.Name)
+		return nil, ErrWildcardClaimName
+	}
+
+	if err := s.DB.Unscoped().Where("name = ? AND id != ?", req.Name, req.ID).First(&models.Claim{}).Error; err == nil {
 		utils.LogInfo("Claim name already exists: %+v", req.Name)
 		return nil, ErrClaimAlreadyExists
 	}
@@ -114,3 +239,8 @@ func (s *ClaimService) UpdateClaim(req dto.UpdateClaimRequest) (*dto.UpdateClaim
 		Name: claim.Name,
 	}, nil
 }
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/data/models"
+	"testing"
+)
+
+func TestDeleteClaimReportsHolders(t *testing.T) {
+	db := newTestDB(t)
+	claim := models.Claim{Name: "report:read"}
+	if err := db.Create(&claim).Error; err != nil {
+		t.Fatalf("create claim: %v", err)
+	}
+	direct := createTestUser(t, db, "alice")
+	member := createTestUser(t, db, "bob")
+	createTestUser(t, db, "carol")
+
+	role := models.Role{Name: "analyst", Claims: []models.Claim{claim}}
+	if err := db.Create(&role).Error; err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+	db.Model(member).Update("role_id", role.ID)
+	if err := db.Model(direct).Association("Claims").Append(&claim); err != nil {
+		t.Fatalf("grant claim: %v", err)
+	}
+
+	res, err := NewClaimService(db).DeleteClaim(dto.DeleteClaimRequest{ID: claim.ID})
+	if err != nil {
+		t.Fatalf("DeleteClaim() error = %v", err)
+	}
+
+	if len(res.Roles) != 1 || res.Roles[0] != "analyst" {
+		t.Errorf("roles = %v, want [analyst]", res.Roles)
+	}
+	if len(res.Users) != 1 || res.Users[0].ID != direct.ID {
+		t.Errorf("users = %+v, want %s", res.Users, direct.Username)
+	}
+	if len(res.RoleMembers) != 1 || res.RoleMembers[0].ID != member.ID || res.RoleMembers[0].Username != member.Username {
+		t.Errorf("role members = %+v, want %s", res.RoleMembers, member.Username)
+	}
+}
+
+func TestWildcardClaimNamesRejected(t *testing.T) {
+	db := newTestDB(t)
+	service := NewClaimService(db)
+	held := models.Claim{Name: "report:read"}
+	wildcard := models.Claim{Name: "report:*"}
+	if err := db.Create(&[]*models.Claim{&held, &wildcard}).Error; err != nil {
+		t.Fatalf("create claims: %v", err)
+	}
+	role := models.Role{Name: "analyst", Claims: []models.Claim{held}}
+	if err := db.Create(&role).Error; err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+
+	// Renaming a held claim to an unseeded wildcard would grant every user claim to the role
+	for _, name := range []string{"user:*", "*", "organization:member:*"} {
+		if _, err := service.UpdateClaim(dto.UpdateClaimRequest{ID: held.ID, Name: name}); !errors.Is(err, ErrWildcardClaimName) {
+			t.Errorf("UpdateClaim(%q) error = %v, want %v", name, err, ErrWildcardClaimName)
+		}
+		if _, err := service.CreateClaim(dto.CreateClaimRequest{Name: name}); !errors.Is(err, ErrWildcardClaimName) {
+			t.Errorf("CreateClaim(%q) error = %v, want %v", name, err, ErrWildcardClaimName)
+		}
+	}
+	if _, err := service.UpdateClaim(dto.UpdateClaimRequest{ID: wildcard.ID, Name: "report:export"}); !errors.Is(err, ErrWildcardClaimName) {
+		t.Errorf("renaming a wildcard error = %v, want %v", err, ErrWildcardClaimName)
+	}
+
+	var stored models.Claim
+	if err := db.First(&stored, held.ID).Error; err != nil {
+		t.Fatalf("find claim: %v", err)
+	}
+	if stored.Name != "report:read" {
+		t.Errorf("claim name = %q, want report:read", stored.Name)
+	}
+
+	// A name with an asterisk that isn't a wildcard segment is an ordinary claim
+	if _, err := service.UpdateClaim(dto.UpdateClaimRequest{ID: held.ID, Name: "report:read*"}); err != nil {
+		t.Errorf("UpdateClaim(report:read*) error = %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExportUserData collects everything stored about the user into a machine-readable archive.
+// Secrets such as password hashes, token values and TOTP secrets are left out.
+func (s *UserService) ExportUserData(userID uint) (*dto.UserDataExport, error) {
+	utils.LogInfo("Exporting user data", "userID", userID)
+
+	var user models.User
+	if err := s.DB.
+		Preload("Role.Claims").
+		Preload("Claims").
+		Where("id = ?", userID).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+
+	export := &dto.UserDataExport{
+		ExportedAt: time.Now(),
+		Profile: dto.ExportProfile{
+			ID:                  user.ID,
+			Username:            user.Username,
+			Email:               user.Email,
+			EmailVerified:       user.EmailVerified,
+			EmailVerifiedAt:     user.EmailVerifiedAt,
+			Provider:            user.Provider,
+			ProfileImage:        user.ProfileImage,
+			HasPassword:         user.Password != "",
+			TwoFactorEnabled:    user.TOTPEnabled,
+			LockedUntil:         user.LockedUntil,
+			DeletionScheduledAt: user.DeletionScheduledAt,
+			CreatedAt:           user.CreatedAt,
+			UpdatedAt:           user.UpdatedAt,
+		},
+		Role: dto.ExportRole{
+			ID:     user.Role.ID,
+			Name:   user.Role.Name,
+			Claims: claimNames(user.Role.Claims),
+		},
+		Claims: claimNames(user.Claims),
+	}
+
+	var refreshTokens []models.RefreshToken
+	if err := s.DB.Where("user_id = ?", user.ID).Order("created_at").Find(&refreshTokens).Error; err != nil {
+		utils.LogErrorWithErr("Failed to export sessions", err)
+		return nil, err
+	}
+	export.Sessions = make([]dto.ExportSession, len(refreshTokens))
+	for i, token := range refreshTokens {
+		export.Sessions[i] = dto.ExportSession{
+			ID:          token.ID,
+			SessionID:   token.FamilyID,
+			DeviceLabel: token.DeviceLabel,
+			UserAgent:   token.UserAgent,
+			IPAddress:   token.IPAddress,
+			IsRevoked:   token.IsRevoked,
+			CreatedAt:   token.CreatedAt,
+			LastUsedAt:  token.LastUsedAt,
+			ExpiresAt:   token.ExpiresAt,
+		}
+	}
+
+	var identities []models.UserIdentity
+	if err := s.DB.Where("user_id = ?", user.ID).Order("created_at").Find(&identities).Error; err != nil {
+		utils.LogErrorWithErr("Failed to export identities", err)
+		return nil, err
+	}
+	export.Identities = make([]dto.IdentityResponse, len(identities))
+	for i, identity := range identities {
+		export.Identities[i] = dto.IdentityResponse{
+			ID:         identity.ID,
+			Provider:   identity.Provider,
+			Email:      identity.Email,
+			CreatedAt:  identity.CreatedAt,
+			LastUsedAt: identity.LastUsedAt,
+		}
+	}
+
+	var passkeys []models.PasskeyCredential
+	if err := s.DB.Where("user_id = ?", user.ID).Order("created_at").Find(&passkeys).Error; err != nil {
+		utils.LogErrorWithErr("Failed to export passkeys", err)
+		return nil, err
+	}
+	export.Passkeys = make([]dto.PasskeyResponse, len(passkeys))
+	for i := range passkeys {
+		export.Passkeys[i] = *toPasskeyResponse(&passkeys[i])
+	}
+
+	var personalAccessTokens []models.PersonalAccessToken
+	if err := s.DB.Preload("Claims").Where("user_id = ?", user.ID).Order("created_at").Find(&personalAccessTokens).Error; err != nil {
+		utils.LogErrorWithErr("Failed to export personal access tokens", err)
+		return nil, err
+	}
+	export.PersonalAccessTokens = make([]dto.PersonalAccessTokenResponse, len(personalAccessTokens))
+	for i := range personalAccessTokens {
+		export.PersonalAccessTokens[i] = personalAccessTokenResponse(&personalAccessTokens[i])
+	}
+
+	var attempts []models.LoginAttempt
+	if err := s.DB.Where("user_id = ?", user.ID).Order("created_at").Find(&attempts).Error; err != nil {
+		utils.LogErrorWithErr("Failed to export login attempts", err)
+		return nil, err
+	}
+	export.LoginAttempts = make([]dto.ExportLoginAttempt, len(attempts))
+	for i, attempt := range attempts {
+		export.LoginAttempts[i] = dto.ExportLoginAttempt{
+			IPAddress: attempt.IPAddress,
+			Succeeded: attempt.Succeeded,
+			CreatedAt: attempt.CreatedAt,
+		}
+	}
+
+	var impersonations []models.Impersonation
+	if err := s.DB.Preload("Actor").Where("subject_id = ?", user.ID).Order("created_at").Find(&impersonations).Error; err != nil {
+		utils.LogErrorWithErr("Failed to export impersonations", err)
+		return nil, err
+	}
+	export.Impersonations = make([]dto.ExportImpersonation, len(impersonations))
+	for i, impersonation := range impersonations {
+		export.Impersonations[i] = dto.ExportImpersonation{
+			ActorUsername: impersonation.Actor.Username,
+			Reason:        impersonation.Reason,
+			CreatedAt:     impersonation.CreatedAt,
+			EndedAt:       impersonation.EndedAt,
+		}
+	}
+
+	var memberships []models.OrganizationMembership
+	if err := s.DB.Preload("Organization").Preload("Role").Where("user_id = ?", user.ID).Order("created_at").Find(&memberships).Error; err != nil {
+		utils.LogErrorWithErr("Failed to export organization memberships", err)
+		return nil, err
+	}
+	export.Organizations = make([]dto.UserOrganizationResponse, len(memberships))
+	for i := range memberships {
+		export.Organizations[i] = userOrganizationResponse(&memberships[i])
+	}
+
+	return export, nil
+}
+
+func claimNames(claims []models.Claim) []string {
+	names := make([]string, len(claims))
+	for i, claim := range claims {
+		names[i] = claim.Name
+	}
+	return names
+}
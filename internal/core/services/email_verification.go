@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmailNotVerified         = errors.New("email not verified")
+	ErrInvalidVerificationToken = errors.New("invalid email verification token")
+	ErrVerificationTokenExpired = errors.New("email verification token expired")
+)
+
+// VerifyEmail marks the email of the user as verified if the token is still valid
+func (s *UserService) VerifyEmail(req dto.VerifyEmailRequest) (*dto.VerifyEmailResponse, error) {
+	utils.LogInfo("Verifying email")
+
+	claims, err := utils.ValidateEmailVerificationToken(req.Token)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to validate email verification token", err)
+		if errors.Is(err, utils.ErrTokenExpired) {
+			return nil, ErrVerificationTokenExpired
+		}
+		return nil, ErrInvalidVerificationToken
+	}
+
+	var user models.User
+	if err := s.DB.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogInfo("User not found", "userID", claims.UserID)
+			return nil, ErrInvalidVerificationToken
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+
+	// The token is only valid for the email it was sent to
+	if user.Email != claims.Email {
+		utils.LogInfo("Email verification token does not match the current email", "userID", user.ID)
+		return nil, ErrInvalidVerificationToken
+	}
+
+	if user.EmailVerified {
+		return &dto.VerifyEmailResponse{IsSuccess: true}, nil
+	}
+
+	now := time.Now()
+	if err := s.DB.Model(&user).Updates(map[string]any{
+		"email_verified":    true,
+		"email_verified_at": now,
+	}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to verify email", err)
+		return nil, err
+	}
+
+	return &dto.VerifyEmailResponse{IsSuccess: true}, nil
+}
+
+// ResendVerificationEmail sends a new verification email to an unverified account
+func (s *UserService) ResendVerificationEmail(req dto.ResendVerificationEmailRequest) (*dto.ResendVerificationEmailResponse, error) {
+	utils.LogInfo("Resending verification email", "email", req.Email)
+
+	var user models.User
+	if err := s.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogInfo("User not found", "email", req.Email)
+			// Don't reveal if user exists or not for security reasons
+			return &dto.ResendVerificationEmailResponse{IsSuccess: true}, nil
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+
+	if user.EmailVerified {
+		utils.LogInfo("Email already verified", "userID", user.ID)
+		return &dto.ResendVerificationEmailResponse{IsSuccess: true}, nil
+	}
+
+	if err := s.sendVerificationEmail(&user); err != nil {
+		utils.LogErrorWithErr("Failed to send verification email", err)
+	}
+
+	return &dto.ResendVerificationEmailResponse{IsSuccess: true}, nil
+}
+
+// sendVerificationEmail emails a signed verification link to the user
+func (s *UserService) sendVerificationEmail(user *models.User) error {
+	token, err := utils.GenerateEmailVerificationToken(strconv.FormatUint(uint64(user.ID), 10), user.Email)
+	if err != nil {
+		return err
+	}
+
+	frontendURL := utils.GetEnv("FRONTEND_URL", "http://localhost:3000")
+	verifyURL := fmt.Sprintf("%s/verify-email?token=%s", frontendURL, token)
+	body := fmt.Sprintf("Click the link to verify your email address: %s", verifyURL)
+
+	return utils.SendEmailWithContext(context.Background(), user.Email, "Verify your email address", body, false)
+}
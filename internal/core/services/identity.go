@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrIdentityAlreadyLinked = errors.New("identity already linked to another user")
+	ErrLastLoginMethod       = errors.New("cannot remove the last login method")
+)
+
+type IdentityService struct {
+	DB       *gorm.DB
+	Policies *authz.Policies
+}
+
+func NewIdentityService(db *gorm.DB, policies *authz.Policies) *IdentityService {
+	return &IdentityService{DB: db, Policies: policies}
+}
+
+// ListIdentities returns the external accounts linked to the user
+func (s *IdentityService) ListIdentities(subject authz.Subject) ([]dto.IdentityResponse, error) {
+	utils.LogInfo("Listing identities", "userID", subject.UserID)
+
+	var identities []models.UserIdentity
+	if err := s.DB.Where("user_id = ?", subject.UserID).Order("created_at").Find(&identities).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list identities", err)
+		return nil, err
+	}
+
+	response := make([]dto.IdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		if !s.Policies.Can(subject, authz.ActionRead, authz.Resource{Type: ResourceIdentity, ID: identity.ID, OwnerID: identity.UserID}) {
+			continue
+		}
+		response = append(response, dto.IdentityResponse{
+			ID:         identity.ID,
+			Provider:   identity.Provider,
+			Email:      identity.Email,
+			CreatedAt:  identity.CreatedAt,
+			LastUsedAt: identity.LastUsedAt,
+		})
+	}
+
+	return response, nil
+}
+
+// UnlinkIdentity removes a linked external account unless it is the last way the user can log in
+func (s *IdentityService) UnlinkIdentity(subject authz.Subject, identityID uint) (*dto.UnlinkIdentityResponse, error) {
+	utils.LogInfo("Unlinking identity", "userID", subject.UserID, "identityID", identityID)
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		if err := tx.Where("id = ?", identityID).First(&identity).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrIdentityNotFound
+			}
+			utils.LogErrorWithErr("Failed to find identity", err)
+			return err
+		}
+		resource := authz.Resource{Type: ResourceIdentity, ID: identity.ID, OwnerID: identity.UserID}
+		// A record the subject can't read is reported as missing so its ID doesn't leak
+		if !s.Policies.Can(subject, authz.ActionRead, resource) {
+			return ErrIdentityNotFound
+		}
+		if err := s.Policies.Authorize(subject, authz.ActionDelete, resource); err != nil {
+			return err
+		}
+
+		if err := ensureAnotherLoginMethod(tx, identity.UserID); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&identity).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete identity", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.UnlinkIdentityResponse{IsSuccess: true}, nil
+}
+
+/*
+Make sure the user keeps a way to log in after one login method is removed.
+Login methods are the password, the linked identities and the passkeys.
+The user row is locked so concurrent removals can't both pass the check.
+Must be called inside a transaction.
+*/
+func ensureAnotherLoginMethod(tx *gorm.DB, userID uint) error {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "password").Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return err
+	}
+
+	methods := int64(0)
+	if user.Password != "" {
+		methods++
+	}
+
+	var identities, passkeys int64
+	if err := tx.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&identities).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count identities", err)
+		return err
+	}
+	if err := tx.Model(&models.PasskeyCredential{}).Where("user_id = ?", userID).Count(&passkeys).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count passkeys", err)
+		return err
+	}
+	methods += identities + passkeys
+
+	if methods <= 1 {
+		utils.LogInfo("Refusing to remove the last login method", "userID", userID)
+		return ErrLastLoginMethod
+	}
+	return nil
+}
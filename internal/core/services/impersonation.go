@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImpersonateClaim allows an admin to act as another user
+const ImpersonateClaim = "user:impersonate"
+
+var (
+	ErrImpersonationNotAllowed = errors.New("user can not be impersonated")
+	ErrNotImpersonating        = errors.New("token is not an impersonation token")
+)
+
+// impersonationAuditLimit is the number of most recent impersonations listed for admins
+const impersonationAuditLimit = 100
+
+type ImpersonationService struct {
+	DB            *gorm.DB
+	TokenDenylist TokenDenylist
+}
+
+func NewImpersonationService(db *gorm.DB, tokenDenylist TokenDenylist) *ImpersonationService {
+	return &ImpersonationService{DB: db, TokenDenylist: tokenDenylist}
+}
+
+// Impersonate issues a short lived access token of the subject for the acting admin and records it.
+// The token belongs to the admin's session; admins that can impersonate can't be impersonated themselves.
+func (s *ImpersonationService) Impersonate(actor *utils.TokenClaims, subjectID uint, req dto.ImpersonateUserRequest, client dto.SessionInfo) (*dto.ImpersonateUserResponse, error) {
+	actorID64, err := strconv.ParseUint(actor.UserID, 10, 32)
+	if err != nil {
+		return nil, ErrParseError
+	}
+	actorID := uint(actorID64)
+
+	if actorID == subjectID {
+		utils.LogInfo("Admin tried to impersonate itself", "userID", actorID)
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	var subject models.User
+	if err := s.DB.
+		Preload("Role.Claims").
+		Preload("Claims").
+		Where("id = ?", subjectID).
+		First(&subject).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+
+	if subject.DisabledAt != nil {
+		utils.LogInfo("Admin tried to impersonate a disabled user", "actorID", actorID, "subjectID", subjectID)
+		return nil, ErrAccountDisabled
+	}
+
+	claimNames := mergeClaimNames(&subject)
+	if authz.NewClaimSet(claimNames).Has(ImpersonateClaim) {
+		utils.LogWarn("Security event: impersonation of a privileged user denied", "actorID", actorID, "subjectID", subjectID)
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	var response *dto.ImpersonateUserResponse
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		record := models.Impersonation{
+			ActorID:   actorID,
+			SubjectID: subject.ID,
+			Reason:    req.Reason,
+			SessionID: actor.SessionID,
+			IPAddress: client.IPAddress,
+			UserAgent: client.UserAgent,
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			utils.LogErrorWithErr("Failed to create impersonation record", err)
+			return err
+		}
+
+		accessToken, claims, err := utils.GenerateImpersonationToken(
+			strconv.FormatUint(uint64(subject.ID), 10),
+			subject.Email,
+			subject.Username,
+			subject.RoleID,
+			claimNames,
+			actor.SessionID,
+			utils.ActorClaims{
+				UserID:          actor.UserID,
+				Username:        actor.Username,
+				ImpersonationID: strconv.FormatUint(uint64(record.ID), 10),
+			},
+		)
+		if err != nil {
+			utils.LogErrorWithErr("Failed to generate impersonation token", err)
+			return err
+		}
+
+		if err := tx.Model(&record).Updates(map[string]any{
+			"token_jti":  claims.ID,
+			"expires_at": claims.ExpiresAt.Time,
+		}).Error; err != nil {
+			utils.LogErrorWithErr("Failed to update impersonation record", err)
+			return err
+		}
+
+		response = &dto.ImpersonateUserResponse{
+			ImpersonationID: record.ID,
+			AccessToken:     accessToken,
+			ExpiresAt:       claims.ExpiresAt.Time,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogWarn("Security event: impersonation started",
+		"actorID", actorID,
+		"subjectID", subject.ID,
+		"impersonationID", response.ImpersonationID,
+		"ip", client.IPAddress,
+		"reason", req.Reason,
+	)
+
+	return response, nil
+}
+
+// EndImpersonation revokes the impersonation token the request was made with
+func (s *ImpersonationService) EndImpersonation(claims *utils.TokenClaims) (*dto.EndImpersonationResponse, error) {
+	if claims.Actor == nil {
+		return nil, ErrNotImpersonating
+	}
+
+	if err := s.TokenDenylist.Revoke(claims.ID, claims.ExpiresAt.Time); err != nil {
+		utils.LogErrorWithErr("Failed to revoke impersonation token", err)
+		return nil, err
+	}
+
+	if err := s.DB.Model(&models.Impersonation{}).
+		Where("token_jti = ? AND ended_at IS NULL", claims.ID).
+		Update("ended_at", time.Now()).Error; err != nil {
+		utils.LogErrorWithErr("Failed to end impersonation", err)
+		return nil, err
+	}
+
+	utils.LogWarn("Security event: impersonation ended",
+		"actorID", claims.Actor.UserID,
+		"subjectID", claims.UserID,
+		"impersonationID", claims.Actor.ImpersonationID,
+	)
+
+	return &dto.EndImpersonationResponse{IsSuccess: true}, nil
+}
+
+// ListImpersonations returns the most recent impersonations for auditing
+func (s *ImpersonationService) ListImpersonations() ([]dto.ImpersonationResponse, error) {
+	var records []models.Impersonation
+	if err := s.DB.
+		Preload("Actor").
+		Preload("Subject").
+		Order("created_at DESC").
+		Limit(impersonationAuditLimit).
+		Find(&records).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list impersonations", err)
+		return nil, err
+	}
+
+	response := make([]dto.ImpersonationResponse, len(records))
+	for i, record := range records {
+		response[i] = dto.ImpersonationResponse{
+			ID:              record.ID,
+			ActorID:         record.ActorID,
+			ActorUsername:   record.Actor.Username,
+			SubjectID:       record.SubjectID,
+			SubjectUsername: record.Subject.Username,
+			Reason:          record.Reason,
+			IPAddress:       record.IPAddress,
+			CreatedAt:       record.CreatedAt,
+			ExpiresAt:       record.ExpiresAt,
+			EndedAt:         record.EndedAt,
+		}
+	}
+
+	return response, nil
+}
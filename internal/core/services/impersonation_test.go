@@ -0,0 +1,51 @@
+package services
+
+import (
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"testing"
+)
+
+func TestImpersonationEndsWithAdminSession(t *testing.T) {
+	db := newTestDB(t)
+	createTestUser(t, db, "admin")
+	subject := createTestUser(t, db, "alice")
+	denylist := NewMemoryTokenDenylist()
+	users := NewUserService(db, config.Auth{}, denylist)
+	impersonations := NewImpersonationService(db, denylist)
+
+	login, err := users.Login(dto.LoginRequest{Email: "admin@example.com", Password: "Password123!"}, dto.SessionInfo{})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	actor, err := utils.VerifyAccessToken(login.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyAccessToken() error = %v", err)
+	}
+
+	res, err := impersonations.Impersonate(actor, subject.ID, dto.ImpersonateUserRequest{Reason: "support ticket"}, dto.SessionInfo{})
+	if err != nil {
+		t.Fatalf("Impersonate() error = %v", err)
+	}
+	claims, err := utils.VerifyAccessToken(res.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyAccessToken() error = %v", err)
+	}
+
+	if _, err := users.Logout(dto.LogoutRequest{RefreshToken: login.RefreshToken}); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+
+	if revoked, _ := denylist.IsRevoked(claims.ID); !revoked {
+		t.Errorf("impersonation token is not revoked after the admin logged out")
+	}
+	var record models.Impersonation
+	if err := db.First(&record, res.ImpersonationID).Error; err != nil {
+		t.Fatalf("find impersonation: %v", err)
+	}
+	if record.EndedAt == nil {
+		t.Errorf("impersonation is not ended after the admin logged out")
+	}
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCredentials   = errors.New("invalid credentials")
+	ErrTooManyLoginAttempts = errors.New("too many login attempts")
+	ErrInvalidUnlockToken   = errors.New("invalid account unlock token")
+	ErrAccountNotLocked     = errors.New("account not locked")
+)
+
+// loginAttemptRetention is how long login attempts are kept for the per IP window and auditing
+const loginAttemptRetention = 7 * 24 * time.Hour
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+
+	attemptSweepMu   sync.Mutex
+	attemptSweepNext time.Time
+)
+
+// loginThrottler counts failed logins per account and per IP address.
+// It is shared by the login methods, so a second factor can't be guessed faster than a password.
+type loginThrottler struct {
+	DB     *gorm.DB
+	Config config.LoginThrottle
+}
+
+func newLoginThrottler(db *gorm.DB, throttle config.LoginThrottle) *loginThrottler {
+	return &loginThrottler{DB: db, Config: throttle}
+}
+
+// dummyPasswordHash is verified against when no account matches the email,
+// so unknown emails take as long as wrong passwords
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		hash, err := utils.HashPassword("knowstack-dummy-password")
+		if err != nil {
+			utils.LogErrorWithErr("Failed to create dummy password hash", err)
+			return
+		}
+		dummyHash = hash
+	})
+	return dummyHash
+}
+
+// ipBlocked reports whether the IP address reached the failed attempt limit within the window
+func (s *loginThrottler) ipBlocked(ip string) (bool, error) {
+	throttle := s.Config
+	if ip == "" || throttle.MaxIPFailures <= 0 {
+		return false, nil
+	}
+
+	var failures int64
+	if err := s.DB.Model(&models.LoginAttempt{}).
+		Where("ip_address = ? AND succeeded = ? AND created_at > ?", ip, false, time.Now().Add(-throttle.IPWindow)).
+		Count(&failures).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count login attempts", err, "ip", ip)
+		return false, err
+	}
+
+	return failures >= int64(throttle.MaxIPFailures), nil
+}
+
+// recordLoginAttempt stores the attempt and occasionally removes expired ones
+func (s *loginThrottler) recordLoginAttempt(email string, userID *uint, ip string, succeeded bool) {
+	attempt := models.LoginAttempt{
+		Email:     email,
+		IPAddress: ip,
+		Succeeded: succeeded,
+		UserID:    userID,
+	}
+	if err := s.DB.Create(&attempt).Error; err != nil {
+		utils.LogErrorWithErr("Failed to record login attempt", err, "email", email)
+	}
+
+	attemptSweepMu.Lock()
+	due := time.Now().After(attemptSweepNext)
+	if due {
+		attemptSweepNext = time.Now().Add(time.Hour)
+	}
+	attemptSweepMu.Unlock()
+
+	if due {
+		if err := s.DB.Where("created_at < ?", time.Now().Add(-loginAttemptRetention)).
+			Delete(&models.LoginAttempt{}).Error; err != nil {
+			utils.LogErrorWithErr("Failed to sweep login attempts", err)
+		}
+	}
+}
+
+// recordLoginFailure counts a failed password for the account and locks it once the limit is reached.
+// It returns the number of consecutive failures, used for the response delay.
+func (s *loginThrottler) recordLoginFailure(user *models.User, ip string) int {
+	s.recordLoginAttempt(user.Email, &user.ID, ip, false)
+
+	if err := s.DB.Model(user).
+		UpdateColumn("failed_login_count", gorm.Expr("failed_login_count + 1")).Error; err != nil {
+		utils.LogErrorWithErr("Failed to increment failed login count", err, "userID", user.ID)
+		return 1
+	}
+
+	var failures int
+	if err := s.DB.Model(&models.User{}).Where("id = ?", user.ID).
+		Pluck("failed_login_count", &failures).Error; err != nil {
+		utils.LogErrorWithErr("Failed to read failed login count", err, "userID", user.ID)
+		return 1
+	}
+
+	throttle := s.Config
+	if throttle.MaxAccountFailures <= 0 || failures < throttle.MaxAccountFailures {
+		return failures
+	}
+
+	// Only the request that reaches the limit locks the account and sends the email
+	lockedUntil := time.Now().Add(throttle.LockoutDuration).Truncate(time.Second)
+	result := s.DB.Model(&models.User{}).
+		Where("id = ? AND failed_login_count >= ?", user.ID, throttle.MaxAccountFailures).
+		Updates(map[string]any{"failed_login_count": 0, "locked_until": lockedUntil})
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to lock account", result.Error, "userID", user.ID)
+		return failures
+	}
+	if result.RowsAffected == 0 {
+		return failures
+	}
+
+	utils.LogWarn("Security event: account locked after failed logins",
+		"userID", user.ID,
+		"ip", ip,
+		"lockedUntil", lockedUntil,
+	)
+
+	if err := s.sendUnlockEmail(user, lockedUntil); err != nil {
+		utils.LogErrorWithErr("Failed to send unlock email", err, "userID", user.ID)
+	}
+
+	return failures
+}
+
+// recordLoginSuccess resets the failure counter of the account
+func (s *loginThrottler) recordLoginSuccess(user *models.User, ip string) {
+	s.recordLoginAttempt(user.Email, &user.ID, ip, true)
+
+	if user.FailedLoginCount == 0 && user.LockedUntil == nil {
+		return
+	}
+	if err := s.DB.Model(user).Updates(map[string]any{
+		"failed_login_count": 0,
+		"locked_until":       nil,
+	}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to reset failed login count", err, "userID", user.ID)
+	}
+}
+
+// emailFailures counts the failed logins with the email since its last successful one, within the lockout window.
+// Emails without an account are counted alike, so the response delay doesn't reveal which accounts exist.
+func (s *loginThrottler) emailFailures(email string) int {
+	window := s.Config.LockoutDuration
+	if window <= 0 {
+		window = loginAttemptRetention
+	}
+	since := time.Now().Add(-window)
+
+	var lastSuccess models.LoginAttempt
+	err := s.DB.Where("email = ? AND succeeded = ? AND created_at > ?", email, true, since).
+		Order("created_at DESC").
+		First(&lastSuccess).Error
+	if err == nil {
+		since = lastSuccess.CreatedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.LogErrorWithErr("Failed to find last successful login", err, "email", email)
+		return 1
+	}
+
+	var failures int64
+	if err := s.DB.Model(&models.LoginAttempt{}).
+		Where("email = ? AND succeeded = ? AND created_at > ?", email, false, since).
+		Count(&failures).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count login attempts", err, "email", email)
+		return 1
+	}
+	return int(failures)
+}
+
+// failureDelay is the response delay of a failed login with the email. It stops growing at the
+// account failure limit, where accounts are locked, so locked accounts and unknown emails look alike.
+func (s *loginThrottler) failureDelay(email string) time.Duration {
+	failures := s.emailFailures(email)
+	if limit := s.Config.MaxAccountFailures; limit > 0 && failures > limit {
+		failures = limit
+	}
+	return s.loginDelay(failures)
+}
+
+// loginDelay doubles with every consecutive failure, starting at the base delay up to the max delay
+func (s *loginThrottler) loginDelay(failures int) time.Duration {
+	throttle := s.Config
+	if failures <= 0 || throttle.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := throttle.BaseDelay
+	for i := 1; i < failures && delay < throttle.MaxDelay; i++ {
+		delay *= 2
+	}
+	if throttle.MaxDelay > 0 && delay > throttle.MaxDelay {
+		delay = throttle.MaxDelay
+	}
+	return delay
+}
+
+// sendUnlockEmail emails a link that lifts the lockout before it expires
+func (s *loginThrottler) sendUnlockEmail(user *models.User, lockedUntil time.Time) error {
+	token, err := utils.GenerateAccountUnlockToken(strconv.FormatUint(uint64(user.ID), 10), lockedUntil)
+	if err != nil {
+		return err
+	}
+
+	frontendURL := utils.GetEnv("FRONTEND_URL", "http://localhost:3000")
+	unlockURL := fmt.Sprintf("%s/unlock-account?token=%s", frontendURL, token)
+	body := fmt.Sprintf(
+		"Your account was locked until %s after too many failed login attempts. "+
+			"If this was you, click the link to unlock it: %s\n"+
+			"If it wasn't, consider resetting your password.",
+		lockedUntil.UTC().Format(time.RFC1123), unlockURL,
+	)
+
+	return utils.SendEmailWithContext(context.Background(), user.Email, "Your account has been locked", body, false)
+}
+
+// UnlockAccount lifts the lockout the unlock token was issued for
+func (s *UserService) UnlockAccount(req dto.UnlockAccountRequest) (*dto.UnlockAccountResponse, error) {
+	utils.LogInfo("Unlocking account")
+
+	claims, err := utils.ValidateAccountUnlockToken(req.Token)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to validate account unlock token", err)
+		return nil, ErrInvalidUnlockToken
+	}
+
+	// The token only unlocks the lockout it was sent for
+	result := s.DB.Model(&models.User{}).
+		Where("id = ? AND locked_until = ?", claims.UserID, time.Unix(claims.LockedUntil, 0)).
+		Updates(map[string]any{"failed_login_count": 0, "locked_until": nil})
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to unlock account", result.Error, "userID", claims.UserID)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		utils.LogInfo("Account unlock token does not match the current lockout", "userID", claims.UserID)
+		return nil, ErrInvalidUnlockToken
+	}
+
+	return &dto.UnlockAccountResponse{IsSuccess: true}, nil
+}
+
+// ListLockouts returns the accounts that are currently locked
+func (s *UserService) ListLockouts() ([]dto.LockoutResponse, error) {
+	var users []models.User
+	if err := s.DB.
+		Where("locked_until > ?", time.Now()).
+		Order("locked_until DESC").
+		Find(&users).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list lockouts", err)
+		return nil, err
+	}
+
+	lockouts := make([]dto.LockoutResponse, 0, len(users))
+	for _, user := range users {
+		lockouts = append(lockouts, dto.LockoutResponse{
+			UserID:      user.ID,
+			Username:    user.Username,
+			Email:       user.Email,
+			LockedUntil: *user.LockedUntil,
+		})
+	}
+
+	return lockouts, nil
+}
+
+// AdminUnlockAccount lifts the lockout of the user and resets the failure counter
+func (s *UserService) AdminUnlockAccount(userID uint) error {
+	utils.LogInfo("Unlocking account by admin", "userID", userID)
+
+	result := s.DB.Model(&models.User{}).
+		Where("id = ? AND locked_until > ?", userID, time.Now()).
+		Updates(map[string]any{"failed_login_count": 0, "locked_until": nil})
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to unlock account", result.Error, "userID", userID)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := s.DB.Select("id").First(&models.User{}, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		return ErrAccountNotLocked
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"testing"
+	"time"
+)
+
+func TestFailureDelayDoesNotRevealAccounts(t *testing.T) {
+	db := newTestDB(t)
+	createTestUser(t, db, "alice")
+	service := NewUserService(db, config.Auth{LoginThrottle: config.LoginThrottle{
+		MaxAccountFailures: 3,
+		LockoutDuration:    time.Minute,
+		BaseDelay:          time.Millisecond,
+		MaxDelay:           time.Second,
+	}}, nil)
+
+	for attempt := 1; attempt <= 5; attempt++ {
+		for _, email := range []string{"alice@example.com", "nobody@example.com"} {
+			_, err := service.Login(dto.LoginRequest{Email: email, Password: "wrong-password"}, dto.SessionInfo{})
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("Login(%s) error = %v, want %v", email, err, ErrInvalidCredentials)
+			}
+		}
+
+		known := service.failureDelay("alice@example.com")
+		unknown := service.failureDelay("nobody@example.com")
+		if known != unknown {
+			t.Fatalf("after %d failures: delay of the account = %v, of the unknown email = %v", attempt, known, unknown)
+		}
+	}
+
+	if got, want := service.failureDelay("nobody@example.com"), service.loginDelay(3); got != want {
+		t.Errorf("delay past the account failure limit = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMagicLinkDisabled        = errors.New("magic link login is disabled")
+	ErrInvalidMagicLink         = errors.New("invalid magic link")
+	ErrMagicLinkExpired         = errors.New("magic link expired")
+	ErrMagicLinkBrowserMismatch = errors.New("magic link opened in another browser")
+)
+
+// RequestMagicLink emails a single-use login link to the account of the email.
+// binding is the browser binding value of the requesting client, empty when links aren't bound.
+func (s *UserService) RequestMagicLink(req dto.RequestMagicLinkRequest, client dto.SessionInfo, binding string) (*dto.RequestMagicLinkResponse, error) {
+	if !s.AuthConfig.MagicLink.Enabled {
+		return nil, ErrMagicLinkDisabled
+	}
+
+	utils.LogInfo("Requesting magic link", "email", req.Email)
+
+	blocked, err := s.ipBlocked(client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		utils.LogWarn("Security event: magic link request blocked for IP address", "ip", client.IPAddress)
+		return nil, ErrTooManyLoginAttempts
+	}
+
+	var user models.User
+	if err := s.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogInfo("User not found", "email", req.Email)
+			// Don't reveal if user exists or not for security reasons
+			return &dto.RequestMagicLinkResponse{IsSuccess: true}, nil
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+
+	limited, err := s.magicLinkRequestsExceeded(user.ID, client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	if limited {
+		utils.LogWarn("Security event: magic link request limit reached", "userID", user.ID, "ip", client.IPAddress)
+		// Same response as a sent link, so the limit doesn't reveal the account
+		return &dto.RequestMagicLinkResponse{IsSuccess: true}, nil
+	}
+
+	token, err := utils.GeneratePasswordResetToken()
+	if err != nil {
+		utils.LogErrorWithErr("Failed to generate magic link token", err)
+		return nil, err
+	}
+
+	record := models.MagicLinkToken{
+		TokenHash: utils.HashToken(token),
+		Remember:  req.Remember,
+		IPAddress: client.IPAddress,
+		ExpiresAt: time.Now().Add(s.AuthConfig.MagicLink.ExpiresIn),
+		UserID:    user.ID,
+	}
+	if binding != "" {
+		record.BindingHash = utils.HashToken(binding)
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Only the most recent link can be used
+		if err := tx.Model(&models.MagicLinkToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", time.Now()).Error; err != nil {
+			utils.LogErrorWithErr("Failed to revoke existing magic links", err)
+			return err
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			utils.LogErrorWithErr("Failed to create magic link token", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	frontendURL := utils.GetEnv("FRONTEND_URL", "http://localhost:3000")
+	loginURL := fmt.Sprintf("%s/magic-login?token=%s", frontendURL, token)
+	body := fmt.Sprintf(
+		"Click the link to log in, it expires in %d minutes and can only be used once: %s",
+		int(s.AuthConfig.MagicLink.ExpiresIn.Minutes()), loginURL,
+	)
+	if err := utils.SendEmailWithContext(context.Background(), user.Email, "Your login link", body, false); err != nil {
+		utils.LogErrorWithErr("Failed to send magic link email", err)
+	}
+
+	return &dto.RequestMagicLinkResponse{IsSuccess: true}, nil
+}
+
+// magicLinkRequestsExceeded reports whether the account or the IP address was sent the maximum number of links within the window
+func (s *UserService) magicLinkRequestsExceeded(userID uint, ip string) (bool, error) {
+	magicLink := s.AuthConfig.MagicLink
+	if magicLink.MaxRequests <= 0 {
+		return false, nil
+	}
+
+	query := s.DB.Model(&models.MagicLinkToken{}).Where("created_at > ?", time.Now().Add(-magicLink.RequestWindow))
+	if ip != "" {
+		query = query.Where("user_id = ? OR ip_address = ?", userID, ip)
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var requests int64
+	if err := query.Count(&requests).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count magic link requests", err, "userID", userID)
+		return false, err
+	}
+
+	return requests >= int64(magicLink.MaxRequests), nil
+}
+
+// VerifyMagicLink redeems a magic link and logs the user in like Login does.
+// Opening the link proves ownership of the email, so it also verifies the email.
+func (s *UserService) VerifyMagicLink(req dto.VerifyMagicLinkRequest, client dto.SessionInfo, binding string) (*dto.LoginResponse, error) {
+	if !s.AuthConfig.MagicLink.Enabled {
+		return nil, ErrMagicLinkDisabled
+	}
+
+	utils.LogInfo("Verifying magic link")
+
+	blocked, err := s.ipBlocked(client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		utils.LogWarn("Security event: magic link login blocked for IP address", "ip", client.IPAddress)
+		return nil, ErrTooManyLoginAttempts
+	}
+
+	var record models.MagicLinkToken
+	if err := s.DB.
+		Preload("User.Role.Claims").
+		Preload("User.Claims").
+		Where("token_hash = ?", utils.HashToken(req.Token)).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogInfo("Magic link not found")
+			// Guessed tokens count towards the limit of the IP address
+			s.recordLoginAttempt("", nil, client.IPAddress, false)
+			return nil, ErrInvalidMagicLink
+		}
+		utils.LogErrorWithErr("Failed to find magic link token", err)
+		return nil, err
+	}
+
+	if record.UsedAt != nil {
+		utils.LogInfo("Magic link already used", "userID", record.UserID)
+		s.recordLoginAttempt(record.User.Email, &record.UserID, client.IPAddress, false)
+		return nil, ErrInvalidMagicLink
+	}
+	if record.ExpiresAt.Before(time.Now()) {
+		utils.LogInfo("Magic link expired", "userID", record.UserID)
+		return nil, ErrMagicLinkExpired
+	}
+
+	// A bound link isn't consumed when opened in another browser, so the user can still use it
+	if record.BindingHash != "" &&
+		subtle.ConstantTimeCompare([]byte(record.BindingHash), []byte(utils.HashToken(binding))) != 1 {
+		utils.LogWarn("Magic link opened in another browser", "userID", record.UserID, "ip", client.IPAddress)
+		return nil, ErrMagicLinkBrowserMismatch
+	}
+
+	// The link stays usable, so it can still be redeemed once the lockout is over
+	if record.User.LockedUntil != nil && record.User.LockedUntil.After(time.Now()) {
+		utils.LogInfo("Magic link login on locked account", "userID", record.UserID)
+		s.recordLoginAttempt(record.User.Email, &record.UserID, client.IPAddress, false)
+		return nil, ErrTooManyLoginAttempts
+	}
+
+	// Only the first redemption may use the link
+	result := s.DB.Model(&models.MagicLinkToken{}).
+		Where("id = ? AND used_at IS NULL", record.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to redeem magic link", result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidMagicLink
+	}
+
+	user := &record.User
+	if !user.EmailVerified {
+		now := time.Now()
+		if err := s.DB.Model(user).Updates(map[string]any{
+			"email_verified":    true,
+			"email_verified_at": now,
+		}).Error; err != nil {
+			utils.LogErrorWithErr("Failed to verify email", err, "userID", user.ID)
+		}
+	}
+
+	s.recordLoginAttempt(user.Email, &user.ID, client.IPAddress, true)
+
+	if req.DeviceLabel != "" {
+		client.DeviceLabel = req.DeviceLabel
+	}
+
+	return s.completeLogin(user, client, record.Remember)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"testing"
+	"time"
+)
+
+func newTestMagicLinkService(t *testing.T) (*UserService, *models.User) {
+	t.Helper()
+
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	service := NewUserService(db, config.Auth{
+		MagicLink: config.MagicLink{
+			Enabled:       true,
+			ExpiresIn:     15 * time.Minute,
+			MaxRequests:   2,
+			RequestWindow: 15 * time.Minute,
+		},
+		LoginThrottle: config.LoginThrottle{MaxIPFailures: 20, IPWindow: 15 * time.Minute},
+	}, NewMemoryTokenDenylist())
+	return service, user
+}
+
+func TestRequestMagicLinkLimit(t *testing.T) {
+	service, user := newTestMagicLinkService(t)
+	createTestUser(t, service.DB, "bob")
+
+	requests := []struct {
+		email string
+		ip    string
+	}{
+		{"alice@example.com", "10.0.0.1"},
+		{"alice@example.com", "10.0.0.2"},
+		// The account reached the limit, from any address
+		{"alice@example.com", "10.0.0.3"},
+		// The first address reached the limit, for any account
+		{"bob@example.com", "10.0.0.1"},
+		{"bob@example.com", "10.0.0.1"},
+	}
+	for _, r := range requests {
+		res, err := service.RequestMagicLink(dto.RequestMagicLinkRequest{Email: r.email}, dto.SessionInfo{IPAddress: r.ip}, "")
+		if err != nil || !res.IsSuccess {
+			t.Fatalf("RequestMagicLink(%s, %s) = %+v, %v, want success", r.email, r.ip, res, err)
+		}
+	}
+
+	var links int64
+	service.DB.Model(&models.MagicLinkToken{}).Where("user_id = ?", user.ID).Count(&links)
+	if links != 2 {
+		t.Errorf("links of the account = %d, want 2", links)
+	}
+	service.DB.Model(&models.MagicLinkToken{}).Count(&links)
+	if links != 3 {
+		t.Errorf("links = %d, want 3", links)
+	}
+}
+
+func TestVerifyMagicLinkOnLockedAccount(t *testing.T) {
+	service, user := newTestMagicLinkService(t)
+
+	token := "magic-link-token"
+	if err := service.DB.Create(&models.MagicLinkToken{
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Minute),
+		UserID:    user.ID,
+	}).Error; err != nil {
+		t.Fatalf("create magic link: %v", err)
+	}
+	lockedUntil := time.Now().Add(time.Hour)
+	service.DB.Model(user).Update("locked_until", lockedUntil)
+
+	if _, err := service.VerifyMagicLink(dto.VerifyMagicLinkRequest{Token: token}, dto.SessionInfo{}, ""); !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Fatalf("VerifyMagicLink() error = %v, want %v", err, ErrTooManyLoginAttempts)
+	}
+
+	// The link can be used once the lockout is over
+	service.DB.Model(user).Update("locked_until", nil)
+	res, err := service.VerifyMagicLink(dto.VerifyMagicLinkRequest{Token: token}, dto.SessionInfo{}, "")
+	if err != nil {
+		t.Fatalf("VerifyMagicLink() after the lockout error = %v", err)
+	}
+	if res.AccessToken == "" {
+		t.Errorf("VerifyMagicLink() = %+v, want tokens", res)
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	defaultGoogleUserInfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
+	defaultGitHubAPIURL      = "https://api.github.com"
+)
+
+// oauthHTTPClient is used for every request to the identity providers
+var oauthHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+/*
+OAuthProvider is an external identity provider used for login.
+AuthCodeURL builds the URL the user is redirected to and
+UserInfo exchanges the callback code for the profile of the user.
+The nonce is only checked by providers that issue an ID token.
+*/
+type OAuthProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce string) (string, error)
+	UserInfo(ctx context.Context, code, nonce string) (*dto.OAuthUserInfo, error)
+}
+
+// newOAuthProvider creates the provider implementation for the configured type
+func newOAuthProvider(cfg config.OAuthProvider) (OAuthProvider, error) {
+	switch cfg.Type {
+	case config.OAuthProviderGoogle:
+		return newGoogleProvider(cfg), nil
+	case config.OAuthProviderGitHub:
+		return newGitHubProvider(cfg), nil
+	case config.OAuthProviderOIDC:
+		if cfg.IssuerURL == "" {
+			return nil, fmt.Errorf("oauth provider %s: issuer url is required", cfg.Name)
+		}
+		return newOIDCProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("oauth provider %s: unknown type %q", cfg.Name, cfg.Type)
+	}
+}
+
+// oauth2Config builds the oauth2 config with the endpoint overrides of the provider
+func oauth2Config(cfg config.OAuthProvider, endpoint oauth2.Endpoint) *oauth2.Config {
+	if cfg.AuthURL != "" {
+		endpoint.AuthURL = cfg.AuthURL
+	}
+	if cfg.TokenURL != "" {
+		endpoint.TokenURL = cfg.TokenURL
+	}
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+		Endpoint:     endpoint,
+	}
+}
+
+// oauthContext makes the oauth2 package use the shared HTTP client
+func oauthContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, oauthHTTPClient)
+}
+
+// getProviderJSON sends an authorized GET request to the provider and decodes the JSON response
+func getProviderJSON(ctx context.Context, url, accessToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to get user info", err)
+		return ErrGetUserInfo
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		utils.LogError("Failed to get user info", "url", url, "status", resp.StatusCode)
+		return ErrGetUserInfo
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to read response body", err)
+		return ErrReadResponse
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		utils.LogErrorWithErr("Failed to unmarshal user info", err)
+		return ErrParseUserInfo
+	}
+	return nil
+}
+
+type googleProvider struct {
+	name        string
+	config      *oauth2.Config
+	userInfoURL string
+}
+
+func newGoogleProvider(cfg config.OAuthProvider) *googleProvider {
+	userInfoURL := cfg.UserInfoURL
+	if userInfoURL == "" {
+		userInfoURL = defaultGoogleUserInfoURL
+	}
+	return &googleProvider{
+		name:        cfg.Name,
+		config:      oauth2Config(cfg, google.Endpoint),
+		userInfoURL: userInfoURL,
+	}
+}
+
+func (p *googleProvider) Name() string {
+	return p.name
+}
+
+func (p *googleProvider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	return p.config.AuthCodeURL(state), nil
+}
+
+func (p *googleProvider) UserInfo(ctx context.Context, code, nonce string) (*dto.OAuthUserInfo, error) {
+	token, err := p.config.Exchange(oauthContext(ctx), code)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to exchange code", err)
+		return nil, ErrExchangeCode
+	}
+
+	var userInfo dto.GoogleUserInfo
+	if err := getProviderJSON(ctx, p.userInfoURL, token.AccessToken, &userInfo); err != nil {
+		return nil, err
+	}
+	if userInfo.ID == "" {
+		return nil, ErrParseUserInfo
+	}
+
+	return &dto.OAuthUserInfo{
+		Provider:      p.name,
+		Subject:       userInfo.ID,
+		Email:         userInfo.Email,
+		EmailVerified: userInfo.VerifiedEmail,
+		Name:          userInfo.Name,
+		Picture:       userInfo.Picture,
+	}, nil
+}
+
+type githubProvider struct {
+	name   string
+	config *oauth2.Config
+	apiURL string
+}
+
+type githubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func newGitHubProvider(cfg config.OAuthProvider) *githubProvider {
+	apiURL := cfg.UserInfoURL
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+	return &githubProvider{
+		name:   cfg.Name,
+		config: oauth2Config(cfg, github.Endpoint),
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+	}
+}
+
+func (p *githubProvider) Name() string {
+	return p.name
+}
+
+func (p *githubProvider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	return p.config.AuthCodeURL(state), nil
+}
+
+func (p *githubProvider) UserInfo(ctx context.Context, code, nonce string) (*dto.OAuthUserInfo, error) {
+	token, err := p.config.Exchange(oauthContext(ctx), code)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to exchange code", err)
+		return nil, ErrExchangeCode
+	}
+
+	var user githubUser
+	if err := getProviderJSON(ctx, p.apiURL+"/user", token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, ErrParseUserInfo
+	}
+
+	// The public email of the profile says nothing about verification, so the primary email is used
+	var emails []githubEmail
+	if err := getProviderJSON(ctx, p.apiURL+"/user/emails", token.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	info := &dto.OAuthUserInfo{
+		Provider: p.name,
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     user.Name,
+		Picture:  user.AvatarURL,
+	}
+	if info.Name == "" {
+		info.Name = user.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			info.Email = email.Email
+			info.EmailVerified = email.Verified
+			break
+		}
+	}
+	if info.Email == "" {
+		return nil, errors.Join(ErrParseUserInfo, errors.New("github account has no primary email"))
+	}
+
+	return info, nil
+}
@@ -2,48 +2,82 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"io"
 	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
 	"knowstack/internal/data/models"
 	"knowstack/internal/utils"
-	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
-	"golang.org/x/oauth2"
 	"gorm.io/gorm"
 )
 
 var (
-	ErrExchangeCode  = errors.New("failed to exchange code")
-	ErrGetUserInfo   = errors.New("failed to get user info")
-	ErrReadResponse  = errors.New("failed to read response")
-	ErrParseUserInfo = errors.New("failed to parse user info")
+	ErrExchangeCode          = errors.New("failed to exchange code")
+	ErrGetUserInfo           = errors.New("failed to get user info")
+	ErrReadResponse          = errors.New("failed to read response")
+	ErrParseUserInfo         = errors.New("failed to parse user info")
+	ErrOAuthProviderNotFound = errors.New("oauth provider not found")
+	ErrOAuthEmailNotVerified = errors.New("oauth email not verified")
+	ErrOAuthAccountExists    = errors.New("an account with the oauth email already exists")
+	ErrInvalidOAuthLink      = errors.New("invalid oauth link request")
 )
 
 type OAuthService struct {
-	DB     *gorm.DB
-	config *oauth2.Config
+	DB         *gorm.DB
+	AuthConfig config.Auth
+	providers  map[string]OAuthProvider
 }
 
-func NewOAuthService(db *gorm.DB, config *oauth2.Config) *OAuthService {
-	return &OAuthService{DB: db, config: config}
+/*
+Create the OAuth service with the configured providers.
+Misconfigured providers are logged and left out.
+*/
+func NewOAuthService(db *gorm.DB, providerConfigs []config.OAuthProvider, authConfig config.Auth) *OAuthService {
+	providers := make(map[string]OAuthProvider, len(providerConfigs))
+	for _, cfg := range providerConfigs {
+		provider, err := newOAuthProvider(cfg)
+		if err != nil {
+			utils.LogErrorWithErr("OAuth provider disabled", err, "provider", cfg.Name)
+			continue
+		}
+		providers[cfg.Name] = provider
+	}
+	return &OAuthService{DB: db, AuthConfig: authConfig, providers: providers}
 }
 
-func (s *OAuthService) GetGoogleLoginURL(state string) string {
-	return s.config.AuthCodeURL(state)
+func (s *OAuthService) provider(name string) (OAuthProvider, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, ErrOAuthProviderNotFound
+	}
+	return provider, nil
 }
 
-func (s *OAuthService) HandleGoogleCallback(code string) (*dto.GoogleAuthResponse, error) {
-	token, err := s.config.Exchange(context.Background(), code)
+// GetLoginURL returns the authorization URL of the provider
+func (s *OAuthService) GetLoginURL(ctx context.Context, providerName, state, nonce string) (string, error) {
+	provider, err := s.provider(providerName)
 	if err != nil {
-		utils.LogErrorWithErr("Failed to exchange code", err)
-		return nil, ErrExchangeCode
+		return "", err
 	}
+	return provider.AuthCodeURL(ctx, state, nonce)
+}
 
-	userInfo, err := s.getGoogleUserInfo(token.AccessToken)
+/*
+HandleCallback exchanges the code of the provider and logs the user of the identity in,
+users with two-factor authentication get an MFA challenge like on the password login.
+Without a linked identity an existing user with the same email is only linked when
+auto linking is enabled and the provider verified the email; otherwise a new user is created.
+*/
+func (s *OAuthService) HandleCallback(ctx context.Context, providerName, code, nonce string, client dto.SessionInfo) (*dto.OAuthAuthResponse, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo, err := provider.UserInfo(ctx, code, nonce)
 	if err != nil {
 		return nil, err
 	}
@@ -51,113 +85,216 @@ func (s *OAuthService) HandleGoogleCallback(code string) (*dto.GoogleAuthRespons
 	var user *models.User
 	isNewUser := false
 
-	err = s.DB.
-		Preload("Role").
-		Preload("Role.Claims").
-		Preload("Claims").
-		Where("google_id = ?", userInfo.ID).
-		Or("email = ?", userInfo.Email).
-		First(&user).Error
+	identity, err := s.findIdentity(userInfo)
+	if err != nil {
+		return nil, err
+	}
 
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		user, err = s.createGoogleUser(userInfo)
+	if identity != nil {
+		user, err = s.loadUser(identity.UserID)
 		if err != nil {
-			utils.LogErrorWithErr("Failed to creating user from google", err)
 			return nil, err
 		}
-		isNewUser = true
-	} else if err != nil {
-		utils.LogErrorWithErr("Failed to find user", err)
-		return nil, err
+		s.touchIdentity(identity, userInfo)
 	} else {
-		if user.GoogleID == "" {
-			user.GoogleID = userInfo.ID
-			user.ProfileImage = userInfo.Picture
-			user.Provider = "google"
-			if err := s.DB.Save(&user).Error; err != nil {
-				utils.LogErrorWithErr("Failed to update user", err)
-			}
+		user, isNewUser, err = s.userForNewIdentity(userInfo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The provider only stands in for the password, the second factor is still asked for
+	if user.TOTPEnabled {
+		mfaToken, err := createMFAChallenge(s.DB, user, true, client.DeviceLabel)
+		if err != nil {
+			return nil, err
 		}
+		return &dto.OAuthAuthResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
 	}
 
-	userID := strconv.FormatUint(uint64(user.ID), 10)
+	tokens, err := issueTokens(s.DB, user, newTokenSession(client, true))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.OAuthAuthResponse{
+		AccessToken:            tokens.AccessToken,
+		RefreshToken:           tokens.RefreshToken,
+		IsNewUser:              isNewUser,
+		TwoFactorSetupRequired: tokens.TwoFactorSetupRequired,
+	}, nil
+}
+
+/*
+StartLink returns the authorization URL for linking the provider to the user
+and the link token that marks the callback as a link request.
+*/
+func (s *OAuthService) StartLink(ctx context.Context, userID uint, providerName, state, nonce string) (string, string, error) {
+	utils.LogInfo("Starting identity link", "userID", userID, "provider", providerName)
 
-	claimNameSet := make(map[string]struct{})
-	for _, c := range user.Role.Claims {
-		claimNameSet[c.Name] = struct{}{}
+	authURL, err := s.GetLoginURL(ctx, providerName, state, nonce)
+	if err != nil {
+		return "", "", err
 	}
-	for _, c := range user.Claims {
-		claimNameSet[c.Name] = struct{}{}
+
+	linkToken, err := utils.GenerateOAuthLinkToken(strconv.FormatUint(uint64(userID), 10), providerName, state)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to generate oauth link token", err)
+		return "", "", err
 	}
-	mergedClaimNames := make([]string, 0, len(claimNameSet))
-	for name := range claimNameSet {
-		mergedClaimNames = append(mergedClaimNames, name)
+
+	return authURL, linkToken, nil
+}
+
+// LinkIdentity exchanges the code of the provider and links the account to the user of the link token
+func (s *OAuthService) LinkIdentity(ctx context.Context, linkToken, providerName, state, code, nonce string) error {
+	claims, err := utils.ValidateOAuthLinkToken(linkToken)
+	if err != nil || claims.Provider != providerName || claims.State != state {
+		utils.LogInfo("Invalid oauth link token", "provider", providerName)
+		return ErrInvalidOAuthLink
 	}
 
-	accessToken, err := utils.GenerateAccessToken(userID, user.Email, user.Username, user.RoleID, mergedClaimNames)
+	userID, err := strconv.ParseUint(claims.UserID, 10, 32)
 	if err != nil {
-		utils.LogErrorWithErr("Failed to generate access token", err)
-		return nil, err
+		return ErrInvalidOAuthLink
 	}
 
-	refreshTokenRecord := models.RefreshToken{
-		UserID:    user.ID,
-		IsRevoked: false,
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return err
 	}
-	if err := s.DB.Create(&refreshTokenRecord).Error; err != nil {
-		utils.LogErrorWithErr("Failed to create token record", err)
-		return nil, err
+
+	userInfo, err := provider.UserInfo(ctx, code, nonce)
+	if err != nil {
+		return err
 	}
 
-	tokenID := strconv.FormatUint(uint64(refreshTokenRecord.ID), 10)
-	refreshToken, err := utils.GenerateRefreshToken(userID, tokenID, true)
+	identity, err := s.findIdentity(userInfo)
 	if err != nil {
-		utils.LogErrorWithErr("Failed to generate refresh token", err)
-		return nil, err
+		return err
+	}
+	if identity != nil {
+		if identity.UserID != uint(userID) {
+			utils.LogWarn("Identity already linked to another user", "provider", providerName, "userID", userID)
+			return ErrIdentityAlreadyLinked
+		}
+		s.touchIdentity(identity, userInfo)
+		return nil
 	}
 
-	refreshTokenRecord.Token = refreshToken
-	if err := s.DB.Save(&refreshTokenRecord).Error; err != nil {
-		utils.LogErrorWithErr("Failed to save token record", err)
+	utils.LogInfo("Linking identity", "userID", userID, "provider", providerName)
+	if err := s.DB.Create(newUserIdentity(uint(userID), userInfo)).Error; err != nil {
+		utils.LogErrorWithErr("Failed to create identity", err)
+		return err
+	}
+	return nil
+}
+
+// findIdentity returns the linked identity of the provider account or nil
+func (s *OAuthService) findIdentity(userInfo *dto.OAuthUserInfo) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := s.DB.Where("provider = ? AND subject = ?", userInfo.Provider, userInfo.Subject).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		utils.LogErrorWithErr("Failed to find identity", err)
 		return nil, err
 	}
+	return &identity, nil
+}
 
-	return &dto.GoogleAuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		IsNewUser:    isNewUser,
-	}, nil
+// touchIdentity records the login and keeps the email of the identity current
+func (s *OAuthService) touchIdentity(identity *models.UserIdentity, userInfo *dto.OAuthUserInfo) {
+	if err := s.DB.Model(identity).Updates(map[string]any{
+		"email":        userInfo.Email,
+		"last_used_at": time.Now(),
+	}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to update identity", err)
+	}
 }
 
-func (s *OAuthService) getGoogleUserInfo(accessToken string) (*dto.GoogleUserInfo, error) {
-	resp, err := http.Get("https://www.googleapis.com/oauth2/v2/userinfo?access_token=" + accessToken)
+// userForNewIdentity links the identity to the user with the same email or creates a new user
+func (s *OAuthService) userForNewIdentity(userInfo *dto.OAuthUserInfo) (*models.User, bool, error) {
+	// Only a verified email proves the ownership of an account
+	if !userInfo.VerifiedEmail() {
+		utils.LogInfo("OAuth email not verified", "provider", userInfo.Provider, "email", userInfo.Email)
+		return nil, false, ErrOAuthEmailNotVerified
+	}
+
+	var existingUser models.User
+	err := s.DB.Where("email = ?", userInfo.Email).First(&existingUser).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err := s.createOAuthUser(userInfo)
+		if err != nil {
+			utils.LogErrorWithErr("Failed to create user from oauth provider", err, "provider", userInfo.Provider)
+			return nil, false, err
+		}
+		return user, true, nil
+	}
 	if err != nil {
-		utils.LogErrorWithErr("Failed to get user info", err)
-		return nil, ErrGetUserInfo
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, false, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		utils.LogError("Failed to get user info: status code %d", resp.StatusCode)
-		return nil, ErrGetUserInfo
+	if !s.AuthConfig.OAuthAutoLinkVerifiedEmail {
+		utils.LogInfo("OAuth email belongs to an existing account", "provider", userInfo.Provider, "userID", existingUser.ID)
+		return nil, false, ErrOAuthAccountExists
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	utils.LogInfo("Auto linking identity by verified email", "provider", userInfo.Provider, "userID", existingUser.ID)
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(newUserIdentity(existingUser.ID, userInfo)).Error; err != nil {
+			utils.LogErrorWithErr("Failed to create identity", err)
+			return err
+		}
+		if !existingUser.EmailVerified {
+			if err := tx.Model(&existingUser).Updates(map[string]any{
+				"email_verified":    true,
+				"email_verified_at": time.Now(),
+			}).Error; err != nil {
+				utils.LogErrorWithErr("Failed to verify email", err)
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		utils.LogErrorWithErr("Failed to read response body", err)
-		return nil, ErrReadResponse
+		return nil, false, err
 	}
 
-	var userInfo dto.GoogleUserInfo
-	if err := json.Unmarshal(body, &userInfo); err != nil {
-		utils.LogErrorWithErr("Failed to unmarshal user info", err)
-		return nil, ErrParseUserInfo
+	user, err := s.loadUser(existingUser.ID)
+	return user, false, err
+}
+
+func (s *OAuthService) loadUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.DB.
+		Preload("Role").
+		Preload("Role.Claims").
+		Preload("Claims").
+		First(&user, userID).Error; err != nil {
+		utils.LogErrorWithErr("Failed to get user", err)
+		return nil, err
 	}
+	return &user, nil
+}
 
-	return &userInfo, nil
+func newUserIdentity(userID uint, userInfo *dto.OAuthUserInfo) *models.UserIdentity {
+	now := time.Now()
+	return &models.UserIdentity{
+		Provider:   userInfo.Provider,
+		Subject:    userInfo.Subject,
+		Email:      userInfo.Email,
+		LastUsedAt: &now,
+		UserID:     userID,
+	}
 }
 
-func (s *OAuthService) createGoogleUser(userInfo *dto.GoogleUserInfo) (*models.User, error) {
+func (s *OAuthService) createOAuthUser(userInfo *dto.OAuthUserInfo) (*models.User, error) {
 	var defaultRole models.Role
 	if err := s.DB.Where("is_default = ?", true).First(&defaultRole).Error; err != nil {
 		utils.LogErrorWithErr("Failed to find default role", err)
@@ -179,28 +316,34 @@ func (s *OAuthService) createGoogleUser(userInfo *dto.GoogleUserInfo) (*models.U
 	}
 
 	user := models.User{
-		Username:     username,
-		Email:        userInfo.Email,
-		GoogleID:     userInfo.ID,
-		Provider:     "google",
-		ProfileImage: userInfo.Picture,
-		RoleID:       defaultRole.ID,
-		Password:     "",
+		Username:      username,
+		Email:         userInfo.Email,
+		EmailVerified: userInfo.EmailVerified,
+		Provider:      userInfo.Provider,
+		ProfileImage:  userInfo.Picture,
+		RoleID:        defaultRole.ID,
+		Password:      "",
 	}
-
-	if err := s.DB.Create(&user).Error; err != nil {
-		utils.LogErrorWithErr("Failed to create user", err)
-		return nil, err
+	// The provider vouches for the email only when it reports it as verified
+	if userInfo.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
 	}
 
-	if err := s.DB.
-		Preload("Role").
-		Preload("Role.Claims").
-		Preload("Claims").
-		First(&user, user.ID).Error; err != nil {
-		utils.LogErrorWithErr("Failed to get user", err)
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			utils.LogErrorWithErr("Failed to create user", err)
+			return err
+		}
+		if err := tx.Create(newUserIdentity(user.ID, userInfo)).Error; err != nil {
+			utils.LogErrorWithErr("Failed to create identity", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return &user, nil
+	return s.loadUser(user.ID)
 }
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrOIDCDiscovery  = errors.New("failed to load oidc discovery document")
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
+
+const (
+	oidcDiscoveryTTL = 24 * time.Hour
+	// A kid missing from the cached keyset triggers a refetch at most this often
+	oidcJWKSRefreshInterval = time.Minute
+)
+
+// Microsoft multi-tenant discovery documents use this placeholder in the issuer
+const oidcTenantPlaceholder = "{tenantid}"
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcBool accepts both JSON booleans and "true"/"false" strings, which some providers send
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	*b = oidcBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+type oidcClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     oidcBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Picture           string   `json:"picture"`
+	// TenantID and EmailDomainOwnerVerified are Microsoft specific
+	TenantID                 string    `json:"tid"`
+	EmailDomainOwnerVerified *oidcBool `json:"xms_edov"`
+}
+
+/*
+oidcProvider implements OpenID Connect login with the discovery document of the issuer.
+The discovery document and the keyset are fetched lazily and cached.
+*/
+type oidcProvider struct {
+	cfg config.OAuthProvider
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	discoveredAt  time.Time
+	jwks          utils.JWKSet
+	jwksFetchedAt time.Time
+}
+
+func newOIDCProvider(cfg config.OAuthProvider) *oidcProvider {
+	return &oidcProvider{cfg: cfg}
+}
+
+func (p *oidcProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	oauthConfig, _, err := p.oauth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauthConfig.AuthCodeURL(state, oauth2.SetAuthURLParam("nonce", nonce)), nil
+}
+
+func (p *oidcProvider) UserInfo(ctx context.Context, code, nonce string) (*dto.OAuthUserInfo, error) {
+	oauthConfig, discovery, err := p.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthConfig.Exchange(oauthContext(ctx), code)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to exchange code", err, "provider", p.cfg.Name)
+		return nil, ErrExchangeCode
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		utils.LogError("Token response has no id token", "provider", p.cfg.Name)
+		return nil, ErrInvalidIDToken
+	}
+
+	claims, err := p.verifyIDToken(ctx, discovery, rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// Some providers only put the email into the userinfo response
+	if claims.Email == "" && discovery.UserInfoEndpoint != "" {
+		var userInfo oidcClaims
+		if err := getProviderJSON(ctx, discovery.UserInfoEndpoint, token.AccessToken, &userInfo); err != nil {
+			return nil, err
+		}
+		if userInfo.Subject != claims.Subject {
+			utils.LogError("Userinfo subject does not match the id token", "provider", p.cfg.Name)
+			return nil, ErrInvalidIDToken
+		}
+		claims.Email = userInfo.Email
+		claims.EmailVerified = userInfo.EmailVerified
+		if claims.Name == "" {
+			claims.Name = userInfo.Name
+		}
+		if claims.Picture == "" {
+			claims.Picture = userInfo.Picture
+		}
+	}
+
+	info := &dto.OAuthUserInfo{
+		Provider:      p.cfg.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Picture:       claims.Picture,
+	}
+	if !info.EmailVerified && claims.EmailDomainOwnerVerified != nil {
+		info.EmailVerified = bool(*claims.EmailDomainOwnerVerified)
+	}
+	if info.Name == "" {
+		info.Name = claims.PreferredUsername
+	}
+	return info, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of the ID token
+func (p *oidcProvider) verifyIDToken(ctx context.Context, discovery *oidcDiscovery, rawIDToken, nonce string) (*oidcClaims, error) {
+	claims := &oidcClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, discovery.JWKSURI, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to verify id token", err, "provider", p.cfg.Name)
+		return nil, ErrInvalidIDToken
+	}
+
+	expectedIssuer := discovery.Issuer
+	if strings.Contains(expectedIssuer, oidcTenantPlaceholder) {
+		if claims.TenantID == "" {
+			return nil, ErrInvalidIDToken
+		}
+		expectedIssuer = strings.ReplaceAll(expectedIssuer, oidcTenantPlaceholder, claims.TenantID)
+	}
+	if claims.Issuer != expectedIssuer {
+		utils.LogError("Id token issuer mismatch", "provider", p.cfg.Name, "issuer", claims.Issuer)
+		return nil, ErrInvalidIDToken
+	}
+
+	if claims.Nonce != nonce {
+		utils.LogError("Id token nonce mismatch", "provider", p.cfg.Name)
+		return nil, ErrInvalidIDToken
+	}
+
+	if claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+	return claims, nil
+}
+
+// oauth2Config builds the oauth2 config from the endpoints of the discovery document
+func (p *oidcProvider) oauth2Config(ctx context.Context) (*oauth2.Config, *oidcDiscovery, error) {
+	discovery, err := p.loadDiscovery(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return oauth2Config(p.cfg, oauth2.Endpoint{
+		AuthURL:  discovery.AuthorizationEndpoint,
+		TokenURL: discovery.TokenEndpoint,
+	}), discovery, nil
+}
+
+func (p *oidcProvider) loadDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil && time.Since(p.discoveredAt) < oidcDiscoveryTTL {
+		return p.discovery, nil
+	}
+
+	url := strings.TrimSuffix(p.cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	var discovery oidcDiscovery
+	if err := getProviderJSON(ctx, url, "", &discovery); err != nil {
+		utils.LogErrorWithErr("Failed to load discovery document", err, "provider", p.cfg.Name)
+		// A stale document is better than failing every login while the issuer is unreachable
+		if p.discovery != nil {
+			return p.discovery, nil
+		}
+		return nil, ErrOIDCDiscovery
+	}
+
+	if discovery.Issuer == "" || discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		utils.LogError("Incomplete discovery document", "provider", p.cfg.Name)
+		return nil, ErrOIDCDiscovery
+	}
+
+	p.discovery = &discovery
+	p.discoveredAt = time.Now()
+	return p.discovery, nil
+}
+
+// publicKey returns the key of the keyset with the kid, refetching the keyset when the kid is unknown
+func (p *oidcProvider) publicKey(ctx context.Context, jwksURI, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.findKey(kid)
+	if !ok && time.Since(p.jwksFetchedAt) > oidcJWKSRefreshInterval {
+		var jwks utils.JWKSet
+		if err := getProviderJSON(ctx, jwksURI, "", &jwks); err != nil {
+			return nil, err
+		}
+		p.jwks = jwks
+		p.jwksFetchedAt = time.Now()
+		key, ok = p.findKey(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("no key found for kid %q", kid)
+	}
+	return key.PublicKey()
+}
+
+// findKey looks the kid up in the cached keyset; a token without kid is accepted only with a single key
+func (p *oidcProvider) findKey(kid string) (utils.JWK, bool) {
+	if kid == "" {
+		if len(p.jwks.Keys) == 1 {
+			return p.jwks.Keys[0], true
+		}
+		return utils.JWK{}, false
+	}
+	return p.jwks.Find(kid)
+}
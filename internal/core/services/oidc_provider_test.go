@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	testOIDCClientID = "knowstack-client"
+	testOIDCCode     = "authorization-code"
+	testOIDCNonce    = "login-nonce"
+)
+
+// mockIdP is an OpenID provider serving discovery, token, keyset and userinfo endpoints
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// signingKey signs the ID tokens, a key missing from the keyset when changed
+	signingKey *rsa.PrivateKey
+	// claims of the next ID token, iss, aud, iat and exp default to valid values
+	claims jwt.MapClaims
+	// userInfo is served on the userinfo endpoint
+	userInfo map[string]any
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	idp := &mockIdP{key: key, signingKey: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"userinfo_endpoint":      idp.server.URL + "/userinfo",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		jwk, err := utils.NewJWK("idp-key", "RS256", &idp.key.PublicKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, utils.JWKSet{Keys: []utils.JWK{jwk}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != testOIDCCode {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		idToken, err := idp.idToken()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{
+			"access_token": "idp-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+	mux.HandleFunc("GET /userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer idp-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, idp.userInfo)
+	})
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *mockIdP) idToken() (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": idp.server.URL,
+		"aud": testOIDCClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for k, v := range idp.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "idp-key"
+	return token.SignedString(idp.signingKey)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func newTestOAuthService(t *testing.T, idp *mockIdP, authConfig config.Auth) (*OAuthService, *gorm.DB) {
+	t.Helper()
+
+	db := newTestDB(t)
+	createTestUser(t, db, "existing")
+	service := NewOAuthService(db, []config.OAuthProvider{{
+		Name:         "corp",
+		Type:         config.OAuthProviderOIDC,
+		ClientID:     testOIDCClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/api/v1/oauth/corp/callback",
+		IssuerURL:    idp.server.URL,
+	}}, authConfig)
+	return service, db
+}
+
+func handleTestCallback(service *OAuthService) (*dto.OAuthAuthResponse, error) {
+	return service.HandleCallback(context.Background(), "corp", testOIDCCode, testOIDCNonce,
+		dto.SessionInfo{IPAddress: "127.0.0.1", UserAgent: "test"})
+}
+
+func TestOIDCCallbackCreatesUser(t *testing.T) {
+	idp := newMockIdP(t)
+	service, db := newTestOAuthService(t, idp, config.Auth{})
+	idp.claims = jwt.MapClaims{
+		"sub":            "subject-1",
+		"nonce":          testOIDCNonce,
+		"email":          "jane@corp.example.com",
+		"email_verified": true,
+		"name":           "Jane",
+	}
+
+	res, err := handleTestCallback(service)
+	if err != nil {
+		t.Fatalf("HandleCallback() error = %v", err)
+	}
+	if !res.IsNewUser || res.AccessToken == "" || res.RefreshToken == "" {
+		t.Fatalf("HandleCallback() = %+v, want tokens of a new user", res)
+	}
+
+	var user models.User
+	if err := db.Where("email = ?", "jane@corp.example.com").First(&user).Error; err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+	if user.Username != "jane" || user.Provider != "corp" || !user.EmailVerified || user.EmailVerifiedAt == nil {
+		t.Errorf("created user = %+v", user)
+	}
+
+	var identity models.UserIdentity
+	if err := db.Where("provider = ? AND subject = ?", "corp", "subject-1").First(&identity).Error; err != nil {
+		t.Fatalf("find identity: %v", err)
+	}
+	if identity.UserID != user.ID {
+		t.Errorf("identity user = %d, want %d", identity.UserID, user.ID)
+	}
+
+	// The linked identity logs the same user in, even after the email changed at the provider
+	idp.claims["email"] = "jane.doe@corp.example.com"
+	res, err = handleTestCallback(service)
+	if err != nil {
+		t.Fatalf("second HandleCallback() error = %v", err)
+	}
+	if res.IsNewUser {
+		t.Errorf("second HandleCallback() created another user")
+	}
+	var users int64
+	db.Model(&models.User{}).Count(&users)
+	if users != 2 {
+		t.Errorf("users = %d, want 2", users)
+	}
+}
+
+func TestOIDCCallbackUsesUserInfoEmail(t *testing.T) {
+	idp := newMockIdP(t)
+	service, db := newTestOAuthService(t, idp, config.Auth{})
+	idp.claims = jwt.MapClaims{"sub": "subject-1", "nonce": testOIDCNonce}
+	idp.userInfo = map[string]any{"sub": "subject-1", "email": "jane@corp.example.com", "email_verified": "true"}
+
+	if _, err := handleTestCallback(service); err != nil {
+		t.Fatalf("HandleCallback() error = %v", err)
+	}
+	var user models.User
+	if err := db.Where("email = ?", "jane@corp.example.com").First(&user).Error; err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+}
+
+func TestOIDCCallbackRejected(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":            "subject-1",
+			"nonce":          testOIDCNonce,
+			"email":          "jane@corp.example.com",
+			"email_verified": true,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		prepare    func(idp *mockIdP)
+		authConfig config.Auth
+		wantErr    error
+	}{
+		{
+			name:    "nonce of another login",
+			prepare: func(idp *mockIdP) { idp.claims["nonce"] = "other-nonce" },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "token for another client",
+			prepare: func(idp *mockIdP) { idp.claims["aud"] = "other-client" },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "foreign issuer",
+			prepare: func(idp *mockIdP) { idp.claims["iss"] = "https://evil.example.com" },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "expired token",
+			prepare: func(idp *mockIdP) { idp.claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "signed by an unknown key",
+			prepare: func(idp *mockIdP) { idp.signingKey = otherKey },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "missing subject",
+			prepare: func(idp *mockIdP) { delete(idp.claims, "sub") },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "userinfo of another subject",
+			prepare: func(idp *mockIdP) {
+				delete(idp.claims, "email")
+				idp.userInfo = map[string]any{"sub": "subject-2", "email": "jane@corp.example.com", "email_verified": true}
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "unverified email",
+			prepare: func(idp *mockIdP) { idp.claims["email_verified"] = false },
+			wantErr: ErrOAuthEmailNotVerified,
+		},
+		{
+			name:    "email of an existing account without auto linking",
+			prepare: func(idp *mockIdP) { idp.claims["email"] = "existing@example.com" },
+			wantErr: ErrOAuthAccountExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			service, db := newTestOAuthService(t, idp, tt.authConfig)
+			idp.claims = valid()
+			tt.prepare(idp)
+
+			res, err := handleTestCallback(service)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("HandleCallback() = %+v, %v, want error %v", res, err, tt.wantErr)
+			}
+
+			var identities int64
+			db.Model(&models.UserIdentity{}).Count(&identities)
+			if identities != 0 {
+				t.Errorf("identities = %d, want 0", identities)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackAutoLinksVerifiedEmail(t *testing.T) {
+	idp := newMockIdP(t)
+	service, db := newTestOAuthService(t, idp, config.Auth{OAuthAutoLinkVerifiedEmail: true})
+	idp.claims = jwt.MapClaims{
+		"sub":            "subject-1",
+		"nonce":          testOIDCNonce,
+		"email":          "existing@example.com",
+		"email_verified": true,
+	}
+
+	res, err := handleTestCallback(service)
+	if err != nil {
+		t.Fatalf("HandleCallback() error = %v", err)
+	}
+	if res.IsNewUser {
+		t.Errorf("HandleCallback() created a user instead of linking")
+	}
+
+	var existing models.User
+	if err := db.Where("email = ?", "existing@example.com").First(&existing).Error; err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+	var identity models.UserIdentity
+	if err := db.Where("provider = ? AND subject = ?", "corp", "subject-1").First(&identity).Error; err != nil {
+		t.Fatalf("find identity: %v", err)
+	}
+	if identity.UserID != existing.ID {
+		t.Errorf("identity user = %d, want %d", identity.UserID, existing.ID)
+	}
+}
+
+func TestOIDCCallbackRequiresSecondFactor(t *testing.T) {
+	idp := newMockIdP(t)
+	service, db := newTestOAuthService(t, idp, config.Auth{OAuthAutoLinkVerifiedEmail: true})
+	if err := db.Model(&models.User{}).Where("username = ?", "existing").Update("totp_enabled", true).Error; err != nil {
+		t.Fatalf("enable 2fa: %v", err)
+	}
+	idp.claims = jwt.MapClaims{
+		"sub":            "subject-1",
+		"nonce":          testOIDCNonce,
+		"email":          "existing@example.com",
+		"email_verified": true,
+	}
+
+	res, err := handleTestCallback(service)
+	if err != nil {
+		t.Fatalf("HandleCallback() error = %v", err)
+	}
+	if !res.MFARequired || res.MFAToken == "" {
+		t.Fatalf("HandleCallback() = %+v, want an MFA challenge", res)
+	}
+	if res.AccessToken != "" || res.RefreshToken != "" {
+		t.Errorf("HandleCallback() issued tokens before the second factor")
+	}
+
+	claims, err := utils.ValidateMFAChallengeToken(res.MFAToken)
+	if err != nil {
+		t.Fatalf("ValidateMFAChallengeToken() error = %v", err)
+	}
+	var challenges int64
+	db.Model(&models.MFAChallenge{}).Where("token_id = ?", claims.ID).Count(&challenges)
+	if challenges != 1 {
+		t.Errorf("challenges = %d, want 1", challenges)
+	}
+	var sessions int64
+	db.Model(&models.RefreshToken{}).Count(&sessions)
+	if sessions != 0 {
+		t.Errorf("refresh tokens = %d, want 0", sessions)
+	}
+}
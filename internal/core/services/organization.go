@@ -0,0 +1,800 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOrganizationNotFound       = errors.New("organization not found")
+	ErrOrganizationAlreadyExists  = errors.New("organization already exists")
+	ErrOrganizationMemberNotFound = errors.New("organization member not found")
+	ErrAlreadyOrganizationMember  = errors.New("user is already a member of the organization")
+	ErrLastOrganizationOwner      = errors.New("organization must keep an owner")
+	ErrInvitationNotFound         = errors.New("organization invitation not found")
+)
+
+// OrganizationOwnerRole is the role the creator of an organization gets in it.
+// An organization always keeps at least one member with this role.
+const OrganizationOwnerRole = "organization_owner"
+
+// OrganizationMemberRole is the seeded role of the members who only use the organization
+const OrganizationMemberRole = "organization_member"
+
+// organizationInvitationTTL is how long an invitation can be accepted, inviting the email again renews it
+const organizationInvitationTTL = 7 * 24 * time.Hour
+
+type OrganizationService struct {
+	DB       *gorm.DB
+	Policies *authz.Policies
+}
+
+func NewOrganizationService(db *gorm.DB, policies *authz.Policies) *OrganizationService {
+	return &OrganizationService{DB: db, Policies: policies}
+}
+
+/*
+Grant the claims of the user's role in the organization to the request, see middleware.OrganizationResolver.
+The membership is read on every request, so role changes and removals apply immediately.
+A personal access token keeps its scopes: only the claims of the organization covered by a scope are granted.
+Returns false if the user is not a member of the organization.
+*/
+func (s *OrganizationService) ResolveOrganization(claims *utils.TokenClaims, organizationID uint) (*utils.TokenClaims, bool, error) {
+	userID, err := strconv.ParseUint(claims.UserID, 10, 32)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var membership models.OrganizationMembership
+	if err := s.DB.
+		Preload("Role.Claims").
+		Preload("User").
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, nil
+		}
+		utils.LogErrorWithErr("Failed to find organization membership", err)
+		return nil, false, err
+	}
+
+	granted := claimNames(membership.Role.Claims)
+	// An organization role requiring two-factor authentication grants nothing until the user enables it,
+	// like a platform role does, see grantedClaimNames
+	if membership.Role.RequiresTwoFactor && !membership.User.TOTPEnabled {
+		utils.LogInfo("Organization role requires two-factor authentication", "userID", userID, "organizationID", organizationID)
+		granted = []string{}
+	}
+	if claims.PersonalAccessTokenID != 0 {
+		var scopes []models.Claim
+		if err := s.DB.Model(&models.PersonalAccessToken{ID: claims.PersonalAccessTokenID}).Association("Claims").Find(&scopes); err != nil {
+			utils.LogErrorWithErr("Failed to find personal access token scopes", err)
+			return nil, false, err
+		}
+		roleClaims := authz.NewClaimSet(granted)
+		granted = make([]string, 0, len(scopes))
+		for _, scope := range scopes {
+			if roleClaims.Has(scope.Name) {
+				granted = append(granted, scope.Name)
+			}
+		}
+	}
+
+	resolved := *claims
+	resolved.OrganizationClaims = granted
+	resolved.OrganizationID = organizationID
+	resolved.OrganizationRoleID = membership.RoleID
+	return &resolved, true, nil
+}
+
+// ListUserOrganizations returns the organizations the user is a member of with its role in them
+func (s *OrganizationService) ListUserOrganizations(userID uint) ([]dto.UserOrganizationResponse, error) {
+	utils.LogInfo("Listing organizations of user", "userID", userID)
+
+	var memberships []models.OrganizationMembership
+	if err := s.DB.
+		Preload("Organization").
+		Preload("Role").
+		Where("user_id = ?", userID).
+		Order("organization_id").
+		Find(&memberships).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list organizations of user", err)
+		return nil, err
+	}
+
+	response := make([]dto.UserOrganizationResponse, len(memberships))
+	for i := range memberships {
+		response[i] = userOrganizationResponse(&memberships[i])
+	}
+	return response, nil
+}
+
+// CreateOrganization creates an organization with the user as its owner
+func (s *OrganizationService) CreateOrganization(userID uint, req dto.CreateOrganizationRequest) (*dto.UserOrganizationResponse, error) {
+	utils.LogInfo("Creating organization", "userID", userID, "name", req.Name)
+
+	var membership models.OrganizationMembership
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("name = ?", req.Name).First(&models.Organization{}).Error; err == nil {
+			utils.LogInfo("Organization already exists", "name", req.Name)
+			return ErrOrganizationAlreadyExists
+		}
+
+		var ownerRole models.Role
+		if err := tx.Where("name = ?", OrganizationOwnerRole).First(&ownerRole).Error; err != nil {
+			utils.LogErrorWithErr("Failed to find organization owner role", err)
+			return err
+		}
+
+		organization := models.Organization{Name: req.Name}
+		if err := tx.Create(&organization).Error; err != nil {
+			utils.LogErrorWithErr("Failed to create organization", err)
+			return err
+		}
+
+		membership = models.OrganizationMembership{
+			OrganizationID: organization.ID,
+			Organization:   organization,
+			UserID:         userID,
+			RoleID:         ownerRole.ID,
+			Role:           ownerRole,
+		}
+		if err := tx.Omit("Organization", "Role", "User").Create(&membership).Error; err != nil {
+			utils.LogErrorWithErr("Failed to create organization membership", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := userOrganizationResponse(&membership)
+	return &response, nil
+}
+
+func (s *OrganizationService) GetOrganization(subject authz.Subject, organizationID uint) (*dto.OrganizationResponse, error) {
+	utils.LogInfo("Getting organization", "organizationID", organizationID)
+
+	if err := s.authorizeOrganization(subject, authz.ActionRead, organizationID); err != nil {
+		return nil, err
+	}
+
+	organization, err := findOrganization(s.DB, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	response := organizationResponse(organization)
+	return &response, nil
+}
+
+// RenameOrganization changes the name of the organization
+func (s *OrganizationService) RenameOrganization(subject authz.Subject, organizationID uint, req dto.UpdateOrganizationRequest) (*dto.OrganizationResponse, error) {
+	utils.LogInfo("Renaming organization", "organizationID", organizationID, "name", req.Name)
+
+	if err := s.authorizeOrganization(subject, authz.ActionUpdate, organizationID); err != nil {
+		return nil, err
+	}
+
+	organization, err := findOrganization(s.DB, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.Where("name = ? AND id != ?", req.Name, organizationID).First(&models.Organization{}).Error; err == nil {
+		utils.LogInfo("Organization name already exists", "name", req.Name)
+		return nil, ErrOrganizationAlreadyExists
+	}
+
+	if err := s.DB.Model(organization).Update("name", req.Name).Error; err != nil {
+		utils.LogErrorWithErr("Failed to rename organization", err)
+		return nil, err
+	}
+	organization.Name = req.Name
+
+	response := organizationResponse(organization)
+	return &response, nil
+}
+
+// DeleteOrganization deletes the organization with its memberships
+func (s *OrganizationService) DeleteOrganization(subject authz.Subject, organizationID uint) (*dto.DeleteOrganizationResponse, error) {
+	utils.LogInfo("Deleting organization", "organizationID", organizationID)
+
+	if err := s.authorizeOrganization(subject, authz.ActionDelete, organizationID); err != nil {
+		return nil, err
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		organization, err := findOrganization(tx.Clauses(clause.Locking{Strength: "UPDATE"}), organizationID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ?", organizationID).Delete(&models.OrganizationMembership{}).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete organization memberships", err)
+			return err
+		}
+		if err := tx.Where("organization_id = ?", organizationID).Delete(&models.OrganizationInvitation{}).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete organization invitations", err)
+			return err
+		}
+		if err := tx.Delete(organization).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete organization", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogWarn("Organization deleted", "organizationID", organizationID, "by", subject.UserID)
+	return &dto.DeleteOrganizationResponse{IsSuccess: true}, nil
+}
+
+// ListMembers returns a page of the members of the organization.
+// The emails are only returned to the members allowed to manage the members.
+func (s *OrganizationService) ListMembers(subject authz.Subject, organizationID uint, query dto.ListOrganizationMembersQuery) (*dto.ListOrganizationMembersResponse, error) {
+	utils.LogInfo("Listing organization members", "organizationID", organizationID, "query", query)
+
+	if err := s.authorizeMember(subject, authz.ActionRead, organizationID, 0); err != nil {
+		return nil, err
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = defaultPageSize
+	}
+
+	db := s.DB.Model(&models.OrganizationMembership{}).Where("organization_id = ?", organizationID)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count organization members", err)
+		return nil, err
+	}
+
+	var memberships []models.OrganizationMembership
+	if err := db.Preload("User").Preload("Role").
+		Order("id").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&memberships).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list organization members", err)
+		return nil, err
+	}
+
+	showEmails := s.Policies.Can(subject, authz.ActionUpdate, authz.Resource{Type: ResourceOrganizationMember, OrganizationID: organizationID})
+	items := make([]dto.OrganizationMemberResponse, len(memberships))
+	for i := range memberships {
+		items[i] = organizationMemberResponse(&memberships[i], showEmails || memberships[i].UserID == subject.UserID)
+	}
+
+	return &dto.ListOrganizationMembersResponse{
+		Items:    items,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
+
+/*
+Invite the owner of the email to the organization with the role, see AcceptInvitation.
+The caller must hold every claim of the role in the organization. Inviting the email again
+replaces the role and renews the invitation. The response is the same whether or not an
+account uses the email, the invited user is only notified when one does.
+*/
+func (s *OrganizationService) InviteMember(subject authz.Subject, organizationID uint, req dto.InviteOrganizationMemberRequest) (*dto.OrganizationInvitationResponse, error) {
+	utils.LogInfo("Inviting organization member", "organizationID", organizationID, "roleID", req.RoleID)
+
+	if err := s.authorizeInvitation(subject, authz.ActionCreate, organizationID); err != nil {
+		return nil, err
+	}
+
+	role, err := findGrantableRole(s.DB, subject.Claims, req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var invitation models.OrganizationInvitation
+	var organization *models.Organization
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		organization, err = findOrganization(tx.Clauses(clause.Locking{Strength: "UPDATE"}), organizationID)
+		if err != nil {
+			return err
+		}
+
+		var members int64
+		if err := tx.Model(&models.OrganizationMembership{}).
+			Joins("JOIN users ON users.id = organization_memberships.user_id").
+			Where("organization_memberships.organization_id = ? AND users.email = ?", organizationID, req.Email).
+			Count(&members).Error; err != nil {
+			utils.LogErrorWithErr("Failed to find organization membership", err)
+			return err
+		}
+		if members > 0 {
+			return ErrAlreadyOrganizationMember
+		}
+
+		if err := tx.Where("organization_id = ? AND email = ?", organizationID, req.Email).First(&invitation).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogErrorWithErr("Failed to find organization invitation", err)
+			return err
+		}
+		invitation.OrganizationID = organizationID
+		invitation.Email = req.Email
+		invitation.RoleID = role.ID
+		invitation.InvitedByID = subject.UserID
+		invitation.ExpiresAt = time.Now().Add(organizationInvitationTTL)
+		if err := tx.Omit("Organization", "Role").Save(&invitation).Error; err != nil {
+			utils.LogErrorWithErr("Failed to save organization invitation", err)
+			return err
+		}
+		invitation.Role = *role
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogInfo("Organization member invited", "organizationID", organizationID, "invitationID", invitation.ID, "by", subject.UserID)
+	s.sendInvitationEmail(&invitation, organization)
+
+	response := organizationInvitationResponse(&invitation)
+	return &response, nil
+}
+
+// ListInvitations returns the invitations of the organization that can still be accepted
+func (s *OrganizationService) ListInvitations(subject authz.Subject, organizationID uint) ([]dto.OrganizationInvitationResponse, error) {
+	utils.LogInfo("Listing organization invitations", "organizationID", organizationID)
+
+	if err := s.authorizeInvitation(subject, authz.ActionRead, organizationID); err != nil {
+		return nil, err
+	}
+
+	var invitations []models.OrganizationInvitation
+	if err := s.DB.Preload("Role").
+		Where("organization_id = ? AND expires_at > ?", organizationID, time.Now()).
+		Order("id").
+		Find(&invitations).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list organization invitations", err)
+		return nil, err
+	}
+
+	response := make([]dto.OrganizationInvitationResponse, len(invitations))
+	for i := range invitations {
+		response[i] = organizationInvitationResponse(&invitations[i])
+	}
+	return response, nil
+}
+
+// RevokeInvitation deletes an invitation of the organization before it is accepted
+func (s *OrganizationService) RevokeInvitation(subject authz.Subject, organizationID uint, invitationID uint) (*dto.DeleteOrganizationInvitationResponse, error) {
+	utils.LogInfo("Revoking organization invitation", "organizationID", organizationID, "invitationID", invitationID)
+
+	if err := s.authorizeInvitation(subject, authz.ActionDelete, organizationID); err != nil {
+		return nil, err
+	}
+
+	result := s.DB.Where("id = ? AND organization_id = ?", invitationID, organizationID).Delete(&models.OrganizationInvitation{})
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to revoke organization invitation", result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvitationNotFound
+	}
+
+	utils.LogInfo("Organization invitation revoked", "organizationID", organizationID, "invitationID", invitationID, "by", subject.UserID)
+	return &dto.DeleteOrganizationInvitationResponse{IsSuccess: true}, nil
+}
+
+// ListUserInvitations returns the invitations sent to the email of the user that can still be accepted
+func (s *OrganizationService) ListUserInvitations(userID uint) ([]dto.UserInvitationResponse, error) {
+	utils.LogInfo("Listing invitations of user", "userID", userID)
+
+	user, err := findInvitedUser(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var invitations []models.OrganizationInvitation
+	if err := s.DB.Preload("Organization").Preload("Role").
+		Where("email = ? AND expires_at > ?", user.Email, time.Now()).
+		Order("id").
+		Find(&invitations).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list invitations of user", err)
+		return nil, err
+	}
+
+	response := make([]dto.UserInvitationResponse, len(invitations))
+	for i := range invitations {
+		response[i] = dto.UserInvitationResponse{
+			ID:           invitations[i].ID,
+			Organization: organizationResponse(&invitations[i].Organization),
+			Role:         dto.OrganizationRole{ID: invitations[i].Role.ID, Name: invitations[i].Role.Name},
+			ExpiresAt:    invitations[i].ExpiresAt,
+			CreatedAt:    invitations[i].CreatedAt,
+		}
+	}
+	return response, nil
+}
+
+// AcceptInvitation makes the user a member of the organization with the role of the invitation.
+// Only an active account with a verified email can accept the invitations sent to that email.
+func (s *OrganizationService) AcceptInvitation(userID uint, invitationID uint) (*dto.UserOrganizationResponse, error) {
+	utils.LogInfo("Accepting organization invitation", "userID", userID, "invitationID", invitationID)
+
+	var membership models.OrganizationMembership
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := findInvitedUser(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		var invitation models.OrganizationInvitation
+		if err := tx.Preload("Role").
+			Where("id = ? AND email = ? AND expires_at > ?", invitationID, user.Email, time.Now()).
+			First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvitationNotFound
+			}
+			utils.LogErrorWithErr("Failed to find organization invitation", err)
+			return err
+		}
+
+		organization, err := findOrganization(tx.Clauses(clause.Locking{Strength: "UPDATE"}), invitation.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&invitation).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete organization invitation", err)
+			return err
+		}
+		if err := tx.Where("organization_id = ? AND user_id = ?", organization.ID, userID).First(&models.OrganizationMembership{}).Error; err == nil {
+			return ErrAlreadyOrganizationMember
+		}
+
+		membership = models.OrganizationMembership{
+			OrganizationID: organization.ID,
+			Organization:   *organization,
+			UserID:         userID,
+			RoleID:         invitation.RoleID,
+			Role:           invitation.Role,
+		}
+		if err := tx.Omit("Organization", "Role", "User").Create(&membership).Error; err != nil {
+			utils.LogErrorWithErr("Failed to create organization membership", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogInfo("Organization invitation accepted", "organizationID", membership.OrganizationID, "userID", userID, "roleID", membership.RoleID)
+	response := userOrganizationResponse(&membership)
+	return &response, nil
+}
+
+// DeclineInvitation deletes an invitation sent to the email of the user
+func (s *OrganizationService) DeclineInvitation(userID uint, invitationID uint) (*dto.DeleteOrganizationInvitationResponse, error) {
+	utils.LogInfo("Declining organization invitation", "userID", userID, "invitationID", invitationID)
+
+	user, err := findInvitedUser(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.DB.Where("id = ? AND email = ?", invitationID, user.Email).Delete(&models.OrganizationInvitation{})
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to decline organization invitation", result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvitationNotFound
+	}
+	return &dto.DeleteOrganizationInvitationResponse{IsSuccess: true}, nil
+}
+
+// SetMemberRole changes the role of a member. The caller must hold every claim of the new role
+// in the organization and can't change its own role.
+func (s *OrganizationService) SetMemberRole(subject authz.Subject, organizationID uint, userID uint, req dto.SetOrganizationMemberRoleRequest) (*dto.OrganizationMemberResponse, error) {
+	utils.LogInfo("Setting role of organization member", "organizationID", organizationID, "userID", userID, "roleID", req.RoleID)
+
+	if subject.UserID == userID {
+		return nil, ErrSelfAdministration
+	}
+	if err := s.authorizeMember(subject, authz.ActionUpdate, organizationID, userID); err != nil {
+		return nil, err
+	}
+
+	role, err := findGrantableRole(s.DB, subject.Claims, req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var membership *models.OrganizationMembership
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		membership, err = lockMembership(tx, organizationID, userID)
+		if err != nil {
+			return err
+		}
+		if role.Name != OrganizationOwnerRole {
+			if err := ensureAnotherOwner(tx, membership); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(membership).Update("role_id", role.ID).Error; err != nil {
+			utils.LogErrorWithErr("Failed to set role of organization member", err)
+			return err
+		}
+		membership.RoleID = role.ID
+		membership.Role = *role
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogInfo("Role of organization member changed", "organizationID", organizationID, "userID", userID, "roleID", role.ID, "by", subject.UserID)
+	response := organizationMemberResponse(membership, true)
+	return &response, nil
+}
+
+// RemoveMember removes a member from the organization, members leave with LeaveOrganization instead
+func (s *OrganizationService) RemoveMember(subject authz.Subject, organizationID uint, userID uint) (*dto.RemoveOrganizationMemberResponse, error) {
+	utils.LogInfo("Removing organization member", "organizationID", organizationID, "userID", userID)
+
+	if subject.UserID == userID {
+		return nil, ErrSelfAdministration
+	}
+	if err := s.authorizeMember(subject, authz.ActionDelete, organizationID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.deleteMembership(organizationID, userID); err != nil {
+		return nil, err
+	}
+
+	utils.LogInfo("Organization member removed", "organizationID", organizationID, "userID", userID, "by", subject.UserID)
+	return &dto.RemoveOrganizationMemberResponse{IsSuccess: true}, nil
+}
+
+// LeaveOrganization removes the user from the organization, the last owner can't leave
+func (s *OrganizationService) LeaveOrganization(subject authz.Subject, organizationID uint) (*dto.RemoveOrganizationMemberResponse, error) {
+	utils.LogInfo("Leaving organization", "organizationID", organizationID, "userID", subject.UserID)
+
+	if err := s.authorizeMember(subject, authz.ActionDelete, organizationID, subject.UserID); err != nil {
+		return nil, err
+	}
+	if err := s.deleteMembership(organizationID, subject.UserID); err != nil {
+		return nil, err
+	}
+	return &dto.RemoveOrganizationMemberResponse{IsSuccess: true}, nil
+}
+
+func (s *OrganizationService) deleteMembership(organizationID uint, userID uint) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		membership, err := lockMembership(tx, organizationID, userID)
+		if err != nil {
+			return err
+		}
+		if err := ensureAnotherOwner(tx, membership); err != nil {
+			return err
+		}
+		if err := tx.Delete(membership).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete organization membership", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// authorizeOrganization authorizes the action on the organization itself
+func (s *OrganizationService) authorizeOrganization(subject authz.Subject, action string, organizationID uint) error {
+	return s.Policies.Authorize(subject, action, authz.Resource{
+		Type:           ResourceOrganization,
+		ID:             organizationID,
+		OrganizationID: organizationID,
+	})
+}
+
+// authorizeInvitation authorizes the action on the invitations of the organization
+func (s *OrganizationService) authorizeInvitation(subject authz.Subject, action string, organizationID uint) error {
+	return s.Policies.Authorize(subject, action, authz.Resource{
+		Type:           ResourceOrganizationInvitation,
+		OrganizationID: organizationID,
+	})
+}
+
+// sendInvitationEmail notifies the active account using the email of the invitation, if any
+func (s *OrganizationService) sendInvitationEmail(invitation *models.OrganizationInvitation, organization *models.Organization) {
+	if err := s.DB.Where("email = ? AND disabled_at IS NULL AND anonymized_at IS NULL", invitation.Email).First(&models.User{}).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogErrorWithErr("Failed to find invited user", err)
+		}
+		return
+	}
+
+	frontendURL := utils.GetEnv("FRONTEND_URL", "http://localhost:3000")
+	body := fmt.Sprintf(
+		"You have been invited to join the organization %s. The invitation expires on %s, review it in your account: %s/settings/invitations",
+		organization.Name, invitation.ExpiresAt.UTC().Format(time.RFC1123), frontendURL,
+	)
+	if err := utils.SendEmailWithContext(context.Background(), invitation.Email, "You have been invited to an organization", body, false); err != nil {
+		utils.LogErrorWithErr("Failed to send organization invitation email", err, "invitationID", invitation.ID)
+	}
+}
+
+// authorizeMember authorizes the action on the membership of the user, zero for the members as a whole
+func (s *OrganizationService) authorizeMember(subject authz.Subject, action string, organizationID uint, userID uint) error {
+	return s.Policies.Authorize(subject, action, authz.Resource{
+		Type:           ResourceOrganizationMember,
+		OwnerID:        userID,
+		OrganizationID: organizationID,
+	})
+}
+
+// findInvitedUser loads the user answering an invitation, which must be active and have verified its email
+func findInvitedUser(db *gorm.DB, userID uint) (*models.User, error) {
+	var user models.User
+	if err := db.Where("id = ? AND anonymized_at IS NULL", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	if !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+	return &user, nil
+}
+
+func findOrganization(db *gorm.DB, organizationID uint) (*models.Organization, error) {
+	var organization models.Organization
+	if err := db.Where("id = ?", organizationID).First(&organization).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		utils.LogErrorWithErr("Failed to find organization", err)
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// findGrantableRole loads the role and verifies the caller holds every claim of it
+func findGrantableRole(db *gorm.DB, callerClaims authz.ClaimSet, roleID uint) (*models.Role, error) {
+	var role models.Role
+	if err := db.Preload("Claims").Where("id = ?", roleID).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		utils.LogErrorWithErr("Failed to find role", err)
+		return nil, err
+	}
+	if err := checkGrantableSet(callerClaims, role.Claims); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+/*
+Lock the organization and load the membership of the user in it.
+Locking the organization serializes the membership changes, so concurrent
+changes can't both remove the last owner. Must be called inside a transaction.
+*/
+func lockMembership(tx *gorm.DB, organizationID uint, userID uint) (*models.OrganizationMembership, error) {
+	if _, err := findOrganization(tx.Clauses(clause.Locking{Strength: "UPDATE"}), organizationID); err != nil {
+		return nil, err
+	}
+
+	var membership models.OrganizationMembership
+	if err := tx.Preload("User").Preload("Role").
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationMemberNotFound
+		}
+		utils.LogErrorWithErr("Failed to find organization membership", err)
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// ensureAnotherOwner makes sure the organization keeps an owner once the membership stops being one
+func ensureAnotherOwner(tx *gorm.DB, membership *models.OrganizationMembership) error {
+	if membership.Role.Name != OrganizationOwnerRole {
+		return nil
+	}
+
+	var owners int64
+	if err := tx.Model(&models.OrganizationMembership{}).
+		Joins("JOIN roles ON roles.id = organization_memberships.role_id").
+		Where("organization_memberships.organization_id = ? AND organization_memberships.id != ? AND roles.name = ?",
+			membership.OrganizationID, membership.ID, OrganizationOwnerRole).
+		Count(&owners).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count organization owners", err)
+		return err
+	}
+	if owners == 0 {
+		return ErrLastOrganizationOwner
+	}
+	return nil
+}
+
+// ensureNotLastOwner makes sure no organization loses its last owner when the user's memberships are removed.
+// Inside a transaction the organizations of the user are locked like in lockMembership.
+func ensureNotLastOwner(tx *gorm.DB, userID uint) error {
+	var memberships []models.OrganizationMembership
+	if err := tx.Preload("Role").Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+		utils.LogErrorWithErr("Failed to find organization memberships", err, "userID", userID)
+		return err
+	}
+
+	for i := range memberships {
+		if memberships[i].Role.Name != OrganizationOwnerRole {
+			continue
+		}
+		if _, err := findOrganization(tx.Clauses(clause.Locking{Strength: "UPDATE"}), memberships[i].OrganizationID); err != nil {
+			return err
+		}
+		if err := ensureAnotherOwner(tx, &memberships[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func organizationResponse(organization *models.Organization) dto.OrganizationResponse {
+	return dto.OrganizationResponse{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		CreatedAt: organization.CreatedAt,
+		UpdatedAt: organization.UpdatedAt,
+	}
+}
+
+func userOrganizationResponse(membership *models.OrganizationMembership) dto.UserOrganizationResponse {
+	return dto.UserOrganizationResponse{
+		OrganizationResponse: organizationResponse(&membership.Organization),
+		Role:                 dto.OrganizationRole{ID: membership.Role.ID, Name: membership.Role.Name},
+	}
+}
+
+func organizationMemberResponse(membership *models.OrganizationMembership, showEmail bool) dto.OrganizationMemberResponse {
+	response := dto.OrganizationMemberResponse{
+		UserID:   membership.UserID,
+		Username: membership.User.Username,
+		Role:     dto.OrganizationRole{ID: membership.Role.ID, Name: membership.Role.Name},
+		JoinedAt: membership.CreatedAt,
+	}
+	if showEmail {
+		response.Email = membership.User.Email
+	}
+	return response
+}
+
+func organizationInvitationResponse(invitation *models.OrganizationInvitation) dto.OrganizationInvitationResponse {
+	return dto.OrganizationInvitationResponse{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      dto.OrganizationRole{ID: invitation.Role.ID, Name: invitation.Role.Name},
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
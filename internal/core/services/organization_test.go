@@ -0,0 +1,268 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strconv"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// newTestOrganization creates the organization roles and an organization owned by the user
+func newTestOrganization(t *testing.T, db *gorm.DB, owner *models.User) (*OrganizationService, uint) {
+	t.Helper()
+
+	roles := map[string][]string{
+		OrganizationOwnerRole: {
+			"organization:read", "organization:update", "organization:delete",
+			"organization:member:read", "organization:member:write", "organization:member:delete",
+		},
+		OrganizationMemberRole: {"organization:read", "organization:member:read"},
+	}
+	for name, claimNames := range roles {
+		role := models.Role{Name: name}
+		for _, claimName := range claimNames {
+			var claim models.Claim
+			if err := db.Where(models.Claim{Name: claimName}).FirstOrCreate(&claim).Error; err != nil {
+				t.Fatalf("create claim: %v", err)
+			}
+			role.Claims = append(role.Claims, claim)
+		}
+		if err := db.Create(&role).Error; err != nil {
+			t.Fatalf("create role: %v", err)
+		}
+	}
+
+	service := NewOrganizationService(db, NewPolicies())
+	organization, err := service.CreateOrganization(owner.ID, dto.CreateOrganizationRequest{Name: "acme"})
+	if err != nil {
+		t.Fatalf("CreateOrganization() error = %v", err)
+	}
+	return service, organization.ID
+}
+
+// organizationSubject resolves the subject of a request of the user in the organization
+func organizationSubject(t *testing.T, service *OrganizationService, userID uint, organizationID uint) authz.Subject {
+	t.Helper()
+
+	claims, member, err := service.ResolveOrganization(&utils.TokenClaims{UserID: strconv.FormatUint(uint64(userID), 10)}, organizationID)
+	if err != nil || !member {
+		t.Fatalf("ResolveOrganization() = %v, %v, want a member", member, err)
+	}
+	subject, err := authz.SubjectFromToken(claims)
+	if err != nil {
+		t.Fatalf("SubjectFromToken() error = %v", err)
+	}
+	return subject
+}
+
+func findRoleID(t *testing.T, db *gorm.DB, name string) uint {
+	t.Helper()
+
+	var role models.Role
+	if err := db.Where("name = ?", name).First(&role).Error; err != nil {
+		t.Fatalf("find role: %v", err)
+	}
+	return role.ID
+}
+
+func TestOrganizationInvitation(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	service, organizationID := newTestOrganization(t, db, alice)
+	owner := organizationSubject(t, service, alice.ID, organizationID)
+	memberRoleID := findRoleID(t, db, OrganizationMemberRole)
+
+	invitation, err := service.InviteMember(owner, organizationID, dto.InviteOrganizationMemberRequest{Email: bob.Email, RoleID: memberRoleID})
+	if err != nil {
+		t.Fatalf("InviteMember() error = %v", err)
+	}
+	// Inviting an email without an account looks the same to the caller
+	if _, err := service.InviteMember(owner, organizationID, dto.InviteOrganizationMemberRequest{Email: "nobody@example.com", RoleID: memberRoleID}); err != nil {
+		t.Fatalf("InviteMember() of an unknown email error = %v", err)
+	}
+
+	var memberships int64
+	db.Model(&models.OrganizationMembership{}).Where("user_id = ?", bob.ID).Count(&memberships)
+	if memberships != 0 {
+		t.Fatalf("memberships of the invited user = %d before accepting, want 0", memberships)
+	}
+
+	// Someone else can't see or accept the invitation
+	if invitations, err := service.ListUserInvitations(carol.ID); err != nil || len(invitations) != 0 {
+		t.Errorf("ListUserInvitations() of another user = %v, %v, want none", invitations, err)
+	}
+	if _, err := service.AcceptInvitation(carol.ID, invitation.ID); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("AcceptInvitation() by another user error = %v, want %v", err, ErrInvitationNotFound)
+	}
+
+	if invitations, err := service.ListUserInvitations(bob.ID); err != nil || len(invitations) != 1 {
+		t.Fatalf("ListUserInvitations() = %v, %v, want the invitation", invitations, err)
+	}
+	res, err := service.AcceptInvitation(bob.ID, invitation.ID)
+	if err != nil {
+		t.Fatalf("AcceptInvitation() error = %v", err)
+	}
+	if res.ID != organizationID || res.Role.Name != OrganizationMemberRole {
+		t.Errorf("AcceptInvitation() = %+v, want a member of the organization", res)
+	}
+	if _, err := service.AcceptInvitation(bob.ID, invitation.ID); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("second AcceptInvitation() error = %v, want %v", err, ErrInvitationNotFound)
+	}
+	if _, err := service.InviteMember(owner, organizationID, dto.InviteOrganizationMemberRequest{Email: bob.Email, RoleID: memberRoleID}); !errors.Is(err, ErrAlreadyOrganizationMember) {
+		t.Errorf("InviteMember() of a member error = %v, want %v", err, ErrAlreadyOrganizationMember)
+	}
+}
+
+func TestOrganizationInvitationRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(db *gorm.DB, user *models.User)
+		wantErr error
+	}{
+		{
+			name:    "disabled account",
+			prepare: func(db *gorm.DB, user *models.User) { db.Model(user).Update("disabled_at", time.Now()) },
+			wantErr: ErrAccountDisabled,
+		},
+		{
+			name:    "unverified email",
+			prepare: func(db *gorm.DB, user *models.User) { db.Model(user).Update("email_verified", false) },
+			wantErr: ErrEmailNotVerified,
+		},
+		{
+			name:    "anonymized account",
+			prepare: func(db *gorm.DB, user *models.User) { db.Model(user).Update("anonymized_at", time.Now()) },
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "expired invitation",
+			prepare: func(db *gorm.DB, user *models.User) {
+				db.Model(&models.OrganizationInvitation{}).Where("email = ?", user.Email).Update("expires_at", time.Now().Add(-time.Minute))
+			},
+			wantErr: ErrInvitationNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			alice := createTestUser(t, db, "alice")
+			bob := createTestUser(t, db, "bob")
+			service, organizationID := newTestOrganization(t, db, alice)
+			owner := organizationSubject(t, service, alice.ID, organizationID)
+
+			invitation, err := service.InviteMember(owner, organizationID, dto.InviteOrganizationMemberRequest{
+				Email:  bob.Email,
+				RoleID: findRoleID(t, db, OrganizationMemberRole),
+			})
+			if err != nil {
+				t.Fatalf("InviteMember() error = %v", err)
+			}
+			tt.prepare(db, bob)
+
+			if _, err := service.AcceptInvitation(bob.ID, invitation.ID); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AcceptInvitation() error = %v, want %v", err, tt.wantErr)
+			}
+			var memberships int64
+			db.Model(&models.OrganizationMembership{}).Where("user_id = ?", bob.ID).Count(&memberships)
+			if memberships != 0 {
+				t.Errorf("memberships = %d, want 0", memberships)
+			}
+		})
+	}
+}
+
+func TestOrganizationMemberPolicies(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	service, organizationID := newTestOrganization(t, db, alice)
+	memberRoleID := findRoleID(t, db, OrganizationMemberRole)
+
+	owner := organizationSubject(t, service, alice.ID, organizationID)
+	invitation, err := service.InviteMember(owner, organizationID, dto.InviteOrganizationMemberRequest{Email: bob.Email, RoleID: memberRoleID})
+	if err != nil {
+		t.Fatalf("InviteMember() error = %v", err)
+	}
+	if _, err := service.AcceptInvitation(bob.ID, invitation.ID); err != nil {
+		t.Fatalf("AcceptInvitation() error = %v", err)
+	}
+	member := organizationSubject(t, service, bob.ID, organizationID)
+
+	// Only the members managing the members see the emails of the others
+	emails := func(subject authz.Subject) map[uint]string {
+		res, err := service.ListMembers(subject, organizationID, dto.ListOrganizationMembersQuery{})
+		if err != nil {
+			t.Fatalf("ListMembers() error = %v", err)
+		}
+		emails := make(map[uint]string)
+		for _, item := range res.Items {
+			emails[item.UserID] = item.Email
+		}
+		return emails
+	}
+	if got := emails(owner); got[alice.ID] != alice.Email || got[bob.ID] != bob.Email {
+		t.Errorf("emails listed to the owner = %v, want every email", got)
+	}
+	if got := emails(member); got[alice.ID] != "" || got[bob.ID] != bob.Email {
+		t.Errorf("emails listed to a member = %v, want only its own", got)
+	}
+
+	if _, err := service.InviteMember(member, organizationID, dto.InviteOrganizationMemberRequest{Email: "carol@example.com", RoleID: memberRoleID}); !errors.Is(err, authz.ErrDenied) {
+		t.Errorf("InviteMember() by a member error = %v, want %v", err, authz.ErrDenied)
+	}
+	if _, err := service.RemoveMember(member, organizationID, alice.ID); !errors.Is(err, authz.ErrDenied) {
+		t.Errorf("RemoveMember() by a member error = %v, want %v", err, authz.ErrDenied)
+	}
+	if _, err := service.RenameOrganization(member, organizationID, dto.UpdateOrganizationRequest{Name: "renamed"}); !errors.Is(err, authz.ErrDenied) {
+		t.Errorf("RenameOrganization() by a member error = %v, want %v", err, authz.ErrDenied)
+	}
+
+	// A subject acting in another organization is denied even with the claims
+	outsider := owner
+	outsider.OrganizationID = organizationID + 1
+	if _, err := service.GetOrganization(outsider, organizationID); !errors.Is(err, authz.ErrDenied) {
+		t.Errorf("GetOrganization() from another organization error = %v, want %v", err, authz.ErrDenied)
+	}
+
+	if _, err := service.LeaveOrganization(member, organizationID); err != nil {
+		t.Errorf("LeaveOrganization() by a member error = %v", err)
+	}
+	if _, err := service.LeaveOrganization(owner, organizationID); !errors.Is(err, ErrLastOrganizationOwner) {
+		t.Errorf("LeaveOrganization() by the last owner error = %v, want %v", err, ErrLastOrganizationOwner)
+	}
+}
+
+func TestOrganizationRoleRequiresTwoFactor(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	service, organizationID := newTestOrganization(t, db, alice)
+	if err := db.Model(&models.Role{}).Where("name = ?", OrganizationOwnerRole).Update("requires_two_factor", true).Error; err != nil {
+		t.Fatalf("require 2fa: %v", err)
+	}
+
+	// The owner stays a member but holds none of the claims of the role
+	owner := organizationSubject(t, service, alice.ID, organizationID)
+	if owner.Claims.Has("organization:read") {
+		t.Errorf("organization claims granted without 2FA")
+	}
+	if _, err := service.GetOrganization(owner, organizationID); !errors.Is(err, authz.ErrDenied) {
+		t.Errorf("GetOrganization() without 2FA error = %v, want %v", err, authz.ErrDenied)
+	}
+
+	if err := db.Model(alice).Update("totp_enabled", true).Error; err != nil {
+		t.Fatalf("enable 2fa: %v", err)
+	}
+	owner = organizationSubject(t, service, alice.ID, organizationID)
+	if _, err := service.GetOrganization(owner, organizationID); err != nil {
+		t.Errorf("GetOrganization() with 2FA error = %v", err)
+	}
+}
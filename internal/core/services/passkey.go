@@ -0,0 +1,461 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPasskeysUnavailable     = errors.New("passkeys are not configured")
+	ErrPasskeyNotFound         = errors.New("passkey not found")
+	ErrInvalidPasskeyChallenge = errors.New("invalid passkey challenge")
+	ErrPasskeyVerification     = errors.New("passkey verification failed")
+)
+
+const (
+	passkeyCeremonyRegistration = "registration"
+	passkeyCeremonyLogin        = "login"
+	passkeyHandleBytes          = 32
+)
+
+type PasskeyService struct {
+	DB       *gorm.DB
+	Policies *authz.Policies
+	webAuthn *webauthn.WebAuthn
+	throttle *loginThrottler
+}
+
+func NewPasskeyService(db *gorm.DB, cfg config.WebAuthn, authConfig config.Auth, policies *authz.Policies) *PasskeyService {
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+	})
+	if err != nil {
+		// The rest of the API keeps working, only the passkey endpoints are unavailable
+		utils.LogErrorWithErr("Failed to initialize WebAuthn, passkeys are disabled", err)
+	}
+	return &PasskeyService{DB: db, Policies: policies, webAuthn: w, throttle: newLoginThrottler(db, authConfig.LoginThrottle)}
+}
+
+// BeginRegistration starts the registration ceremony of a new passkey for the user
+func (s *PasskeyService) BeginRegistration(userID uint) (*dto.BeginPasskeyResponse, error) {
+	utils.LogInfo("Beginning passkey registration", "userID", userID)
+
+	if s.webAuthn == nil {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	user, err := s.loadPasskeyUser(s.DB.Where("id = ?", userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(user.user.PasskeyHandle) == 0 {
+		handle, err := utils.GenerateRandomBytes(passkeyHandleBytes)
+		if err != nil {
+			utils.LogErrorWithErr("Failed to generate passkey handle", err)
+			return nil, err
+		}
+		if err := s.DB.Model(user.user).Update("passkey_handle", handle).Error; err != nil {
+			utils.LogErrorWithErr("Failed to save passkey handle", err)
+			return nil, err
+		}
+		user.user.PasskeyHandle = handle
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(webauthn.Credentials(user.WebAuthnCredentials()).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+	)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to begin passkey registration", err)
+		return nil, err
+	}
+
+	key, err := s.saveChallenge(passkeyCeremonyRegistration, session, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.BeginPasskeyResponse{SessionKey: key, Options: creation}, nil
+}
+
+// FinishRegistration verifies the attestation and stores the new passkey
+func (s *PasskeyService) FinishRegistration(userID uint, req dto.FinishPasskeyRegistrationRequest) (*dto.PasskeyResponse, error) {
+	utils.LogInfo("Finishing passkey registration", "userID", userID)
+
+	if s.webAuthn == nil {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	session, err := s.consumeChallenge(req.SessionKey, passkeyCeremonyRegistration, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.loadPasskeyUser(s.DB.Where("id = ?", userID))
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to parse passkey registration", err)
+		return nil, ErrPasskeyVerification
+	}
+
+	credential, err := s.webAuthn.CreateCredential(user, *session, parsed)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to verify passkey registration", err)
+		return nil, ErrPasskeyVerification
+	}
+
+	name := req.Name
+	if name == "" {
+		name = "Passkey"
+	}
+
+	transports := make([]string, len(credential.Transport))
+	for i, t := range credential.Transport {
+		transports[i] = string(t)
+	}
+
+	record := models.PasskeyCredential{
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		Flags:           uint8(credential.Flags.ProtocolValue()),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		UserID:          userID,
+	}
+	if err := s.DB.Create(&record).Error; err != nil {
+		utils.LogErrorWithErr("Failed to save passkey", err)
+		return nil, err
+	}
+
+	return toPasskeyResponse(&record), nil
+}
+
+// BeginLogin starts a discoverable login ceremony, the user is identified by the passkey itself
+func (s *PasskeyService) BeginLogin() (*dto.BeginPasskeyResponse, error) {
+	utils.LogInfo("Beginning passkey login")
+
+	if s.webAuthn == nil {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationPreferred),
+	)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to begin passkey login", err)
+		return nil, err
+	}
+
+	key, err := s.saveChallenge(passkeyCeremonyLogin, session, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.BeginPasskeyResponse{SessionKey: key, Options: assertion}, nil
+}
+
+// FinishLogin verifies the assertion, updates the sign count and issues tokens like Login
+func (s *PasskeyService) FinishLogin(req dto.FinishPasskeyLoginRequest, client dto.SessionInfo) (*dto.LoginResponse, error) {
+	utils.LogInfo("Finishing passkey login")
+
+	if s.webAuthn == nil {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	blocked, err := s.throttle.ipBlocked(client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		utils.LogWarn("Security event: passkey login blocked for IP address", "ip", client.IPAddress)
+		return nil, ErrTooManyLoginAttempts
+	}
+
+	session, err := s.consumeChallenge(req.SessionKey, passkeyCeremonyLogin, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to parse passkey assertion", err)
+		return nil, ErrPasskeyVerification
+	}
+
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		user, err := s.loadPasskeyUser(s.DB.Where("passkey_handle = ?", userHandle))
+		if err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	webAuthnUser, credential, err := s.webAuthn.ValidatePasskeyLogin(handler, *session, parsed)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to verify passkey assertion", err)
+		s.throttle.recordLoginAttempt("", nil, client.IPAddress, false)
+		return nil, ErrPasskeyVerification
+	}
+	user := webAuthnUser.(*passkeyUser)
+
+	now := time.Now()
+	if err := s.DB.Model(&models.PasskeyCredential{}).
+		Where("user_id = ? AND credential_id = ?", user.user.ID, credential.ID).
+		Updates(map[string]any{
+			"sign_count":    credential.Authenticator.SignCount,
+			"clone_warning": credential.Authenticator.CloneWarning,
+			"flags":         uint8(credential.Flags.ProtocolValue()),
+			"last_used_at":  now,
+		}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to update passkey", err)
+		return nil, err
+	}
+
+	if credential.Authenticator.CloneWarning {
+		utils.LogWarn("Security event: passkey sign count did not increase, the authenticator may be cloned",
+			"userID", user.user.ID,
+		)
+		s.throttle.recordLoginAttempt(user.user.Email, &user.user.ID, client.IPAddress, false)
+		return nil, ErrPasskeyVerification
+	}
+
+	s.throttle.recordLoginAttempt(user.user.Email, &user.user.ID, client.IPAddress, true)
+
+	tokens, err := issueTokens(s.DB, user.user, newTokenSession(client, req.Remember))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		AccessToken:            tokens.AccessToken,
+		RefreshToken:           tokens.RefreshToken,
+		TwoFactorSetupRequired: tokens.TwoFactorSetupRequired,
+	}, nil
+}
+
+// ListPasskeys returns the passkeys registered by the user
+func (s *PasskeyService) ListPasskeys(subject authz.Subject) ([]dto.PasskeyResponse, error) {
+	utils.LogInfo("Listing passkeys", "userID", subject.UserID)
+
+	var credentials []models.PasskeyCredential
+	if err := s.DB.Where("user_id = ?", subject.UserID).Order("created_at").Find(&credentials).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list passkeys", err)
+		return nil, err
+	}
+
+	response := make([]dto.PasskeyResponse, 0, len(credentials))
+	for i := range credentials {
+		if s.Policies.Can(subject, authz.ActionRead, authz.Resource{Type: ResourcePasskey, ID: credentials[i].ID, OwnerID: credentials[i].UserID}) {
+			response = append(response, *toPasskeyResponse(&credentials[i]))
+		}
+	}
+
+	return response, nil
+}
+
+// DeletePasskey removes a passkey of the user
+func (s *PasskeyService) DeletePasskey(subject authz.Subject, passkeyID uint) (*dto.DeletePasskeyResponse, error) {
+	utils.LogInfo("Deleting passkey", "userID", subject.UserID, "passkeyID", passkeyID)
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var passkey models.PasskeyCredential
+		if err := tx.Where("id = ?", passkeyID).First(&passkey).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPasskeyNotFound
+			}
+			utils.LogErrorWithErr("Failed to find passkey", err)
+			return err
+		}
+		resource := authz.Resource{Type: ResourcePasskey, ID: passkey.ID, OwnerID: passkey.UserID}
+		// A record the subject can't read is reported as missing so its ID doesn't leak
+		if !s.Policies.Can(subject, authz.ActionRead, resource) {
+			return ErrPasskeyNotFound
+		}
+		if err := s.Policies.Authorize(subject, authz.ActionDelete, resource); err != nil {
+			return err
+		}
+
+		if err := ensureAnotherLoginMethod(tx, passkey.UserID); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&passkey).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete passkey", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.DeletePasskeyResponse{IsSuccess: true}, nil
+}
+
+// saveChallenge stores the ceremony state and returns the key the client sends back on finish
+func (s *PasskeyService) saveChallenge(ceremony string, session *webauthn.SessionData, userID *uint) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to marshal passkey session", err)
+		return "", err
+	}
+
+	key, err := utils.GenerateSecureToken()
+	if err != nil {
+		utils.LogErrorWithErr("Failed to generate passkey session key", err)
+		return "", err
+	}
+
+	// Drop abandoned ceremonies
+	if err := s.DB.Where("expires_at < ?", time.Now()).Delete(&models.PasskeyChallenge{}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to delete expired passkey challenges", err)
+	}
+
+	expiresInMinutes := utils.GetEnvAsInt("PASSKEY_CHALLENGE_EXPIRES_IN_MIN", 5)
+	challenge := models.PasskeyChallenge{
+		ChallengeKey: utils.HashToken(key),
+		Ceremony:     ceremony,
+		SessionData:  string(data),
+		UserID:       userID,
+		ExpiresAt:    time.Now().Add(time.Duration(expiresInMinutes) * time.Minute),
+	}
+	if err := s.DB.Create(&challenge).Error; err != nil {
+		utils.LogErrorWithErr("Failed to save passkey challenge", err)
+		return "", err
+	}
+
+	return key, nil
+}
+
+// consumeChallenge loads and deletes the ceremony state so it can only be used once
+func (s *PasskeyService) consumeChallenge(key, ceremony string, userID *uint) (*webauthn.SessionData, error) {
+	var challenge models.PasskeyChallenge
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("challenge_key = ? AND ceremony = ?", utils.HashToken(key), ceremony).First(&challenge).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&challenge).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidPasskeyChallenge
+		}
+		utils.LogErrorWithErr("Failed to consume passkey challenge", err)
+		return nil, err
+	}
+
+	if challenge.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidPasskeyChallenge
+	}
+
+	if userID != nil && (challenge.UserID == nil || *challenge.UserID != *userID) {
+		return nil, ErrInvalidPasskeyChallenge
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(challenge.SessionData), &session); err != nil {
+		utils.LogErrorWithErr("Failed to unmarshal passkey session", err)
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// loadPasskeyUser loads the user matched by the query with everything needed to issue tokens
+func (s *PasskeyService) loadPasskeyUser(query *gorm.DB) (*passkeyUser, error) {
+	var user models.User
+	if err := query.
+		Preload("Role").
+		Preload("Role.Claims").
+		Preload("Claims").
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+
+	var credentials []models.PasskeyCredential
+	if err := s.DB.Where("user_id = ?", user.ID).Find(&credentials).Error; err != nil {
+		utils.LogErrorWithErr("Failed to find passkeys", err)
+		return nil, err
+	}
+
+	return &passkeyUser{user: &user, credentials: credentials}, nil
+}
+
+// passkeyUser adapts models.User to webauthn.User
+type passkeyUser struct {
+	user        *models.User
+	credentials []models.PasskeyCredential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.user.PasskeyHandle
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return u.user.Username
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, len(u.credentials))
+	for i, c := range u.credentials {
+		var transports []protocol.AuthenticatorTransport
+		if c.Transports != "" {
+			for _, t := range strings.Split(c.Transports, ",") {
+				transports = append(transports, protocol.AuthenticatorTransport(t))
+			}
+		}
+		credentials[i] = webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(c.Flags)),
+			Authenticator: webauthn.Authenticator{
+				AAGUID:       c.AAGUID,
+				SignCount:    c.SignCount,
+				CloneWarning: c.CloneWarning,
+			},
+		}
+	}
+	return credentials
+}
+
+func toPasskeyResponse(c *models.PasskeyCredential) *dto.PasskeyResponse {
+	return &dto.PasskeyResponse{
+		ID:             c.ID,
+		Name:           c.Name,
+		BackupEligible: protocol.AuthenticatorFlags(c.Flags).HasBackupEligible(),
+		CloneWarning:   c.CloneWarning,
+		CreatedAt:      c.CreatedAt,
+		LastUsedAt:     c.LastUsedAt,
+	}
+}
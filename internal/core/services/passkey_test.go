@@ -0,0 +1,381 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"gorm.io/gorm"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "http://localhost:3000"
+)
+
+// Authenticator data flags, see https://www.w3.org/TR/webauthn-3/#authdata-flags
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+// softAuthenticator is a software passkey: an ES256 key pair answering the ceremonies like a platform authenticator
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	origin       string
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatalf("generate credential ID: %v", err)
+	}
+	return &softAuthenticator{key: key, credentialID: credentialID, origin: testOrigin}
+}
+
+// create answers navigator.credentials.create with a "none" attestation
+func (a *softAuthenticator) create(t *testing.T, options any) json.RawMessage {
+	t.Helper()
+
+	creation, ok := options.(*protocol.CredentialCreation)
+	if !ok {
+		t.Fatalf("unexpected registration options %T", options)
+	}
+	switch id := creation.Response.User.ID.(type) {
+	case protocol.URLEncodedBase64:
+		a.userHandle = id
+	case []byte:
+		a.userHandle = id
+	default:
+		t.Fatalf("unexpected user handle %T", id)
+	}
+
+	publicKey, err := webauthncbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	attested := make([]byte, 16, 16+2+len(a.credentialID)+len(publicKey)) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authenticatorData(flagUserPresent|flagUserVerified|flagAttested, attested),
+	})
+	if err != nil {
+		t.Fatalf("marshal attestation object: %v", err)
+	}
+
+	return a.credential(t, map[string]any{
+		"clientDataJSON":    a.clientData(t, protocol.CreateCeremony, creation.Response.Challenge),
+		"attestationObject": b64(attestationObject),
+	})
+}
+
+// get answers navigator.credentials.get with an assertion signed by the key
+func (a *softAuthenticator) get(t *testing.T, options any) json.RawMessage {
+	t.Helper()
+
+	assertion, ok := options.(*protocol.CredentialAssertion)
+	if !ok {
+		t.Fatalf("unexpected login options %T", options)
+	}
+
+	authData := a.authenticatorData(flagUserPresent|flagUserVerified, nil)
+	clientData := a.clientData(t, protocol.AssertCeremony, assertion.Response.Challenge)
+	clientDataJSON, _ := base64.RawURLEncoding.DecodeString(clientData)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("sign assertion: %v", err)
+	}
+
+	return a.credential(t, map[string]any{
+		"clientDataJSON":    clientData,
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) authenticatorData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremony protocol.CeremonyType, challenge protocol.URLEncodedBase64) string {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": challenge.String(),
+		"origin":    a.origin,
+	})
+	if err != nil {
+		t.Fatalf("marshal client data: %v", err)
+	}
+	return b64(data)
+}
+
+func (a *softAuthenticator) credential(t *testing.T, response map[string]any) json.RawMessage {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("marshal credential: %v", err)
+	}
+	return data
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func newTestPasskeyService(t *testing.T) (*PasskeyService, *gorm.DB) {
+	t.Helper()
+
+	db := newTestDB(t)
+	service := NewPasskeyService(db, config.WebAuthn{
+		RPID:          testRPID,
+		RPDisplayName: "Knowstack",
+		RPOrigins:     []string{testOrigin},
+	}, config.Auth{}, NewPolicies())
+	return service, db
+}
+
+// registerPasskey runs the registration ceremony of the authenticator for the user
+func registerPasskey(t *testing.T, service *PasskeyService, userID uint, authenticator *softAuthenticator) (*dto.PasskeyResponse, error) {
+	t.Helper()
+
+	begin, err := service.BeginRegistration(userID)
+	if err != nil {
+		t.Fatalf("begin registration: %v", err)
+	}
+	return service.FinishRegistration(userID, dto.FinishPasskeyRegistrationRequest{
+		SessionKey: begin.SessionKey,
+		Name:       "Laptop",
+		Credential: authenticator.create(t, begin.Options),
+	})
+}
+
+// loginWithPasskey runs the login ceremony of the authenticator and returns the session key it used
+func loginWithPasskey(t *testing.T, service *PasskeyService, authenticator *softAuthenticator) (string, json.RawMessage, *dto.LoginResponse, error) {
+	t.Helper()
+
+	begin, err := service.BeginLogin()
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	credential := authenticator.get(t, begin.Options)
+	res, err := service.FinishLogin(dto.FinishPasskeyLoginRequest{
+		SessionKey: begin.SessionKey,
+		Credential: credential,
+	}, dto.SessionInfo{IPAddress: "127.0.0.1", UserAgent: "test"})
+	return begin.SessionKey, credential, res, err
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	service, db := newTestPasskeyService(t)
+	user := createTestUser(t, db, "alice")
+	authenticator := newSoftAuthenticator(t)
+
+	passkey, err := registerPasskey(t, service, user.ID, authenticator)
+	if err != nil {
+		t.Fatalf("FinishRegistration() error = %v", err)
+	}
+	if passkey.Name != "Laptop" {
+		t.Errorf("passkey name = %q, want %q", passkey.Name, "Laptop")
+	}
+
+	var stored models.PasskeyCredential
+	if err := db.First(&stored, passkey.ID).Error; err != nil {
+		t.Fatalf("find passkey: %v", err)
+	}
+	if string(stored.CredentialID) != string(authenticator.credentialID) || stored.UserID != user.ID {
+		t.Fatalf("stored passkey = %+v, want credential of user %d", stored, user.ID)
+	}
+
+	authenticator.signCount = 1
+	_, _, res, err := loginWithPasskey(t, service, authenticator)
+	if err != nil {
+		t.Fatalf("FinishLogin() error = %v", err)
+	}
+	if res.AccessToken == "" || res.RefreshToken == "" {
+		t.Fatalf("FinishLogin() = %+v, want tokens", res)
+	}
+
+	if err := db.First(&stored, passkey.ID).Error; err != nil {
+		t.Fatalf("find passkey: %v", err)
+	}
+	if stored.SignCount != 1 || stored.LastUsedAt == nil {
+		t.Errorf("passkey after login: sign count %d, last used %v, want 1 and set", stored.SignCount, stored.LastUsedAt)
+	}
+}
+
+func TestPasskeyRegistrationRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(authenticator *softAuthenticator)
+		// finishAs finishes the ceremony as another user when set
+		finishAs bool
+		wantErr  error
+	}{
+		{
+			name:    "foreign origin",
+			prepare: func(a *softAuthenticator) { a.origin = "https://evil.example.com" },
+			wantErr: ErrPasskeyVerification,
+		},
+		{
+			name:     "ceremony of another user",
+			finishAs: true,
+			wantErr:  ErrInvalidPasskeyChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, db := newTestPasskeyService(t)
+			user := createTestUser(t, db, "alice")
+			other := createTestUser(t, db, "bob")
+			authenticator := newSoftAuthenticator(t)
+			if tt.prepare != nil {
+				tt.prepare(authenticator)
+			}
+
+			begin, err := service.BeginRegistration(user.ID)
+			if err != nil {
+				t.Fatalf("begin registration: %v", err)
+			}
+			finishingUser := user.ID
+			if tt.finishAs {
+				finishingUser = other.ID
+			}
+			_, err = service.FinishRegistration(finishingUser, dto.FinishPasskeyRegistrationRequest{
+				SessionKey: begin.SessionKey,
+				Credential: authenticator.create(t, begin.Options),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FinishRegistration() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var count int64
+			db.Model(&models.PasskeyCredential{}).Count(&count)
+			if count != 0 {
+				t.Errorf("stored passkeys = %d, want 0", count)
+			}
+		})
+	}
+}
+
+func TestPasskeyLoginRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(authenticator *softAuthenticator)
+		wantErr error
+	}{
+		{
+			name: "signed by another key",
+			prepare: func(a *softAuthenticator) {
+				impostor, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				a.key = impostor
+			},
+			wantErr: ErrPasskeyVerification,
+		},
+		{
+			name:    "foreign origin",
+			prepare: func(a *softAuthenticator) { a.origin = "https://evil.example.com" },
+			wantErr: ErrPasskeyVerification,
+		},
+		{
+			// A sign count that does not increase indicates a cloned authenticator
+			name:    "sign count went backwards",
+			prepare: func(a *softAuthenticator) { a.signCount = 1 },
+			wantErr: ErrPasskeyVerification,
+		},
+		{
+			name:    "unknown user handle",
+			prepare: func(a *softAuthenticator) { a.userHandle = []byte("unknown") },
+			wantErr: ErrPasskeyVerification,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, db := newTestPasskeyService(t)
+			user := createTestUser(t, db, "alice")
+			authenticator := newSoftAuthenticator(t)
+			authenticator.signCount = 5
+			if _, err := registerPasskey(t, service, user.ID, authenticator); err != nil {
+				t.Fatalf("FinishRegistration() error = %v", err)
+			}
+
+			authenticator.signCount = 6
+			tt.prepare(authenticator)
+			_, _, res, err := loginWithPasskey(t, service, authenticator)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FinishLogin() = %+v, %v, want error %v", res, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasskeyLoginChallengeIsSingleUse(t *testing.T) {
+	service, db := newTestPasskeyService(t)
+	user := createTestUser(t, db, "alice")
+	authenticator := newSoftAuthenticator(t)
+	if _, err := registerPasskey(t, service, user.ID, authenticator); err != nil {
+		t.Fatalf("FinishRegistration() error = %v", err)
+	}
+
+	authenticator.signCount = 1
+	sessionKey, credential, _, err := loginWithPasskey(t, service, authenticator)
+	if err != nil {
+		t.Fatalf("FinishLogin() error = %v", err)
+	}
+
+	_, err = service.FinishLogin(dto.FinishPasskeyLoginRequest{
+		SessionKey: sessionKey,
+		Credential: credential,
+	}, dto.SessionInfo{IPAddress: "127.0.0.1"})
+	if !errors.Is(err, ErrInvalidPasskeyChallenge) {
+		t.Fatalf("replayed FinishLogin() error = %v, want %v", err, ErrInvalidPasskeyChallenge)
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCurrentPassword = errors.New("invalid current password")
+
+// ChangePassword sets a new password for the authenticated user and signs out every other session.
+// Users without a password, e.g. OAuth-only accounts, can set their first password without the current one.
+func (s *UserService) ChangePassword(userID uint, sessionID string, req dto.ChangePasswordRequest, client dto.SessionInfo) (*dto.ChangePasswordResponse, error) {
+	utils.LogInfo("Changing password", "userID", userID)
+
+	var user models.User
+	if err := s.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+
+	firstPassword := user.Password == ""
+	// recordLoginFailure resets the failure count when it locks, so a locked account must not be guessed on
+	if !firstPassword && user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		utils.LogInfo("Password change on locked account", "userID", user.ID)
+		return nil, ErrTooManyLoginAttempts
+	}
+	if !firstPassword && !utils.VerifyPassword(req.CurrentPassword, user.Password) {
+		// A stolen access token must not allow guessing the password without limits
+		utils.LogInfo("Invalid current password", "userID", user.ID)
+		failures := s.recordLoginFailure(&user, client.IPAddress)
+		time.Sleep(s.loginDelay(failures))
+		return nil, ErrInvalidCurrentPassword
+	}
+
+	var revoked int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.PasswordPolicy.SetPassword(tx, &user, req.NewPassword); err != nil {
+			return err
+		}
+
+		var err error
+		revoked, err = revokeRefreshTokens(tx, s.TokenDenylist, "user_id = ? AND family_id <> ?", user.ID, sessionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogInfo("Password changed", "userID", user.ID, "revokedSessions", revoked)
+
+	if err := s.sendPasswordChangedEmail(&user, firstPassword, client); err != nil {
+		utils.LogErrorWithErr("Failed to send password changed email", err, "userID", user.ID)
+	}
+
+	return &dto.ChangePasswordResponse{IsSuccess: true, RevokedSessions: revoked}, nil
+}
+
+// sendPasswordChangedEmail notifies the user, so an unexpected change can be noticed
+func (s *UserService) sendPasswordChangedEmail(user *models.User, firstPassword bool, client dto.SessionInfo) error {
+	subject := "Your password has been changed"
+	body := "The password of your account was changed"
+	if firstPassword {
+		subject = "A password has been set for your account"
+		body = "A password was set for your account, you can now also log in with your email and password"
+	}
+	body += " from " + client.IPAddress + " at " + time.Now().UTC().Format(time.RFC1123) + ".\n" +
+		"Your other sessions were signed out. If this wasn't you, reset your password immediately."
+
+	return utils.SendEmailWithContext(context.Background(), user.Email, subject, body, false)
+}
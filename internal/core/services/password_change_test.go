@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"testing"
+	"time"
+)
+
+func TestPasswordConfirmationOnLockedAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		confirm func(s *UserService, userID uint, password string) error
+	}{
+		{
+			name: "change password",
+			confirm: func(s *UserService, userID uint, password string) error {
+				_, err := s.ChangePassword(userID, "", dto.ChangePasswordRequest{CurrentPassword: password, NewPassword: "NewPassword456!"}, dto.SessionInfo{})
+				return err
+			},
+		},
+		{
+			name: "request account deletion",
+			confirm: func(s *UserService, userID uint, password string) error {
+				_, err := s.RequestAccountDeletion(userID, dto.RequestAccountDeletionRequest{Password: password}, dto.SessionInfo{})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			user := createTestUser(t, db, "alice")
+			service := NewUserService(db, config.Auth{
+				LoginThrottle: config.LoginThrottle{
+					MaxAccountFailures: 2,
+					LockoutDuration:    time.Hour,
+					BaseDelay:          time.Millisecond,
+					MaxDelay:           time.Millisecond,
+				},
+				AccountDeletion: config.AccountDeletion{GracePeriod: time.Hour},
+			}, NewMemoryTokenDenylist())
+
+			for range 2 {
+				if err := tt.confirm(service, user.ID, "wrong-password"); !errors.Is(err, ErrInvalidCurrentPassword) {
+					t.Fatalf("wrong password error = %v, want %v", err, ErrInvalidCurrentPassword)
+				}
+			}
+
+			// The correct password isn't accepted, nor are further guesses counted, while locked
+			if err := tt.confirm(service, user.ID, "Password123!"); !errors.Is(err, ErrTooManyLoginAttempts) {
+				t.Fatalf("correct password on locked account error = %v, want %v", err, ErrTooManyLoginAttempts)
+			}
+
+			db.Model(user).Update("locked_until", nil)
+			if err := tt.confirm(service, user.ID, "Password123!"); err != nil {
+				t.Fatalf("correct password after the lockout error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Password policy rules, used as the validation tags of the password field
+const (
+	PasswordRuleLength    = "password_length"
+	PasswordRuleUppercase = "password_uppercase"
+	PasswordRuleLowercase = "password_lowercase"
+	PasswordRuleDigit     = "password_digit"
+	PasswordRuleSymbol    = "password_symbol"
+	PasswordRuleIdentity  = "password_identity"
+	PasswordRuleBreached  = "password_breached"
+	PasswordRuleReused    = "password_reused"
+)
+
+// minIdentityLength is the shortest username or email part that is looked for in passwords
+const minIdentityLength = 3
+
+// PasswordPolicyError is returned when a password violates a rule of the policy
+type PasswordPolicyError struct {
+	Rule string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password violates the policy: " + e.Rule
+}
+
+type PasswordPolicy struct {
+	Config   config.PasswordPolicy
+	breached *breachedPasswordCorpus
+}
+
+func NewPasswordPolicy(cfg config.PasswordPolicy) *PasswordPolicy {
+	policy := &PasswordPolicy{Config: cfg}
+	if cfg.BreachedCorpusPath != "" {
+		if _, err := os.Stat(cfg.BreachedCorpusPath); err != nil {
+			utils.LogWarn("Breached password corpus is not readable", "path", cfg.BreachedCorpusPath, "error", err)
+		}
+		policy.breached = newBreachedPasswordCorpus(cfg.BreachedCorpusPath)
+	}
+	return policy
+}
+
+// Check validates the password against the rules that don't need the password history
+func (p *PasswordPolicy) Check(password, username, email string) error {
+	if utf8.RuneCountInString(password) < p.Config.MinLength {
+		return &PasswordPolicyError{Rule: PasswordRuleLength}
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.Config.RequireUppercase && !hasUpper {
+		return &PasswordPolicyError{Rule: PasswordRuleUppercase}
+	}
+	if p.Config.RequireLowercase && !hasLower {
+		return &PasswordPolicyError{Rule: PasswordRuleLowercase}
+	}
+	if p.Config.RequireDigit && !hasDigit {
+		return &PasswordPolicyError{Rule: PasswordRuleDigit}
+	}
+	if p.Config.RequireSymbol && !hasSymbol {
+		return &PasswordPolicyError{Rule: PasswordRuleSymbol}
+	}
+
+	if p.Config.RejectIdentity && containsIdentity(password, username, email) {
+		return &PasswordPolicyError{Rule: PasswordRuleIdentity}
+	}
+
+	if p.breached != nil {
+		// The corpus is an extra safeguard, an unreadable corpus doesn't block password changes
+		breached, err := p.breached.Contains(password)
+		if err != nil {
+			utils.LogErrorWithErr("Failed to check breached password corpus", err)
+		} else if breached {
+			return &PasswordPolicyError{Rule: PasswordRuleBreached}
+		}
+	}
+
+	return nil
+}
+
+// SetPassword checks the new password against the policy and the password history of the user,
+// then updates it and keeps the replaced hash in the history
+func (p *PasswordPolicy) SetPassword(tx *gorm.DB, user *models.User, password string) error {
+	if err := p.Check(password, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	if p.Config.HistorySize > 0 {
+		if user.Password != "" && utils.VerifyPassword(password, user.Password) {
+			return &PasswordPolicyError{Rule: PasswordRuleReused}
+		}
+
+		// The current password counts as one of the remembered passwords
+		var history []models.PasswordHistory
+		if err := tx.Where("user_id = ?", user.ID).
+			Order("created_at DESC").
+			Limit(p.Config.HistorySize - 1).
+			Find(&history).Error; err != nil {
+			utils.LogErrorWithErr("Failed to find password history", err, "userID", user.ID)
+			return err
+		}
+		for _, entry := range history {
+			if utils.VerifyPassword(password, entry.PasswordHash) {
+				return &PasswordPolicyError{Rule: PasswordRuleReused}
+			}
+		}
+
+		if user.Password != "" {
+			if err := tx.Create(&models.PasswordHistory{UserID: user.ID, PasswordHash: user.Password}).Error; err != nil {
+				utils.LogErrorWithErr("Failed to save password history", err, "userID", user.ID)
+				return err
+			}
+		}
+		if err := p.pruneHistory(tx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	// Password is hashed by the User.BeforeUpdate hook, a new password satisfies a forced reset
+	if err := tx.Model(user).Updates(map[string]any{"password": password, "password_reset_required": false}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to update password", err, "userID", user.ID)
+		return err
+	}
+
+	return nil
+}
+
+// pruneHistory removes the history entries that are no longer checked
+func (p *PasswordPolicy) pruneHistory(tx *gorm.DB, userID uint) error {
+	var keep []uint
+	if err := tx.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(p.Config.HistorySize-1).
+		Pluck("id", &keep).Error; err != nil {
+		utils.LogErrorWithErr("Failed to find password history", err, "userID", userID)
+		return err
+	}
+
+	query := tx.Where("user_id = ?", userID)
+	if len(keep) > 0 {
+		query = query.Where("id NOT IN ?", keep)
+	}
+	if err := query.Delete(&models.PasswordHistory{}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to prune password history", err, "userID", userID)
+		return err
+	}
+	return nil
+}
+
+// containsIdentity reports whether the password contains the username or the local part of the email
+func containsIdentity(password, username, email string) bool {
+	lowered := strings.ToLower(password)
+
+	identities := []string{username}
+	if local, _, ok := strings.Cut(email, "@"); ok {
+		identities = append(identities, local)
+	}
+
+	for _, identity := range identities {
+		identity = strings.ToLower(identity)
+		if utf8.RuneCountInString(identity) >= minIdentityLength && strings.Contains(lowered, identity) {
+			return true
+		}
+	}
+	return false
+}
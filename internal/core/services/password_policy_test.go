@@ -0,0 +1,135 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// assertPasswordRule checks that err is a policy error of the rule, or nil for an empty rule
+func assertPasswordRule(t *testing.T, err error, rule string) {
+	t.Helper()
+
+	if rule == "" {
+		if err != nil {
+			t.Fatalf("error = %v, want nil", err)
+		}
+		return
+	}
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) || policyErr.Rule != rule {
+		t.Fatalf("error = %v, want the %s rule", err, rule)
+	}
+}
+
+func TestPasswordPolicyLength(t *testing.T) {
+	tests := []struct {
+		name      string
+		minLength int
+		password  string
+		rule      string
+	}{
+		{"shorter than a long minimum", 12, "Tr0ub4dor&3", PasswordRuleLength},
+		{"at a long minimum", 12, "Tr0ub4dor&3x", ""},
+		{"below the default minimum", 4, "abcdef", ""},
+		{"counts characters, not bytes", 6, "şifreğ", ""},
+		{"shorter than a low minimum", 4, "abc", PasswordRuleLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewPasswordPolicy(config.PasswordPolicy{MinLength: tt.minLength})
+			assertPasswordRule(t, policy.Check(tt.password, "alice", "alice@example.com"), tt.rule)
+		})
+	}
+}
+
+func TestPasswordPolicyHistory(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	policy := NewPasswordPolicy(config.PasswordPolicy{MinLength: 8, HistorySize: 3})
+
+	setPassword := func(password string) error {
+		var current models.User
+		if err := db.First(&current, user.ID).Error; err != nil {
+			t.Fatalf("find user: %v", err)
+		}
+		return db.Transaction(func(tx *gorm.DB) error {
+			return policy.SetPassword(tx, &current, password)
+		})
+	}
+
+	// The current password is one of the three remembered ones
+	assertPasswordRule(t, setPassword("Password123!"), PasswordRuleReused)
+	assertPasswordRule(t, setPassword("Second456!"), "")
+	assertPasswordRule(t, setPassword("Third789!"), "")
+	assertPasswordRule(t, setPassword("Password123!"), PasswordRuleReused)
+	assertPasswordRule(t, setPassword("Second456!"), PasswordRuleReused)
+
+	// A fourth password pushes the first one out of the history
+	assertPasswordRule(t, setPassword("Fourth012!"), "")
+	assertPasswordRule(t, setPassword("Password123!"), "")
+
+	var entries int64
+	db.Model(&models.PasswordHistory{}).Where("user_id = ?", user.ID).Count(&entries)
+	if entries != 2 {
+		t.Errorf("history entries = %d, want 2", entries)
+	}
+}
+
+// breachedHash returns the uppercase SHA-1 hash of the password as in the Pwned Passwords corpus
+func breachedHash(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestPasswordPolicyBreachedCorpus(t *testing.T) {
+	breached := "Summer2024!"
+	padded := "Winter2024!"
+
+	singleFile := filepath.Join(t.TempDir(), "pwned.txt")
+	lines := breachedHash(breached) + ":42\n" + strings.ToLower(breachedHash(padded)) + ":0\n"
+	if err := os.WriteFile(singleFile, []byte(lines), 0o600); err != nil {
+		t.Fatalf("write corpus: %v", err)
+	}
+
+	rangeDir := t.TempDir()
+	for _, password := range []string{breached, padded} {
+		hash := breachedHash(password)
+		count := "42"
+		if password == padded {
+			count = "0"
+		}
+		name := filepath.Join(rangeDir, hash[:5]+".txt")
+		file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			t.Fatalf("open range file: %v", err)
+		}
+		if _, err := file.WriteString(hash[5:] + ":" + count + "\r\n"); err != nil {
+			t.Fatalf("write range file: %v", err)
+		}
+		file.Close()
+	}
+
+	for name, path := range map[string]string{"single file": singleFile, "range directory": rangeDir} {
+		t.Run(name, func(t *testing.T) {
+			policy := NewPasswordPolicy(config.PasswordPolicy{MinLength: 8, BreachedCorpusPath: path})
+
+			assertPasswordRule(t, policy.Check(breached, "alice", "alice@example.com"), PasswordRuleBreached)
+			assertPasswordRule(t, policy.Check(padded, "alice", "alice@example.com"), "")
+			assertPasswordRule(t, policy.Check("Unlisted-Passw0rd", "alice", "alice@example.com"), "")
+		})
+	}
+
+	// An unreadable corpus doesn't block the password
+	policy := NewPasswordPolicy(config.PasswordPolicy{MinLength: 8, BreachedCorpusPath: filepath.Join(rangeDir, "missing", "corpus.txt")})
+	assertPasswordRule(t, policy.Check(breached, "alice", "alice@example.com"), "")
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
+	ErrPersonalAccessTokenExpired  = errors.New("personal access token expired")
+	ErrInvalidTokenScope           = errors.New("scope is unknown or not granted to the user")
+)
+
+// personalAccessTokenTouchInterval limits how often last_used_at is written for a busy token
+const personalAccessTokenTouchInterval = time.Minute
+
+type PersonalAccessTokenService struct {
+	DB       *gorm.DB
+	Policies *authz.Policies
+}
+
+func NewPersonalAccessTokenService(db *gorm.DB, policies *authz.Policies) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{DB: db, Policies: policies}
+}
+
+// CreateToken creates a token limited to the requested scopes. The scopes must be a subset
+// of the caller's claims, so a token can never grant more than the request creating it had.
+func (s *PersonalAccessTokenService) CreateToken(userID uint, callerClaims []string, req dto.CreatePersonalAccessTokenRequest) (*dto.CreatePersonalAccessTokenResponse, error) {
+	utils.LogInfo("Creating personal access token", "userID", userID, "name", req.Name)
+
+	granted := authz.NewClaimSet(callerClaims)
+	for _, scope := range req.Scopes {
+		if !granted.Has(scope) {
+			utils.LogInfo("Scope not granted to the user", "userID", userID, "scope", scope)
+			return nil, ErrInvalidTokenScope
+		}
+	}
+
+	scopes := make(map[string]struct{}, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scopes[scope] = struct{}{}
+	}
+
+	var claims []models.Claim
+	if err := s.DB.Where("name IN ?", req.Scopes).Find(&claims).Error; err != nil {
+		utils.LogErrorWithErr("Failed to find claims", err)
+		return nil, err
+	}
+	// Every scope must be a known claim, a token is never created with fewer scopes than requested
+	if len(claims) == 0 || len(claims) != len(scopes) {
+		utils.LogInfo("Unknown scope requested", "userID", userID, "scopes", req.Scopes)
+		return nil, ErrInvalidTokenScope
+	}
+
+	token, err := utils.GeneratePersonalAccessToken()
+	if err != nil {
+		utils.LogErrorWithErr("Failed to generate personal access token", err)
+		return nil, err
+	}
+
+	record := models.PersonalAccessToken{
+		Name:      req.Name,
+		TokenHash: utils.HashToken(token),
+		Prefix:    token[:len(utils.PersonalAccessTokenPrefix)+4],
+		Claims:    claims,
+		ExpiresAt: time.Now().AddDate(0, 0, req.ExpiresInDays),
+		UserID:    userID,
+	}
+	if err := s.DB.Create(&record).Error; err != nil {
+		utils.LogErrorWithErr("Failed to create personal access token", err)
+		return nil, err
+	}
+
+	return &dto.CreatePersonalAccessTokenResponse{
+		PersonalAccessTokenResponse: personalAccessTokenResponse(&record),
+		Token:                       token,
+	}, nil
+}
+
+// ListTokens returns the personal access tokens of the user without the secrets
+func (s *PersonalAccessTokenService) ListTokens(subject authz.Subject) ([]dto.PersonalAccessTokenResponse, error) {
+	utils.LogInfo("Listing personal access tokens", "userID", subject.UserID)
+
+	var tokens []models.PersonalAccessToken
+	if err := s.DB.Preload("Claims").Where("user_id = ?", subject.UserID).Order("created_at").Find(&tokens).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list personal access tokens", err)
+		return nil, err
+	}
+
+	response := make([]dto.PersonalAccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		if s.Policies.Can(subject, authz.ActionRead, authz.Resource{Type: ResourcePersonalAccessToken, ID: tokens[i].ID, OwnerID: tokens[i].UserID}) {
+			response = append(response, personalAccessTokenResponse(&tokens[i]))
+		}
+	}
+
+	return response, nil
+}
+
+// DeleteToken revokes a personal access token of the user, it stops working immediately
+func (s *PersonalAccessTokenService) DeleteToken(subject authz.Subject, tokenID uint) (*dto.DeletePersonalAccessTokenResponse, error) {
+	utils.LogInfo("Deleting personal access token", "userID", subject.UserID, "tokenID", tokenID)
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var token models.PersonalAccessToken
+		if err := tx.Where("id = ?", tokenID).First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPersonalAccessTokenNotFound
+			}
+			utils.LogErrorWithErr("Failed to find personal access token", err)
+			return err
+		}
+		resource := authz.Resource{Type: ResourcePersonalAccessToken, ID: token.ID, OwnerID: token.UserID}
+		// A record the subject can't read is reported as missing so its ID doesn't leak
+		if !s.Policies.Can(subject, authz.ActionRead, resource) {
+			return ErrPersonalAccessTokenNotFound
+		}
+		if err := s.Policies.Authorize(subject, authz.ActionDelete, resource); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&token).Association("Claims").Clear(); err != nil {
+			utils.LogErrorWithErr("Failed to clear personal access token scopes", err)
+			return err
+		}
+		if err := tx.Delete(&token).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete personal access token", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.DeletePersonalAccessTokenResponse{IsSuccess: true}, nil
+}
+
+// VerifyPersonalAccessToken authenticates a request made with a personal access token.
+// The returned claims hold the token scopes that the user still has, so removing a claim
+// from the user or the role also removes it from every token of the user.
+func (s *PersonalAccessTokenService) VerifyPersonalAccessToken(token string) (*utils.TokenClaims, error) {
+	var record models.PersonalAccessToken
+	if err := s.DB.
+		Preload("Claims").
+		Preload("User.Role.Claims").
+		Preload("User.Claims").
+		Where("token_hash = ?", utils.HashToken(token)).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPersonalAccessTokenNotFound
+		}
+		utils.LogErrorWithErr("Failed to find personal access token", err)
+		return nil, err
+	}
+
+	if record.ExpiresAt.Before(time.Now()) {
+		utils.LogInfo("Personal access token expired", "tokenID", record.ID)
+		return nil, ErrPersonalAccessTokenExpired
+	}
+	if err := checkLoginAllowed(&record.User); err != nil {
+		utils.LogInfo("Personal access token of blocked account", "tokenID", record.ID)
+		return nil, err
+	}
+
+	userClaims := authz.NewClaimSet(grantedClaimNames(&record.User))
+	scopes := make([]string, 0, len(record.Claims))
+	for _, claim := range record.Claims {
+		if userClaims.Has(claim.Name) {
+			scopes = append(scopes, claim.Name)
+		}
+	}
+
+	now := time.Now()
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) > personalAccessTokenTouchInterval {
+		if err := s.DB.Model(&record).UpdateColumn("last_used_at", now).Error; err != nil {
+			utils.LogErrorWithErr("Failed to update personal access token last use", err, "tokenID", record.ID)
+		}
+	}
+
+	userID := strconv.FormatUint(uint64(record.UserID), 10)
+	return &utils.TokenClaims{
+		UserID:                userID,
+		Email:                 record.User.Email,
+		Username:              record.User.Username,
+		RoleID:                record.User.RoleID,
+		Claims:                scopes,
+		PersonalAccessTokenID: record.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(record.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(record.CreatedAt),
+		},
+	}, nil
+}
+
+func personalAccessTokenResponse(token *models.PersonalAccessToken) dto.PersonalAccessTokenResponse {
+	scopes := make([]string, len(token.Claims))
+	for i, claim := range token.Claims {
+		scopes[i] = claim.Name
+	}
+	return dto.PersonalAccessTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
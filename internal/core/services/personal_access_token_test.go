@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/data/models"
+	"testing"
+)
+
+func TestCreateTokenScopes(t *testing.T) {
+	tests := []struct {
+		name         string
+		callerClaims []string
+		scopes       []string
+		wantErr      error
+		wantScopes   int
+	}{
+		{name: "held scopes", callerClaims: []string{"user:read", "role:read"}, scopes: []string{"user:read", "role:read"}, wantScopes: 2},
+		{name: "duplicate scope", callerClaims: []string{"user:read"}, scopes: []string{"user:read", "user:read"}, wantScopes: 1},
+		{name: "scope implied by a wildcard", callerClaims: []string{"user:*"}, scopes: []string{"user:read"}, wantScopes: 1},
+		{name: "scope not held", callerClaims: []string{"user:read"}, scopes: []string{"role:read"}, wantErr: ErrInvalidTokenScope},
+		{name: "unknown scope", callerClaims: []string{"*"}, scopes: []string{"user:unknown"}, wantErr: ErrInvalidTokenScope},
+		{name: "unknown scope among known ones", callerClaims: []string{"*"}, scopes: []string{"user:read", "user:unknown"}, wantErr: ErrInvalidTokenScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			user := createTestUser(t, db, "alice")
+			for _, name := range []string{"user:read", "role:read"} {
+				if err := db.Create(&models.Claim{Name: name}).Error; err != nil {
+					t.Fatalf("create claim: %v", err)
+				}
+			}
+			service := NewPersonalAccessTokenService(db, NewPolicies())
+
+			res, err := service.CreateToken(user.ID, tt.callerClaims, dto.CreatePersonalAccessTokenRequest{
+				Name:          "ci",
+				Scopes:        tt.scopes,
+				ExpiresInDays: 30,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				var tokens int64
+				db.Model(&models.PersonalAccessToken{}).Count(&tokens)
+				if tokens != 0 {
+					t.Errorf("tokens = %d, want 0", tokens)
+				}
+				return
+			}
+			if len(res.Scopes) != tt.wantScopes {
+				t.Errorf("scopes = %v, want %d", res.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestDeleteTokenOfAnotherUser(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	if err := db.Create(&models.Claim{Name: "user:read"}).Error; err != nil {
+		t.Fatalf("create claim: %v", err)
+	}
+	service := NewPersonalAccessTokenService(db, NewPolicies())
+
+	token, err := service.CreateToken(alice.ID, []string{"*"}, dto.CreatePersonalAccessTokenRequest{Name: "ci", Scopes: []string{"user:read"}, ExpiresInDays: 30})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	// Another user can't tell the token exists
+	if _, err := service.DeleteToken(authz.Subject{UserID: bob.ID}, token.ID); !errors.Is(err, ErrPersonalAccessTokenNotFound) {
+		t.Errorf("DeleteToken() by another user error = %v, want %v", err, ErrPersonalAccessTokenNotFound)
+	}
+	// An admin impersonating the owner sees the token but can't delete it
+	impersonated := authz.Subject{UserID: alice.ID, Attributes: map[string]any{"impersonated": true}}
+	if _, err := service.DeleteToken(impersonated, token.ID); !errors.Is(err, authz.ErrDenied) {
+		t.Errorf("DeleteToken() while impersonated error = %v, want %v", err, authz.ErrDenied)
+	}
+
+	if tokens, err := service.ListTokens(authz.Subject{UserID: bob.ID}); err != nil || len(tokens) != 0 {
+		t.Errorf("ListTokens() of another user = %v, %v, want none", tokens, err)
+	}
+	if tokens, err := service.ListTokens(authz.Subject{UserID: alice.ID}); err != nil || len(tokens) != 1 {
+		t.Fatalf("ListTokens() of the owner = %v, %v, want the token", tokens, err)
+	}
+
+	if _, err := service.DeleteToken(authz.Subject{UserID: alice.ID}, token.ID); err != nil {
+		t.Fatalf("DeleteToken() by the owner error = %v", err)
+	}
+	if tokens, _ := service.ListTokens(authz.Subject{UserID: alice.ID}); len(tokens) != 0 {
+		t.Errorf("ListTokens() after deletion = %v, want none", tokens)
+	}
+}
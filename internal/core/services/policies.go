@@ -0,0 +1,64 @@
+package services
+
+import "knowstack/internal/core/authz"
+
+// Resource types of the policies
+const (
+	ResourcePersonalAccessToken = "personal_access_token"
+	ResourcePasskey             = "passkey"
+	ResourceIdentity            = "identity"
+	ResourceOrganization        = "organization"
+	ResourceOrganizationMember  = "organization_member"
+	// ResourceOrganizationInvitation holds the email of the invited user, only the member managers see it
+	ResourceOrganizationInvitation = "organization_invitation"
+)
+
+/*
+Build the policies of the resources the services authorize with authz.Policies.Authorize.
+Declare here who may act on a record instead of filtering the queries by user ID,
+a denied action is reported as authz.ErrDenied.
+*/
+func NewPolicies() *authz.Policies {
+	policies := authz.NewPolicies()
+
+	// The credentials of a user are only managed by the user itself, never by an impersonating admin.
+	// Records the user can't read are reported as missing by the services.
+	credentialOwner := authz.Every(
+		authz.Owner(),
+		authz.Not(authz.SubjectAttributeEquals("impersonated", true)),
+	)
+	for _, resourceType := range []string{ResourcePersonalAccessToken, ResourcePasskey, ResourceIdentity} {
+		policies.Register(resourceType, authz.Policy{
+			authz.ActionRead:   authz.Owner(),
+			authz.ActionDelete: credentialOwner,
+		})
+	}
+
+	// Organizations and their members are managed with the claims granted in the organization
+	policies.Register(ResourceOrganization, authz.Policy{
+		authz.ActionRead:   organizationClaim("organization:read"),
+		authz.ActionUpdate: organizationClaim("organization:update"),
+		authz.ActionDelete: organizationClaim("organization:delete"),
+	})
+	policies.Register(ResourceOrganizationMember, authz.Policy{
+		authz.ActionRead:   organizationClaim("organization:member:read"),
+		authz.ActionUpdate: organizationClaim("organization:member:write"),
+		// Every member may leave, removing someone else takes the claim
+		authz.ActionDelete: authz.Some(
+			authz.Every(authz.SameOrganization(), authz.Owner()),
+			organizationClaim("organization:member:delete"),
+		),
+	})
+	policies.Register(ResourceOrganizationInvitation, authz.Policy{
+		authz.ActionRead:   organizationClaim("organization:member:write"),
+		authz.ActionCreate: organizationClaim("organization:member:write"),
+		authz.ActionDelete: organizationClaim("organization:member:write"),
+	})
+
+	return policies
+}
+
+// organizationClaim allows the subjects holding the claim in the organization of the resource
+func organizationClaim(claim string) authz.Condition {
+	return authz.Every(authz.SameOrganization(), authz.Satisfies(authz.Claim(claim)))
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrRoleAlreadyExists    = errors.New("role already exists")
+	ErrRoleHasMembers       = errors.New("role still has members")
+	ErrDefaultRoleDeletion  = errors.New("default role can't be deleted")
+	ErrInvalidReassignRole  = errors.New("users can't be reassigned to the deleted role")
+	ErrReassignRoleNotFound = errors.New("replacement role not found")
+	ErrProtectedRole        = errors.New("organization roles can't be renamed or deleted")
+)
+
+type RoleService struct {
+	DB *gorm.DB
+}
+
+func NewRoleService(db *gorm.DB) *RoleService {
+	return &RoleService{DB: db}
+}
+
+func (s *RoleService) ListRoles() ([]dto.RoleResponse, error) {
+	utils.LogInfo("Listing roles")
+
+	var roles []models.Role
+	if err := s.DB.Preload("Claims").Order("id").Find(&roles).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list roles", err)
+		return nil, err
+	}
+
+	var counts []struct {
+		RoleID uint
+		Count  int64
+	}
+	if err := s.DB.Model(&models.User{}).
+		Select("role_id, COUNT(*) AS count").
+		Group("role_id").
+		Scan(&counts).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count role members", err)
+		return nil, err
+	}
+	members := make(map[uint]int64, len(counts))
+	for _, count := range counts {
+		members[count.RoleID] = count.Count
+	}
+
+	response := make([]dto.RoleResponse, len(roles))
+	for i := range roles {
+		response[i] = roleResponse(&roles[i], members[roles[i].ID])
+	}
+	return response, nil
+}
+
+func (s *RoleService) GetRole(roleID uint) (*dto.RoleResponse, error) {
+	utils.LogInfo("Getting role", "roleID", roleID)
+
+	role, err := s.findRole(s.DB.Preload("Claims"), roleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.roleResponseWithMembers(role)
+}
+
+// CreateRole creates a role, optionally with its initial claims the caller must hold
+func (s *RoleService) CreateRole(callerClaims []string, req dto.CreateRoleRequest) (*dto.RoleResponse, error) {
+	utils.LogInfo("Creating role", "name", req.Name)
+
+	if err := s.DB.Where("name = ?", req.Name).First(&models.Role{}).Error; err == nil {
+		utils.LogInfo("Role already exists", "name", req.Name)
+		return nil, ErrRoleAlreadyExists
+	}
+
+	role := models.Role{Name: req.Name, RequiresTwoFactor: req.RequiresTwoFactor}
+	if len(req.Claims) > 0 {
+		claims, err := s.findClaims(req.Claims)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkGrantable(callerClaims, claims); err != nil {
+			return nil, err
+		}
+		role.Claims = claims
+	}
+
+	if err := s.DB.Create(&role).Error; err != nil {
+		utils.LogErrorWithErr("Failed to create role", err)
+		return nil, err
+	}
+
+	response := roleResponse(&role, 0)
+	return &response, nil
+}
+
+// RenameRole changes the name of the role and, when given, whether it requires two-factor authentication
+// The organization roles keep their names.
+func (s *RoleService) RenameRole(roleID uint, req dto.UpdateRoleRequest) (*dto.RoleResponse, error) {
+	utils.LogInfo("Renaming role", "roleID", roleID, "name", req.Name)
+
+	role, err := s.findRole(s.DB.Preload("Claims"), roleID)
+	if err != nil {
+		return nil, err
+	}
+	if isProtectedRole(role.Name) && req.Name != role.Name {
+		return nil, ErrProtectedRole
+	}
+
+	if err := s.DB.Where("name = ? AND id != ?", req.Name, roleID).First(&models.Role{}).Error; err == nil {
+		utils.LogInfo("Role name already exists", "name", req.Name)
+		return nil, ErrRoleAlreadyExists
+	}
+
+	updates := map[string]any{"name": req.Name}
+	if req.RequiresTwoFactor != nil {
+		updates["requires_two_factor"] = *req.RequiresTwoFactor
+	}
+	if err := s.DB.Model(role).Updates(updates).Error; err != nil {
+		utils.LogErrorWithErr("Failed to rename role", err)
+		return nil, err
+	}
+	role.Name = req.Name
+	if req.RequiresTwoFactor != nil {
+		role.RequiresTwoFactor = *req.RequiresTwoFactor
+	}
+
+	return s.roleResponseWithMembers(role)
+}
+
+// SetDefaultRole makes the role the one assigned to new users
+func (s *RoleService) SetDefaultRole(roleID uint) (*dto.RoleResponse, error) {
+	utils.LogInfo("Setting default role", "roleID", roleID)
+
+	var role *models.Role
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		role, err = s.findRole(tx.Preload("Claims"), roleID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Role{}).Where("is_default = ? AND id != ?", true, roleID).Update("is_default", false).Error; err != nil {
+			utils.LogErrorWithErr("Failed to unset default role", err)
+			return err
+		}
+		if err := tx.Model(role).Update("is_default", true).Error; err != nil {
+			utils.LogErrorWithErr("Failed to set default role", err)
+			return err
+		}
+		role.IsDefault = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.roleResponseWithMembers(role)
+}
+
+// AttachClaims grants the claims to every member of the role, the caller must hold them.
+// Access tokens already issued keep their claims until they expire.
+func (s *RoleService) AttachClaims(callerClaims []string, roleID uint, req dto.RoleClaimsRequest) (*dto.RoleResponse, error) {
+	utils.LogInfo("Attaching claims to role", "roleID", roleID, "claims", req.Claims)
+
+	role, err := s.findRole(s.DB, roleID)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.findClaims(req.Claims)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGrantable(callerClaims, claims); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.Model(role).Association("Claims").Append(claims); err != nil {
+		utils.LogErrorWithErr("Failed to attach claims to role", err)
+		return nil, err
+	}
+
+	return s.GetRole(roleID)
+}
+
+// DetachClaims removes the claims from the role
+func (s *RoleService) DetachClaims(roleID uint, req dto.RoleClaimsRequest) (*dto.RoleResponse, error) {
+	utils.LogInfo("Detaching claims from role", "roleID", roleID, "claims", req.Claims)
+
+	role, err := s.findRole(s.DB, roleID)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.findClaims(req.Claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.Model(role).Association("Claims").Delete(claims); err != nil {
+		utils.LogErrorWithErr("Failed to detach claims from role", err)
+		return nil, err
+	}
+
+	return s.GetRole(roleID)
+}
+
+// DeleteRole deletes the role. A role with members, in the users or in the organizations, can only
+// be deleted when the members are reassigned to another role; the default role and the organization roles can't be deleted.
+func (s *RoleService) DeleteRole(roleID uint, reassignTo *uint) (*dto.DeleteRoleResponse, error) {
+	utils.LogInfo("Deleting role", "roleID", roleID, "reassignTo", reassignTo)
+
+	var reassigned, reassignedMemberships int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		role, err := s.findRole(tx.Clauses(clause.Locking{Strength: "UPDATE"}), roleID)
+		if err != nil {
+			return err
+		}
+		if role.IsDefault {
+			return ErrDefaultRoleDeletion
+		}
+		if isProtectedRole(role.Name) {
+			return ErrProtectedRole
+		}
+
+		if reassignTo != nil {
+			if *reassignTo == roleID {
+				return ErrInvalidReassignRole
+			}
+			if err := tx.Where("id = ?", *reassignTo).First(&models.Role{}).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrReassignRoleNotFound
+				}
+				utils.LogErrorWithErr("Failed to find role", err)
+				return err
+			}
+
+			result := tx.Model(&models.User{}).Where("role_id = ?", roleID).Update("role_id", *reassignTo)
+			if result.Error != nil {
+				utils.LogErrorWithErr("Failed to reassign role members", result.Error)
+				return result.Error
+			}
+			reassigned = result.RowsAffected
+
+			result = tx.Model(&models.OrganizationMembership{}).Where("role_id = ?", roleID).Update("role_id", *reassignTo)
+			if result.Error != nil {
+				utils.LogErrorWithErr("Failed to reassign organization members of role", result.Error)
+				return result.Error
+			}
+			reassignedMemberships = result.RowsAffected
+		} else {
+			var members, memberships int64
+			if err := tx.Model(&models.User{}).Where("role_id = ?", roleID).Count(&members).Error; err != nil {
+				utils.LogErrorWithErr("Failed to count role members", err)
+				return err
+			}
+			if err := tx.Model(&models.OrganizationMembership{}).Where("role_id = ?", roleID).Count(&memberships).Error; err != nil {
+				utils.LogErrorWithErr("Failed to count organization members of role", err)
+				return err
+			}
+			if members > 0 || memberships > 0 {
+				utils.LogInfo("Role still has members", "roleID", roleID, "members", members, "organizationMembers", memberships)
+				return ErrRoleHasMembers
+			}
+		}
+
+		if err := tx.Model(role).Association("Claims").Clear(); err != nil {
+			utils.LogErrorWithErr("Failed to clear role claims", err)
+			return err
+		}
+		if err := tx.Delete(role).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete role", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.DeleteRoleResponse{
+		IsSuccess:                     true,
+		ReassignedUsers:               reassigned,
+		ReassignedOrganizationMembers: reassignedMemberships,
+	}, nil
+}
+
+// isProtectedRole reports whether the organizations look the role up by its name, so it must keep it
+func isProtectedRole(name string) bool {
+	return name == OrganizationOwnerRole || name == OrganizationMemberRole
+}
+
+func (s *RoleService) findRole(db *gorm.DB, roleID uint) (*models.Role, error) {
+	var role models.Role
+	if err := db.Where("id = ?", roleID).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogInfo("Role not found", "roleID", roleID)
+			return nil, ErrRoleNotFound
+		}
+		utils.LogErrorWithErr("Failed to find role", err)
+		return nil, err
+	}
+	return &role, nil
+}
+
+// findClaims loads the claims by name, every name must exist
+func (s *RoleService) findClaims(names []string) ([]models.Claim, error) {
+	var claims []models.Claim
+	if err := s.DB.Where("name IN ?", names).Find(&claims).Error; err != nil {
+		utils.LogErrorWithErr("Failed to find claims", err)
+		return nil, err
+	}
+
+	found := make(map[string]struct{}, len(claims))
+	for _, claim := range claims {
+		found[claim.Name] = struct{}{}
+	}
+	for _, name := range names {
+		if _, ok := found[name]; !ok {
+			utils.LogInfo("Claim not found", "name", name)
+			return nil, ErrClaimNotFound
+		}
+	}
+	return claims, nil
+}
+
+func (s *RoleService) roleResponseWithMembers(role *models.Role) (*dto.RoleResponse, error) {
+	var members int64
+	if err := s.DB.Model(&models.User{}).Where("role_id = ?", role.ID).Count(&members).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count role members", err)
+		return nil, err
+	}
+	response := roleResponse(role, members)
+	return &response, nil
+}
+
+func roleResponse(role *models.Role, members int64) dto.RoleResponse {
+	return dto.RoleResponse{
+		ID:                role.ID,
+		Name:              role.Name,
+		IsDefault:         role.IsDefault,
+		RequiresTwoFactor: role.RequiresTwoFactor,
+		Claims:            claimNames(role.Claims),
+		MemberCount:       members,
+		CreatedAt:         role.CreatedAt,
+		UpdatedAt:         role.UpdatedAt,
+	}
+}
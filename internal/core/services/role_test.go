@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/data/models"
+	"testing"
+)
+
+func TestRoleClaimsMustBeGrantable(t *testing.T) {
+	tests := []struct {
+		name         string
+		callerClaims []string
+		claims       []string
+		wantErr      error
+	}{
+		{name: "held claim", callerClaims: []string{"role:update", "user:read"}, claims: []string{"user:read"}},
+		{name: "claim held through a wildcard", callerClaims: []string{"user:*"}, claims: []string{"user:impersonate"}},
+		{name: "claim not held", callerClaims: []string{"role:update"}, claims: []string{"user:impersonate"}, wantErr: ErrClaimNotGrantable},
+		{name: "wildcard not held", callerClaims: []string{"role:*", "user:*"}, claims: []string{"*"}, wantErr: ErrClaimNotGrantable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			for _, name := range []string{"*", "user:read", "user:impersonate"} {
+				if err := db.Create(&models.Claim{Name: name}).Error; err != nil {
+					t.Fatalf("create claim: %v", err)
+				}
+			}
+			service := NewRoleService(db)
+
+			_, err := service.CreateRole(tt.callerClaims, dto.CreateRoleRequest{Name: "support", Claims: tt.claims})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateRole() error = %v, want %v", err, tt.wantErr)
+			}
+
+			role := models.Role{Name: "existing"}
+			if err := db.Create(&role).Error; err != nil {
+				t.Fatalf("create role: %v", err)
+			}
+			_, err = service.AttachClaims(tt.callerClaims, role.ID, dto.RoleClaimsRequest{Claims: tt.claims})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AttachClaims() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if count := db.Model(&role).Association("Claims").Count(); count != 0 {
+					t.Errorf("claims of the role = %d, want 0", count)
+				}
+			}
+		})
+	}
+}
+
+func TestOrganizationRolesKeepTheirNames(t *testing.T) {
+	db := newTestDB(t)
+	service := NewRoleService(db)
+	if err := db.Create(&models.Role{Name: "user", IsDefault: true}).Error; err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+
+	for _, name := range []string{OrganizationOwnerRole, OrganizationMemberRole} {
+		role := models.Role{Name: name}
+		if err := db.Create(&role).Error; err != nil {
+			t.Fatalf("create role: %v", err)
+		}
+
+		if _, err := service.RenameRole(role.ID, dto.UpdateRoleRequest{Name: "renamed"}); !errors.Is(err, ErrProtectedRole) {
+			t.Errorf("RenameRole(%s) error = %v, want %v", name, err, ErrProtectedRole)
+		}
+		requiresTwoFactor := true
+		if _, err := service.RenameRole(role.ID, dto.UpdateRoleRequest{Name: name, RequiresTwoFactor: &requiresTwoFactor}); err != nil {
+			t.Errorf("RenameRole(%s) keeping the name error = %v", name, err)
+		}
+		if _, err := service.DeleteRole(role.ID, nil); !errors.Is(err, ErrProtectedRole) {
+			t.Errorf("DeleteRole(%s) error = %v, want %v", name, err, ErrProtectedRole)
+		}
+	}
+}
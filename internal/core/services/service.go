@@ -1,20 +1,49 @@
 package services
 
 import (
-	"golang.org/x/oauth2"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/core/config"
+
 	"gorm.io/gorm"
 )
 
 type Service struct {
-	UserService  *UserService
-	ClaimService *ClaimService
-	OAuthService *OAuthService
+	UserService                *UserService
+	ClaimService               *ClaimService
+	OAuthService               *OAuthService
+	SessionService             *SessionService
+	TwoFactorService           *TwoFactorService
+	PasskeyService             *PasskeyService
+	IdentityService            *IdentityService
+	TokenIntrospectionService  *TokenIntrospectionService
+	PersonalAccessTokenService *PersonalAccessTokenService
+	ImpersonationService       *ImpersonationService
+	RoleService                *RoleService
+	OrganizationService        *OrganizationService
+	// TokenDenylist is shared by the services and JWTMiddleware
+	TokenDenylist TokenDenylist
+	// Policies decide who may act on a particular record
+	Policies *authz.Policies
 }
 
-func NewService(db *gorm.DB, oauthConfig *oauth2.Config) *Service {
+func NewService(db *gorm.DB, cfg config.Server) *Service {
+	tokenDenylist := NewTokenDenylist(db, cfg.Auth)
+	policies := NewPolicies()
+
 	return &Service{
-		UserService:  NewUserService(db),
-		ClaimService: NewClaimService(db),
-		OAuthService: NewOAuthService(db, oauthConfig),
+		UserService:                NewUserService(db, cfg.Auth, tokenDenylist),
+		ClaimService:               NewClaimService(db),
+		OAuthService:               NewOAuthService(db, cfg.OAuth, cfg.Auth),
+		SessionService:             NewSessionService(db, tokenDenylist),
+		TwoFactorService:           NewTwoFactorService(db, cfg.Auth),
+		PasskeyService:             NewPasskeyService(db, cfg.WebAuthn, cfg.Auth, policies),
+		IdentityService:            NewIdentityService(db, policies),
+		TokenIntrospectionService:  NewTokenIntrospectionService(db, cfg.OAuthClients, tokenDenylist),
+		PersonalAccessTokenService: NewPersonalAccessTokenService(db, policies),
+		ImpersonationService:       NewImpersonationService(db, tokenDenylist),
+		RoleService:                NewRoleService(db),
+		OrganizationService:        NewOrganizationService(db, policies),
+		TokenDenylist:              tokenDenylist,
+		Policies:                   policies,
 	}
 }
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+type SessionService struct {
+	DB            *gorm.DB
+	TokenDenylist TokenDenylist
+}
+
+func NewSessionService(db *gorm.DB, tokenDenylist TokenDenylist) *SessionService {
+	return &SessionService{DB: db, TokenDenylist: tokenDenylist}
+}
+
+// ListSessions returns the active sessions of the user. currentSessionID marks the caller's session.
+func (s *SessionService) ListSessions(userID uint, currentSessionID string) ([]dto.SessionResponse, error) {
+	utils.LogInfo("Listing sessions", "userID", userID)
+
+	// Each session has exactly one non-revoked token, the latest one of its family
+	var tokens []models.RefreshToken
+	if err := s.DB.
+		Where("user_id = ? AND is_revoked = ? AND expires_at > ?", userID, false, time.Now()).
+		Order("last_used_at DESC").
+		Find(&tokens).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list sessions", err)
+		return nil, err
+	}
+
+	response := make([]dto.SessionResponse, len(tokens))
+	for i, token := range tokens {
+		response[i] = dto.SessionResponse{
+			ID:          token.FamilyID,
+			DeviceLabel: token.DeviceLabel,
+			UserAgent:   token.UserAgent,
+			IPAddress:   token.IPAddress,
+			CreatedAt:   token.SessionStartedAt,
+			LastUsedAt:  token.LastUsedAt,
+			ExpiresAt:   token.ExpiresAt,
+			IsCurrent:   token.FamilyID == currentSessionID,
+		}
+	}
+
+	return response, nil
+}
+
+// RevokeSession signs the user out of a single session
+func (s *SessionService) RevokeSession(userID uint, sessionID string) (*dto.RevokeSessionResponse, error) {
+	utils.LogInfo("Revoking session", "userID", userID, "sessionID", sessionID)
+
+	revoked, err := revokeRefreshTokens(s.DB, s.TokenDenylist, "user_id = ? AND family_id = ?", userID, sessionID)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to revoke session", err)
+		return nil, err
+	}
+	if revoked == 0 {
+		utils.LogInfo("Session not found", "sessionID", sessionID)
+		return nil, ErrSessionNotFound
+	}
+
+	return &dto.RevokeSessionResponse{IsSuccess: true}, nil
+}
+
+// RevokeOtherSessions signs the user out of every session except the current one
+func (s *SessionService) RevokeOtherSessions(userID uint, currentSessionID string) (*dto.RevokeOtherSessionsResponse, error) {
+	utils.LogInfo("Revoking other sessions", "userID", userID)
+
+	revoked, err := revokeRefreshTokens(s.DB, s.TokenDenylist, "user_id = ? AND family_id <> ?", userID, currentSessionID)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to revoke other sessions", err)
+		return nil, err
+	}
+
+	return &dto.RevokeOtherSessionsResponse{RevokedCount: revoked}, nil
+}
+
+// RevokeAllSessions signs the user out everywhere, including the current session
+func (s *SessionService) RevokeAllSessions(userID uint) (*dto.RevokeOtherSessionsResponse, error) {
+	utils.LogInfo("Revoking all sessions", "userID", userID)
+
+	revoked, err := revokeRefreshTokens(s.DB, s.TokenDenylist, "user_id = ?", userID)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to revoke all sessions", err)
+		return nil, err
+	}
+
+	return &dto.RevokeOtherSessionsResponse{RevokedCount: revoked}, nil
+}
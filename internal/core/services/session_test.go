@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// loginTestSession logs the user in from the device and returns the ID of the new session
+func loginTestSession(t *testing.T, db *gorm.DB, service *UserService, email, deviceLabel string) string {
+	t.Helper()
+
+	res, err := service.Login(dto.LoginRequest{Email: email, Password: "Password123!"}, dto.SessionInfo{DeviceLabel: deviceLabel})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	var token models.RefreshToken
+	if err := db.Where("token = ?", res.RefreshToken).First(&token).Error; err != nil {
+		t.Fatalf("find refresh token: %v", err)
+	}
+	return token.FamilyID
+}
+
+func TestSessionManagement(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	createTestUser(t, db, "bob")
+	denylist := NewMemoryTokenDenylist()
+	users := NewUserService(db, config.Auth{}, denylist)
+	service := NewSessionService(db, denylist)
+
+	laptop := loginTestSession(t, db, users, "alice@example.com", "Laptop")
+	phone := loginTestSession(t, db, users, "alice@example.com", "Phone")
+	tablet := loginTestSession(t, db, users, "alice@example.com", "Tablet")
+	bobs := loginTestSession(t, db, users, "bob@example.com", "Laptop")
+
+	sessions, err := service.ListSessions(alice.ID, laptop)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 3 {
+		t.Fatalf("sessions = %d, want 3", len(sessions))
+	}
+	for _, session := range sessions {
+		if session.IsCurrent != (session.ID == laptop) {
+			t.Errorf("session %s (%s) current = %t", session.ID, session.DeviceLabel, session.IsCurrent)
+		}
+	}
+
+	// A session of another user isn't found
+	if _, err := service.RevokeSession(alice.ID, bobs); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("RevokeSession() of another user's session error = %v, want %v", err, ErrSessionNotFound)
+	}
+	if _, err := service.RevokeSession(alice.ID, phone); err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+	if _, err := service.RevokeSession(alice.ID, phone); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("RevokeSession() of a revoked session error = %v, want %v", err, ErrSessionNotFound)
+	}
+
+	var phoneToken models.RefreshToken
+	if err := db.Where("family_id = ?", phone).First(&phoneToken).Error; err != nil {
+		t.Fatalf("find session token: %v", err)
+	}
+	if revoked, _ := denylist.IsRevoked(phoneToken.AccessTokenJTI); !revoked {
+		t.Errorf("access token of the revoked session isn't denied")
+	}
+
+	res, err := service.RevokeOtherSessions(alice.ID, laptop)
+	if err != nil {
+		t.Fatalf("RevokeOtherSessions() error = %v", err)
+	}
+	if res.RevokedCount != 1 {
+		t.Errorf("RevokeOtherSessions() revoked %d sessions, want 1 (%s)", res.RevokedCount, tablet)
+	}
+
+	sessions, err = service.ListSessions(alice.ID, laptop)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != laptop {
+		t.Errorf("sessions after revoking the others = %+v, want the current one", sessions)
+	}
+
+	var bobActive int64
+	db.Model(&models.RefreshToken{}).Where("family_id = ? AND is_revoked = ?", bobs, false).Count(&bobActive)
+	if bobActive != 1 {
+		t.Errorf("active sessions of another user = %d, want 1", bobActive)
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"knowstack/internal/data/models"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB returns an empty in-memory database with the schema of the API
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	// Every connection of an in-memory database is a new database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.Role{}, &models.Claim{}, &models.User{}, &models.RefreshToken{}, &models.PasswordResetToken{}, &models.RecoveryCode{}, &models.MFAChallenge{}, &models.PasskeyCredential{}, &models.PasskeyChallenge{}, &models.UserIdentity{}, &models.RevokedToken{}, &models.LoginAttempt{}, &models.PersonalAccessToken{}, &models.Impersonation{}, &models.MagicLinkToken{}, &models.PasswordHistory{}, &models.Organization{}, &models.OrganizationMembership{}, &models.OrganizationInvitation{}); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	return db
+}
+
+// createTestUser creates a local user in a new default role
+func createTestUser(t *testing.T, db *gorm.DB, username string) *models.User {
+	t.Helper()
+
+	var role models.Role
+	if err := db.Where(models.Role{Name: "user"}).Attrs(models.Role{IsDefault: true}).FirstOrCreate(&role).Error; err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+
+	user := models.User{
+		Username:      username,
+		Email:         username + "@example.com",
+		Password:      "Password123!",
+		Provider:      "local",
+		RoleID:        role.ID,
+		EmailVerified: true,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &user
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"knowstack/internal/api/dto"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// issuedTokens is the result of a successful authentication or refresh
+type issuedTokens struct {
+	AccessToken  string
+	RefreshToken string
+	Record       *models.RefreshToken
+	// TwoFactorSetupRequired marks a restricted session, see twoFactorSetupRequired
+	TwoFactorSetupRequired bool
+}
+
+// tokenSession describes the session a refresh token is issued for
+type tokenSession struct {
+	// FamilyID of the session; empty starts a new session
+	FamilyID  string
+	Remember  bool
+	StartedAt time.Time
+	Client    dto.SessionInfo
+}
+
+// newTokenSession starts a new session for the client
+func newTokenSession(client dto.SessionInfo, remember bool) tokenSession {
+	if client.DeviceLabel == "" {
+		client.DeviceLabel = utils.DeviceLabelFromUserAgent(client.UserAgent)
+	}
+	return tokenSession{Remember: remember, Client: client}
+}
+
+// rotatedTokenSession continues the session of the token, keeping its device label
+func rotatedTokenSession(token *models.RefreshToken, client dto.SessionInfo) tokenSession {
+	client.DeviceLabel = token.DeviceLabel
+	return tokenSession{
+		FamilyID:  token.FamilyID,
+		Remember:  token.Remember,
+		StartedAt: token.SessionStartedAt,
+		Client:    client,
+	}
+}
+
+// mergeClaimNames merges the role claims and the user claims distinctly.
+// The user must be loaded with Role.Claims and Claims preloaded.
+func mergeClaimNames(user *models.User) []string {
+	claimNameSet := make(map[string]struct{})
+	for _, c := range user.Role.Claims {
+		claimNameSet[c.Name] = struct{}{}
+	}
+	for _, c := range user.Claims {
+		claimNameSet[c.Name] = struct{}{}
+	}
+	mergedClaimNames := make([]string, 0, len(claimNameSet))
+	for name := range claimNameSet {
+		mergedClaimNames = append(mergedClaimNames, name)
+	}
+	return mergedClaimNames
+}
+
+// twoFactorSetupRequired reports whether the role of the user requires two-factor authentication
+// the user hasn't enabled. The user must be loaded with Role preloaded.
+func twoFactorSetupRequired(user *models.User) bool {
+	return user.Role.RequiresTwoFactor && !user.TOTPEnabled
+}
+
+// grantedClaimNames are the claims the tokens of the user carry. Users who still have to enable
+// two-factor authentication get none, so they can only manage their own account until they do.
+func grantedClaimNames(user *models.User) []string {
+	if twoFactorSetupRequired(user) {
+		return []string{}
+	}
+	return mergeClaimNames(user)
+}
+
+/*
+Check that the account may authenticate. Disabled accounts can't authenticate at all,
+accounts with a forced password reset only once the password is reset.
+*/
+func checkLoginAllowed(user *models.User) error {
+	if user.DisabledAt != nil {
+		utils.LogInfo("Login attempt on disabled account", "userID", user.ID)
+		return ErrAccountDisabled
+	}
+	if user.PasswordResetRequired {
+		utils.LogInfo("Password reset required", "userID", user.ID)
+		return ErrPasswordResetRequired
+	}
+	return nil
+}
+
+/*
+Issue an access token and a refresh token for the user.
+The refresh token is persisted as the current token of the session.
+*/
+func issueTokens(db *gorm.DB, user *models.User, session tokenSession) (*issuedTokens, error) {
+	// Every login and refresh ends here, so blocked accounts can't get tokens by any method
+	if err := checkLoginAllowed(user); err != nil {
+		return nil, err
+	}
+
+	userID := strconv.FormatUint(uint64(user.ID), 10)
+
+	var err error
+	if session.FamilyID == "" {
+		session.FamilyID, err = utils.GenerateTokenFamilyID()
+		if err != nil {
+			utils.LogErrorWithErr("Failed to generate token family", err)
+			return nil, err
+		}
+	}
+
+	accessToken, accessClaims, err := utils.GenerateAccessToken(userID, user.Email, user.Username, user.RoleID, grantedClaimNames(user), session.FamilyID)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to generate access token", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	if session.StartedAt.IsZero() {
+		session.StartedAt = now
+	}
+
+	// Initialize refresh token record; token will be assigned after ID generation
+	refreshTokenRecord := models.RefreshToken{
+		UserID:           user.ID,
+		FamilyID:         session.FamilyID,
+		Remember:         session.Remember,
+		IsRevoked:        false,
+		UserAgent:        session.Client.UserAgent,
+		IPAddress:        session.Client.IPAddress,
+		DeviceLabel:      session.Client.DeviceLabel,
+		SessionStartedAt: session.StartedAt,
+		LastUsedAt:       now,
+		// Recorded so the access token can be denied when the session is revoked
+		AccessTokenJTI:       accessClaims.ID,
+		AccessTokenExpiresAt: accessClaims.ExpiresAt.Time,
+	}
+	if err := db.Create(&refreshTokenRecord).Error; err != nil {
+		utils.LogErrorWithErr("Failed to create token record", err)
+		return nil, err
+	}
+
+	tokenID := strconv.FormatUint(uint64(refreshTokenRecord.ID), 10)
+	refreshToken, expiresAt, err := utils.GenerateRefreshToken(userID, tokenID, session.Remember)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to generate refresh token", err)
+		return nil, err
+	}
+
+	refreshTokenRecord.Token = refreshToken
+	refreshTokenRecord.ExpiresAt = expiresAt
+	if err := db.Save(&refreshTokenRecord).Error; err != nil {
+		utils.LogErrorWithErr("Failed to save token record", err)
+		return nil, err
+	}
+
+	return &issuedTokens{
+		AccessToken:            accessToken,
+		RefreshToken:           refreshToken,
+		Record:                 &refreshTokenRecord,
+		TwoFactorSetupRequired: twoFactorSetupRequired(user),
+	}, nil
+}
+
+// revokeTokenFamily revokes every refresh token issued in the family and denies its access tokens
+func revokeTokenFamily(db *gorm.DB, denylist TokenDenylist, familyID string) error {
+	_, err := revokeRefreshTokens(db, denylist, "family_id = ?", familyID)
+	return err
+}
+
+/*
+Revoke the active refresh tokens matching the condition and deny the unexpired
+access tokens of their families, so the sessions end immediately.
+Returns the number of revoked refresh tokens, which is one per session.
+*/
+func revokeRefreshTokens(db *gorm.DB, denylist TokenDenylist, query string, args ...any) (int64, error) {
+	var familyIDs []string
+	if err := db.Model(&models.RefreshToken{}).
+		Where("is_revoked = ?", false).
+		Where(query, args...).
+		Distinct().
+		Pluck("family_id", &familyIDs).Error; err != nil {
+		utils.LogErrorWithErr("Failed to find token families", err)
+		return 0, err
+	}
+	if len(familyIDs) == 0 {
+		return 0, nil
+	}
+
+	if err := denyFamilyAccessTokens(db, denylist, familyIDs); err != nil {
+		return 0, err
+	}
+
+	result := db.Model(&models.RefreshToken{}).
+		Where("is_revoked = ?", false).
+		Where(query, args...).
+		Update("is_revoked", true)
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to revoke refresh tokens", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// denyFamilyAccessTokens puts the unexpired access and impersonation tokens issued in the families on the denylist
+func denyFamilyAccessTokens(db *gorm.DB, denylist TokenDenylist, familyIDs []string) error {
+	var tokens []models.RefreshToken
+	if err := db.Select("access_token_jti", "access_token_expires_at").
+		Where("family_id IN ? AND access_token_jti <> ? AND access_token_expires_at > ?", familyIDs, "", time.Now()).
+		Find(&tokens).Error; err != nil {
+		utils.LogErrorWithErr("Failed to find access tokens", err)
+		return err
+	}
+
+	for _, token := range tokens {
+		if err := denylist.Revoke(token.AccessTokenJTI, token.AccessTokenExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	// Impersonation tokens end with the admin's session they were issued in
+	var impersonations []models.Impersonation
+	if err := db.Select("id", "token_jti", "expires_at").
+		Where("session_id IN ? AND token_jti <> ? AND ended_at IS NULL AND expires_at > ?", familyIDs, "", time.Now()).
+		Find(&impersonations).Error; err != nil {
+		utils.LogErrorWithErr("Failed to find impersonations", err)
+		return err
+	}
+
+	for _, impersonation := range impersonations {
+		if err := denylist.Revoke(impersonation.TokenJTI, impersonation.ExpiresAt); err != nil {
+			return err
+		}
+		if err := db.Model(&impersonation).Update("ended_at", time.Now()).Error; err != nil {
+			utils.LogErrorWithErr("Failed to end impersonation", err, "impersonationID", impersonation.ID)
+			return err
+		}
+	}
+	return nil
+}
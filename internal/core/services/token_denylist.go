@@ -0,0 +1,133 @@
+package services
+
+import (
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sweeping expired entries more often than this is wasted work
+const tokenDenylistSweepInterval = time.Minute
+
+/*
+TokenDenylist stores the jti of revoked access tokens until they expire.
+JWTMiddleware rejects tokens on the list, so logouts take effect immediately.
+*/
+type TokenDenylist interface {
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+}
+
+/*
+Create the denylist backend selected by the auth config.
+The memory backend is only shared within a single instance;
+deployments with several instances should use the database backend.
+*/
+func NewTokenDenylist(db *gorm.DB, authConfig config.Auth) TokenDenylist {
+	switch authConfig.TokenDenylistBackend {
+	case config.TokenDenylistDatabase:
+		return NewDBTokenDenylist(db)
+	case config.TokenDenylistMemory:
+		return NewMemoryTokenDenylist()
+	default:
+		utils.LogWarn("Unknown token denylist backend, using memory", "backend", authConfig.TokenDenylistBackend)
+		return NewMemoryTokenDenylist()
+	}
+}
+
+// MemoryTokenDenylist keeps the revoked jtis in memory and drops them when the tokens expire
+type MemoryTokenDenylist struct {
+	mu        sync.RWMutex
+	entries   map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryTokenDenylist() *MemoryTokenDenylist {
+	return &MemoryTokenDenylist{entries: make(map[string]time.Time)}
+}
+
+func (d *MemoryTokenDenylist) Revoke(jti string, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(d.lastSweep) > tokenDenylistSweepInterval {
+		for key, exp := range d.entries {
+			if !exp.After(now) {
+				delete(d.entries, key)
+			}
+		}
+		d.lastSweep = now
+	}
+
+	if expiresAt.After(now) {
+		d.entries[jti] = expiresAt
+	}
+	return nil
+}
+
+func (d *MemoryTokenDenylist) IsRevoked(jti string) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	expiresAt, ok := d.entries[jti]
+	return ok && expiresAt.After(time.Now()), nil
+}
+
+// DBTokenDenylist keeps the revoked jtis in the revoked_tokens table
+type DBTokenDenylist struct {
+	DB *gorm.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewDBTokenDenylist(db *gorm.DB) *DBTokenDenylist {
+	return &DBTokenDenylist{DB: db}
+}
+
+func (d *DBTokenDenylist) Revoke(jti string, expiresAt time.Time) error {
+	if !expiresAt.After(time.Now()) {
+		return nil
+	}
+
+	d.sweep()
+
+	if err := d.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to revoke access token", err)
+		return err
+	}
+	return nil
+}
+
+func (d *DBTokenDenylist) IsRevoked(jti string) (bool, error) {
+	var count int64
+	if err := d.DB.Model(&models.RevokedToken{}).
+		Where("jti = ? AND expires_at > ?", jti, time.Now()).
+		Count(&count).Error; err != nil {
+		utils.LogErrorWithErr("Failed to check revoked token", err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// sweep deletes the entries of expired tokens
+func (d *DBTokenDenylist) sweep() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if time.Since(d.lastSweep) < tokenDenylistSweepInterval {
+		return
+	}
+	d.lastSweep = time.Now()
+
+	if err := d.DB.Where("expires_at <= ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to delete expired revoked tokens", err)
+	}
+}
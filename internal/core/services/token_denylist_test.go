@@ -0,0 +1,77 @@
+package services
+
+import (
+	"knowstack/internal/data/models"
+	"testing"
+	"time"
+)
+
+func TestTokenDenylist(t *testing.T) {
+	backends := map[string]func(t *testing.T) TokenDenylist{
+		"memory":   func(t *testing.T) TokenDenylist { return NewMemoryTokenDenylist() },
+		"database": func(t *testing.T) TokenDenylist { return NewDBTokenDenylist(newTestDB(t)) },
+	}
+
+	for name, newDenylist := range backends {
+		t.Run(name, func(t *testing.T) {
+			denylist := newDenylist(t)
+			now := time.Now()
+
+			for jti, expiresAt := range map[string]time.Time{
+				"active":   now.Add(time.Hour),
+				"expiring": now.Add(100 * time.Millisecond),
+				"expired":  now.Add(-time.Minute),
+			} {
+				if err := denylist.Revoke(jti, expiresAt); err != nil {
+					t.Fatalf("Revoke(%s) error = %v", jti, err)
+				}
+			}
+			// Revoking a token twice is not an error
+			if err := denylist.Revoke("active", now.Add(time.Hour)); err != nil {
+				t.Fatalf("Revoke() of a revoked token error = %v", err)
+			}
+
+			assertRevoked := func(jti string, want bool) {
+				t.Helper()
+				revoked, err := denylist.IsRevoked(jti)
+				if err != nil {
+					t.Fatalf("IsRevoked(%s) error = %v", jti, err)
+				}
+				if revoked != want {
+					t.Errorf("IsRevoked(%s) = %t, want %t", jti, revoked, want)
+				}
+			}
+			assertRevoked("active", true)
+			assertRevoked("expiring", true)
+			assertRevoked("expired", false)
+			assertRevoked("unknown", false)
+
+			// The entry is dropped once the token expired, it can't be used anymore anyway
+			time.Sleep(150 * time.Millisecond)
+			assertRevoked("expiring", false)
+			assertRevoked("active", true)
+		})
+	}
+}
+
+func TestDBTokenDenylistSharedAndSwept(t *testing.T) {
+	db := newTestDB(t)
+	expired := models.RevokedToken{JTI: "expired", ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := db.Create(&expired).Error; err != nil {
+		t.Fatalf("create revoked token: %v", err)
+	}
+
+	// Instances sharing the database see each other's revocations
+	if err := NewDBTokenDenylist(db).Revoke("active", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if revoked, err := NewDBTokenDenylist(db).IsRevoked("active"); err != nil || !revoked {
+		t.Errorf("IsRevoked() on another instance = %t, %v, want true", revoked, err)
+	}
+
+	var count int64
+	db.Model(&models.RevokedToken{}).Where("jti = ?", "expired").Count(&count)
+	if count != 0 {
+		t.Errorf("expired entries = %d after a revocation, want them swept", count)
+	}
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TokenIntrospectionService struct {
+	DB            *gorm.DB
+	TokenDenylist TokenDenylist
+	// clientSecrets holds the SHA-256 of the secret of every client
+	clientSecrets map[string][32]byte
+}
+
+func NewTokenIntrospectionService(db *gorm.DB, clients []config.OAuthClient, tokenDenylist TokenDenylist) *TokenIntrospectionService {
+	clientSecrets := make(map[string][32]byte, len(clients))
+	for _, client := range clients {
+		clientSecrets[client.ID] = sha256.Sum256([]byte(client.Secret))
+	}
+	return &TokenIntrospectionService{DB: db, TokenDenylist: tokenDenylist, clientSecrets: clientSecrets}
+}
+
+// AuthenticateClient checks the client credentials in constant time
+func (s *TokenIntrospectionService) AuthenticateClient(clientID, clientSecret string) bool {
+	expected, ok := s.clientSecrets[clientID]
+	// Hash anyway so unknown clients take as long as known ones
+	actual := sha256.Sum256([]byte(clientSecret))
+	if !ok || clientSecret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare(expected[:], actual[:]) == 1
+}
+
+/*
+Introspect reports whether the token is active (RFC 7662).
+An access token is active while its signature and expiry are valid and its session
+was not logged out or revoked. A refresh token is active while its record is not revoked.
+The hint only decides which type is tried first.
+*/
+func (s *TokenIntrospectionService) Introspect(token, tokenTypeHint string) (*dto.IntrospectionResponse, error) {
+	if tokenTypeHint == dto.TokenTypeRefreshToken {
+		if res, err := s.introspectRefreshToken(token); err != nil || res != nil {
+			return res, err
+		}
+		if res, err := s.introspectAccessToken(token); err != nil || res != nil {
+			return res, err
+		}
+	} else {
+		if res, err := s.introspectAccessToken(token); err != nil || res != nil {
+			return res, err
+		}
+		if res, err := s.introspectRefreshToken(token); err != nil || res != nil {
+			return res, err
+		}
+	}
+	return &dto.IntrospectionResponse{Active: false}, nil
+}
+
+/*
+Revoke ends the session of the token (RFC 7009).
+Revoking either an access or a refresh token revokes the whole refresh token family,
+so the tokens of the session can't be refreshed anymore.
+Invalid or unknown tokens are not an error.
+*/
+func (s *TokenIntrospectionService) Revoke(token, tokenTypeHint string) error {
+	familyID := ""
+	if tokenTypeHint == dto.TokenTypeRefreshToken {
+		familyID = s.refreshTokenFamily(token)
+		if familyID == "" {
+			familyID = accessTokenFamily(token)
+		}
+	} else {
+		familyID = accessTokenFamily(token)
+		if familyID == "" {
+			familyID = s.refreshTokenFamily(token)
+		}
+	}
+
+	if familyID == "" {
+		utils.LogInfo("Revocation requested for an unknown token")
+		return nil
+	}
+
+	utils.LogInfo("Revoking token family", "sessionID", familyID)
+	if err := revokeTokenFamily(s.DB, s.TokenDenylist, familyID); err != nil {
+		utils.LogErrorWithErr("Failed to revoke token family", err)
+		return err
+	}
+	return nil
+}
+
+// introspectAccessToken returns nil when the token is not an access token
+func (s *TokenIntrospectionService) introspectAccessToken(token string) (*dto.IntrospectionResponse, error) {
+	claims, err := utils.VerifyAccessToken(token)
+	if err != nil {
+		return nil, nil
+	}
+
+	revoked, err := s.TokenDenylist.IsRevoked(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.sessionActive(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked || !active {
+		return &dto.IntrospectionResponse{Active: false}, nil
+	}
+
+	res := &dto.IntrospectionResponse{
+		Active:    true,
+		TokenType: dto.TokenTypeAccessToken,
+		Subject:   claims.Subject,
+		Username:  claims.Username,
+		Email:     claims.Email,
+		RoleID:    claims.RoleID,
+		Claims:    claims.Claims,
+		SessionID: claims.SessionID,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+	}
+	if claims.IssuedAt != nil {
+		res.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.ExpiresAt != nil {
+		res.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.Actor != nil {
+		res.Actor = &dto.IntrospectionActor{Subject: claims.Actor.UserID, Username: claims.Actor.Username}
+	}
+	return res, nil
+}
+
+// introspectRefreshToken returns nil when the token is not a refresh token
+func (s *TokenIntrospectionService) introspectRefreshToken(token string) (*dto.IntrospectionResponse, error) {
+	claims, err := utils.ValidateRefreshToken(token)
+	if err != nil {
+		if errors.Is(err, utils.ErrTokenExpired) {
+			return &dto.IntrospectionResponse{Active: false}, nil
+		}
+		return nil, nil
+	}
+
+	record, err := s.findRefreshToken(claims.TokenID, token)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || record.IsRevoked || !record.ExpiresAt.After(time.Now()) {
+		return &dto.IntrospectionResponse{Active: false}, nil
+	}
+
+	res := &dto.IntrospectionResponse{
+		Active:    true,
+		TokenType: dto.TokenTypeRefreshToken,
+		Subject:   claims.Subject,
+		SessionID: record.FamilyID,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		ExpiresAt: record.ExpiresAt.Unix(),
+	}
+	if claims.IssuedAt != nil {
+		res.IssuedAt = claims.IssuedAt.Unix()
+	}
+	return res, nil
+}
+
+// sessionActive reports whether the refresh token family still has a usable token
+func (s *TokenIntrospectionService) sessionActive(familyID string) (bool, error) {
+	if familyID == "" {
+		return false, nil
+	}
+	var count int64
+	if err := s.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND is_revoked = ? AND expires_at > ?", familyID, false, time.Now()).
+		Count(&count).Error; err != nil {
+		utils.LogErrorWithErr("Failed to check session", err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *TokenIntrospectionService) findRefreshToken(tokenID, token string) (*models.RefreshToken, error) {
+	id, err := strconv.ParseUint(tokenID, 10, 32)
+	if err != nil {
+		return nil, nil
+	}
+	var record models.RefreshToken
+	err = s.DB.Where("id = ? AND token = ?", id, token).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		utils.LogErrorWithErr("Failed to find refresh token", err)
+		return nil, err
+	}
+	return &record, nil
+}
+
+// refreshTokenFamily returns the family of a refresh token, also when it expired
+func (s *TokenIntrospectionService) refreshTokenFamily(token string) string {
+	claims, err := utils.ValidateRefreshToken(token)
+	if err != nil && !errors.Is(err, utils.ErrTokenExpired) {
+		return ""
+	}
+	var record models.RefreshToken
+	query := s.DB.Where("token = ?", token)
+	if claims != nil {
+		query = s.DB.Where("id = ? AND token = ?", claims.TokenID, token)
+	}
+	if err := query.First(&record).Error; err != nil {
+		return ""
+	}
+	return record.FamilyID
+}
+
+func accessTokenFamily(token string) string {
+	claims, err := utils.VerifyAccessToken(token)
+	if err != nil {
+		return ""
+	}
+	return claims.SessionID
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"testing"
+)
+
+func TestTokenIntrospectionAuthenticateClient(t *testing.T) {
+	service := NewTokenIntrospectionService(newTestDB(t), []config.OAuthClient{{ID: "gateway", Secret: "s3cret"}}, NewMemoryTokenDenylist())
+
+	for _, tt := range []struct {
+		id, secret string
+		want       bool
+	}{
+		{"gateway", "s3cret", true},
+		{"gateway", "wrong", false},
+		{"gateway", "", false},
+		{"unknown", "s3cret", false},
+	} {
+		if got := service.AuthenticateClient(tt.id, tt.secret); got != tt.want {
+			t.Errorf("AuthenticateClient(%q, %q) = %t, want %t", tt.id, tt.secret, got, tt.want)
+		}
+	}
+}
+
+func TestTokenIntrospectionRevokesSession(t *testing.T) {
+	for _, hint := range []string{"", dto.TokenTypeAccessToken, dto.TokenTypeRefreshToken} {
+		t.Run("hint "+hint, func(t *testing.T) {
+			db := newTestDB(t)
+			createTestUser(t, db, "alice")
+			denylist := NewMemoryTokenDenylist()
+			login, err := NewUserService(db, config.Auth{}, denylist).
+				Login(dto.LoginRequest{Email: "alice@example.com", Password: "Password123!"}, dto.SessionInfo{})
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			service := NewTokenIntrospectionService(db, nil, denylist)
+
+			access, err := service.Introspect(login.AccessToken, hint)
+			if err != nil {
+				t.Fatalf("Introspect() access token error = %v", err)
+			}
+			if !access.Active || access.TokenType != dto.TokenTypeAccessToken || access.Username != "alice" {
+				t.Errorf("Introspect() access token = %+v, want an active access token of alice", access)
+			}
+			refresh, err := service.Introspect(login.RefreshToken, hint)
+			if err != nil {
+				t.Fatalf("Introspect() refresh token error = %v", err)
+			}
+			if !refresh.Active || refresh.TokenType != dto.TokenTypeRefreshToken || refresh.SessionID != access.SessionID {
+				t.Errorf("Introspect() refresh token = %+v, want an active refresh token of the session", refresh)
+			}
+
+			// Revoking one token of the session deactivates both
+			revoked := login.AccessToken
+			if hint == dto.TokenTypeRefreshToken {
+				revoked = login.RefreshToken
+			}
+			if err := service.Revoke(revoked, hint); err != nil {
+				t.Fatalf("Revoke() error = %v", err)
+			}
+			for name, token := range map[string]string{"access": login.AccessToken, "refresh": login.RefreshToken} {
+				res, err := service.Introspect(token, hint)
+				if err != nil {
+					t.Fatalf("Introspect() %s token error = %v", name, err)
+				}
+				if res.Active {
+					t.Errorf("Introspect() %s token after Revoke() = %+v, want inactive", name, res)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenIntrospectionUnknownToken(t *testing.T) {
+	service := NewTokenIntrospectionService(newTestDB(t), nil, NewMemoryTokenDenylist())
+
+	res, err := service.Introspect("not-a-token", "")
+	if err != nil {
+		t.Fatalf("Introspect() error = %v", err)
+	}
+	if res.Active {
+		t.Errorf("Introspect() of an invalid token = %+v, want inactive", res)
+	}
+	if err := service.Revoke("not-a-token", ""); err != nil {
+		t.Errorf("Revoke() of an invalid token error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication not enrolled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication not enabled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidMFAChallenge     = errors.New("invalid mfa challenge")
+	ErrMFAChallengeExpired     = errors.New("mfa challenge expired")
+)
+
+const recoveryCodeCount = 10
+
+// maxMFAChallengeAttempts is the number of codes accepted for one MFA challenge, a new login is needed afterwards
+const maxMFAChallengeAttempts = 5
+
+type TwoFactorService struct {
+	DB       *gorm.DB
+	throttle *loginThrottler
+}
+
+func NewTwoFactorService(db *gorm.DB, authConfig config.Auth) *TwoFactorService {
+	return &TwoFactorService{DB: db, throttle: newLoginThrottler(db, authConfig.LoginThrottle)}
+}
+
+// Enroll generates a new TOTP secret for the user. It is not active until confirmed with a code.
+// Any account can enroll, the accounts without a password included, so every role can require it.
+func (s *TwoFactorService) Enroll(userID uint) (*dto.EnrollTwoFactorResponse, error) {
+	utils.LogInfo("Enrolling two-factor authentication", "userID", userID)
+
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		utils.LogErrorWithErr("Failed to generate TOTP secret", err)
+		return nil, err
+	}
+
+	encrypted, err := utils.EncryptTOTPSecret(secret)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to encrypt TOTP secret", err)
+		return nil, err
+	}
+
+	if err := s.DB.Model(user).Update("totp_secret", encrypted).Error; err != nil {
+		utils.LogErrorWithErr("Failed to save TOTP secret", err)
+		return nil, err
+	}
+
+	return &dto.EnrollTwoFactorResponse{
+		Secret:     secret,
+		OtpauthURI: utils.TOTPProvisioningURI(secret, user.Email),
+	}, nil
+}
+
+// Confirm enables two-factor authentication with the first code and returns the recovery codes
+func (s *TwoFactorService) Confirm(userID uint, req dto.TwoFactorCodeRequest) (*dto.ConfirmTwoFactorResponse, error) {
+	utils.LogInfo("Confirming two-factor authentication", "userID", userID)
+
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	secret, err := utils.DecryptTOTPSecret(user.TOTPSecret)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to decrypt TOTP secret", err, "userID", userID)
+		return nil, err
+	}
+
+	step, ok := utils.ValidateTOTPCode(secret, req.Code, time.Now())
+	if !ok {
+		utils.LogInfo("Invalid two-factor code", "userID", userID)
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]any{
+			"totp_enabled":        true,
+			"totp_last_used_step": step,
+		}).Error; err != nil {
+			utils.LogErrorWithErr("Failed to enable two-factor authentication", err)
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ConfirmTwoFactorResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns two-factor authentication off after checking a TOTP or recovery code, see checkSecondFactor
+func (s *TwoFactorService) Disable(userID uint, req dto.TwoFactorCodeRequest, client dto.SessionInfo) (*dto.DisableTwoFactorResponse, error) {
+	utils.LogInfo("Disabling two-factor authentication", "userID", userID)
+
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	if err := s.checkSecondFactor(user, req.Code, client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]any{
+			"totp_enabled":        false,
+			"totp_secret":         "",
+			"totp_last_used_step": 0,
+		}).Error; err != nil {
+			utils.LogErrorWithErr("Failed to disable two-factor authentication", err)
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			utils.LogErrorWithErr("Failed to delete recovery codes", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.DisableTwoFactorResponse{IsSuccess: true}, nil
+}
+
+// RegenerateRecoveryCodes invalidates the old recovery codes and returns new ones, see checkSecondFactor
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, req dto.TwoFactorCodeRequest, client dto.SessionInfo) (*dto.RecoveryCodesResponse, error) {
+	utils.LogInfo("Regenerating recovery codes", "userID", userID)
+
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	if err := s.checkSecondFactor(user, req.Code, client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+/*
+VerifyLogin exchanges the MFA challenge of Login and a second factor for real tokens.
+A challenge accepts maxMFAChallengeAttempts codes and is consumed by the first valid one.
+Wrong codes count as failed logins of the account and the IP address, like wrong passwords.
+*/
+func (s *TwoFactorService) VerifyLogin(req dto.VerifyTwoFactorLoginRequest, client dto.SessionInfo) (*dto.LoginResponse, error) {
+	utils.LogInfo("Verifying two-factor login")
+
+	claims, err := utils.ValidateMFAChallengeToken(req.MFAToken)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to validate MFA challenge token", err)
+		if errors.Is(err, utils.ErrTokenExpired) {
+			return nil, ErrMFAChallengeExpired
+		}
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	blocked, err := s.throttle.ipBlocked(client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		utils.LogWarn("Security event: two-factor login blocked for IP address", "ip", client.IPAddress)
+		return nil, ErrTooManyLoginAttempts
+	}
+
+	// The attempt is counted before the code is checked, so concurrent guesses can't exceed the limit
+	result := s.DB.Model(&models.MFAChallenge{}).
+		Where("token_id = ? AND user_id = ? AND used_at IS NULL AND attempts < ?", claims.ID, claims.UserID, maxMFAChallengeAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to count MFA challenge attempt", result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		utils.LogInfo("MFA challenge used or out of attempts", "userID", claims.UserID)
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	var user models.User
+	if err := s.DB.
+		Preload("Role").
+		Preload("Role.Claims").
+		Preload("Claims").
+		Where("id = ?", claims.UserID).
+		First(&user).Error; err != nil {
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	if !user.TOTPEnabled {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		utils.LogInfo("Two-factor login attempt on locked account", "userID", user.ID)
+		return nil, ErrTooManyLoginAttempts
+	}
+
+	if !s.verifySecondFactor(&user, req.Code) {
+		utils.LogInfo("Invalid two-factor code", "userID", user.ID)
+		failures := s.throttle.recordLoginFailure(&user, client.IPAddress)
+		time.Sleep(s.throttle.loginDelay(failures))
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	// Consume the challenge, a concurrent request with another valid code gets nothing
+	result = s.DB.Model(&models.MFAChallenge{}).
+		Where("token_id = ? AND used_at IS NULL", claims.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to consume MFA challenge", result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		utils.LogInfo("MFA challenge already used", "userID", user.ID)
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	s.throttle.recordLoginSuccess(&user, client.IPAddress)
+
+	client.DeviceLabel = claims.DeviceLabel
+	tokens, err := issueTokens(s.DB, &user, newTokenSession(client, claims.Remember))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		AccessToken:            tokens.AccessToken,
+		RefreshToken:           tokens.RefreshToken,
+		TwoFactorSetupRequired: tokens.TwoFactorSetupRequired,
+	}, nil
+}
+
+/*
+checkSecondFactor checks the code confirming a change of the two-factor settings.
+Wrong codes count as failed logins of the account and the IP address like in VerifyLogin,
+so a stolen session can't guess codes faster than a login and the lockout stops it.
+*/
+func (s *TwoFactorService) checkSecondFactor(user *models.User, code, ip string) error {
+	blocked, err := s.throttle.ipBlocked(ip)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		utils.LogWarn("Security event: two-factor code blocked for IP address", "ip", ip, "userID", user.ID)
+		return ErrTooManyLoginAttempts
+	}
+
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		utils.LogInfo("Two-factor code on locked account", "userID", user.ID)
+		return ErrTooManyLoginAttempts
+	}
+
+	if !s.verifySecondFactor(user, code) {
+		utils.LogInfo("Invalid two-factor code", "userID", user.ID)
+		failures := s.throttle.recordLoginFailure(user, ip)
+		time.Sleep(s.throttle.loginDelay(failures))
+		return ErrInvalidTwoFactorCode
+	}
+
+	s.throttle.recordLoginSuccess(user, ip)
+	return nil
+}
+
+// verifySecondFactor accepts a TOTP code that was not used before or an unused recovery code
+func (s *TwoFactorService) verifySecondFactor(user *models.User, code string) bool {
+	secret, err := utils.DecryptTOTPSecret(user.TOTPSecret)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to decrypt TOTP secret", err, "userID", user.ID)
+		return false
+	}
+
+	if step, ok := utils.ValidateTOTPCode(secret, code, time.Now()); ok {
+		// Conditional update so a code can't be replayed, even concurrently
+		result := s.DB.Model(&models.User{}).
+			Where("id = ? AND totp_last_used_step < ?", user.ID, step).
+			Update("totp_last_used_step", step)
+		if result.Error != nil {
+			utils.LogErrorWithErr("Failed to save TOTP step", result.Error)
+			return false
+		}
+		return result.RowsAffected == 1
+	}
+
+	result := s.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to use recovery code", result.Error)
+		return false
+	}
+	if result.RowsAffected == 1 {
+		utils.LogWarn("Recovery code used", "userID", user.ID)
+		return true
+	}
+	return false
+}
+
+func (s *TwoFactorService) findUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+	return &user, nil
+}
+
+// replaceRecoveryCodes deletes the existing recovery codes and stores the hashes of new ones
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to delete recovery codes", err)
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			utils.LogErrorWithErr("Failed to generate recovery code", err)
+			return nil, err
+		}
+		codes[i] = code
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(code)}
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		utils.LogErrorWithErr("Failed to save recovery codes", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
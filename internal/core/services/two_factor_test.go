@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// enableTestTwoFactor turns two-factor authentication on for the user and returns its recovery codes
+func enableTestTwoFactor(t *testing.T, db *gorm.DB, user *models.User) []string {
+	t.Helper()
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("generate secret: %v", err)
+	}
+	encrypted, err := utils.EncryptTOTPSecret(secret)
+	if err != nil {
+		t.Fatalf("encrypt secret: %v", err)
+	}
+	if err := db.Model(user).Updates(map[string]any{"totp_enabled": true, "totp_secret": encrypted}).Error; err != nil {
+		t.Fatalf("enable 2fa: %v", err)
+	}
+	codes, err := replaceRecoveryCodes(db, user.ID)
+	if err != nil {
+		t.Fatalf("create recovery codes: %v", err)
+	}
+	return codes
+}
+
+func TestTwoFactorSettingsLockOutGuesses(t *testing.T) {
+	client := dto.SessionInfo{IPAddress: "127.0.0.1"}
+
+	for _, tc := range []struct {
+		name   string
+		change func(*TwoFactorService, uint, string) error
+	}{
+		{"disable", func(s *TwoFactorService, userID uint, code string) error {
+			_, err := s.Disable(userID, dto.TwoFactorCodeRequest{Code: code}, client)
+			return err
+		}},
+		{"regenerate recovery codes", func(s *TwoFactorService, userID uint, code string) error {
+			_, err := s.RegenerateRecoveryCodes(userID, dto.TwoFactorCodeRequest{Code: code}, client)
+			return err
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDB(t)
+			user := createTestUser(t, db, "alice")
+			codes := enableTestTwoFactor(t, db, user)
+			service := NewTwoFactorService(db, config.Auth{LoginThrottle: config.LoginThrottle{
+				MaxAccountFailures: 3,
+				LockoutDuration:    time.Minute,
+			}})
+
+			for attempt := 1; attempt <= 3; attempt++ {
+				if err := tc.change(service, user.ID, "000000"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+					t.Fatalf("attempt %d error = %v, want %v", attempt, err, ErrInvalidTwoFactorCode)
+				}
+			}
+
+			if err := tc.change(service, user.ID, codes[0]); !errors.Is(err, ErrTooManyLoginAttempts) {
+				t.Fatalf("valid code on the locked account error = %v, want %v", err, ErrTooManyLoginAttempts)
+			}
+
+			var stored models.User
+			if err := db.First(&stored, user.ID).Error; err != nil {
+				t.Fatalf("find user: %v", err)
+			}
+			if !stored.TOTPEnabled {
+				t.Errorf("two-factor authentication was disabled")
+			}
+			var unused int64
+			db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&unused)
+			if unused != int64(len(codes)) {
+				t.Errorf("unused recovery codes = %d, want %d", unused, len(codes))
+			}
+		})
+	}
+}
+
+func TestEnrollWithoutPassword(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	if err := db.Model(user).Updates(map[string]any{"provider": "github", "password": ""}).Error; err != nil {
+		t.Fatalf("make oauth-only user: %v", err)
+	}
+	service := NewTwoFactorService(db, config.Auth{})
+
+	res, err := service.Enroll(user.ID)
+	if err != nil {
+		t.Fatalf("Enroll() error = %v", err)
+	}
+	if res.Secret == "" {
+		t.Fatalf("Enroll() returned no secret")
+	}
+
+	var stored models.User
+	if err := db.First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+	if stored.TOTPSecret == "" || stored.TOTPEnabled {
+		t.Errorf("after Enroll() secret stored = %t, enabled = %t, want stored and not enabled", stored.TOTPSecret != "", stored.TOTPEnabled)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
 	"knowstack/internal/data/models"
 	"knowstack/internal/utils"
 	"strconv"
@@ -24,15 +25,28 @@ var (
 	ErrParseError            = errors.New("parsing error")
 	ErrMismatchTokenAndUser  = errors.New("token and user mismatch")
 	ErrRefreshTokenExpired   = errors.New("refresh token expired")
+	ErrRefreshTokenReused    = errors.New("refresh token reused")
+	ErrTokenRevoked          = errors.New("token revoked")
+	ErrResetTokenNotFound    = errors.New("password reset token not found")
+	ErrResetTokenExpired     = errors.New("password reset token expired")
+	ErrResetTokenUsed        = errors.New("password reset token already used")
 )
 
 type UserService struct {
-	DB *gorm.DB
+	*loginThrottler
+	DB             *gorm.DB
+	AuthConfig     config.Auth
+	TokenDenylist  TokenDenylist
+	PasswordPolicy *PasswordPolicy
 }
 
-func NewUserService(db *gorm.DB) *UserService {
+func NewUserService(db *gorm.DB, authConfig config.Auth, tokenDenylist TokenDenylist) *UserService {
 	return &UserService{
-		DB: db,
+		loginThrottler: newLoginThrottler(db, authConfig.LoginThrottle),
+		DB:             db,
+		AuthConfig:     authConfig,
+		TokenDenylist:  tokenDenylist,
+		PasswordPolicy: NewPasswordPolicy(authConfig.PasswordPolicy),
 	}
 }
 
@@ -49,6 +63,11 @@ func (s *UserService) CreateUser(req dto.CreateUserRequest) (*dto.CreateUserResp
 		return nil, ErrEmailAlreadyExists
 	}
 
+	if err := s.PasswordPolicy.Check(req.Password, req.Username, req.Email); err != nil {
+		utils.LogInfo("Password rejected by the policy", "username", req.Username, "error", err)
+		return nil, err
+	}
+
 	var defaultRole models.Role
 	if err := s.DB.Where("is_default = ?", true).First(&defaultRole).Error; err != nil {
 		utils.LogErrorWithErr("Failed to find default role", err)
@@ -60,6 +79,7 @@ func (s *UserService) CreateUser(req dto.CreateUserRequest) (*dto.CreateUserResp
 		Email:    req.Email,
 		RoleID:   defaultRole.ID,
 		Password: req.Password,
+		Provider: "local",
 	}
 
 	if err := s.DB.Create(user).Error; err != nil {
@@ -67,6 +87,11 @@ func (s *UserService) CreateUser(req dto.CreateUserRequest) (*dto.CreateUserResp
 		return nil, err
 	}
 
+	// The account is created even if the email can't be sent, a new one can be requested
+	if err := s.sendVerificationEmail(user); err != nil {
+		utils.LogErrorWithErr("Failed to send verification email", err)
+	}
+
 	return &dto.CreateUserResponse{
 		ID:       user.ID,
 		Username: user.Username,
@@ -74,9 +99,21 @@ func (s *UserService) CreateUser(req dto.CreateUserRequest) (*dto.CreateUserResp
 	}, nil
 }
 
-func (s *UserService) Login(req dto.LoginRequest) (*dto.LoginResponse, error) {
+// Login verifies the password of the account. Unknown emails, wrong passwords and locked
+// accounts all fail with ErrInvalidCredentials so the response doesn't reveal which accounts exist.
+// Failures are throttled per submitted email, per account and per IP address, see AuthConfig.LoginThrottle.
+func (s *UserService) Login(req dto.LoginRequest, client dto.SessionInfo) (*dto.LoginResponse, error) {
 	utils.LogInfo("Logging in user", "email", req.Email)
 
+	blocked, err := s.ipBlocked(client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		utils.LogWarn("Security event: login blocked for IP address", "ip", client.IPAddress)
+		return nil, ErrTooManyLoginAttempts
+	}
+
 	var user models.User
 	if err := s.DB.
 		Preload("Role").
@@ -84,66 +121,116 @@ func (s *UserService) Login(req dto.LoginRequest) (*dto.LoginResponse, error) {
 		Preload("Claims").
 		Where("email = ?", req.Email).
 		First(&user).Error; err != nil {
-		utils.LogErrorWithErr("Failed to find user", err)
-		return nil, ErrUserNotFound
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogErrorWithErr("Failed to find user", err)
+			return nil, err
+		}
+		utils.LogInfo("User not found", "email", req.Email)
+		utils.VerifyPassword(req.Password, dummyPasswordHash())
+		s.recordLoginAttempt(req.Email, nil, client.IPAddress, false)
+		time.Sleep(s.failureDelay(req.Email))
+		return nil, ErrInvalidCredentials
 	}
 
-	if !utils.VerifyPassword(req.Password, user.Password) {
-		utils.LogError("Invalid password")
-		return nil, ErrInvalidPassword
-	}
+	passwordValid := utils.VerifyPassword(req.Password, user.Password)
 
-	userID := strconv.FormatUint(uint64(user.ID), 10)
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		utils.LogInfo("Login attempt on locked account", "userID", user.ID)
+		s.recordLoginAttempt(user.Email, &user.ID, client.IPAddress, false)
+		time.Sleep(s.failureDelay(user.Email))
+		return nil, ErrInvalidCredentials
+	}
 
-	// Merge role claims and user claims distinctly
-	claimNameSet := make(map[string]struct{})
-	for _, c := range user.Role.Claims {
-		claimNameSet[c.Name] = struct{}{}
+	if !passwordValid {
+		utils.LogInfo("Invalid password", "userID", user.ID)
+		s.recordLoginFailure(&user, client.IPAddress)
+		time.Sleep(s.failureDelay(user.Email))
+		return nil, ErrInvalidCredentials
 	}
-	for _, c := range user.Claims {
-		claimNameSet[c.Name] = struct{}{}
+
+	s.recordLoginSuccess(&user, client.IPAddress)
+
+	if err := checkLoginAllowed(&user); err != nil {
+		return nil, err
 	}
-	mergedClaimNames := make([]string, 0, len(claimNameSet))
-	for name := range claimNameSet {
-		mergedClaimNames = append(mergedClaimNames, name)
+
+	if s.AuthConfig.RequireEmailVerification && user.Provider == "local" && !user.EmailVerified {
+		utils.LogInfo("Email not verified", "userID", user.ID)
+		return nil, ErrEmailNotVerified
 	}
 
-	token, err := utils.GenerateAccessToken(userID, user.Email, user.Username, user.RoleID, mergedClaimNames)
-	if err != nil {
-		utils.LogErrorWithErr("Failed to generate JWT", err)
-		return nil, err
+	// Transparently migrate legacy or outdated hashes, the BeforeUpdate hook hashes the password
+	if utils.NeedsRehash(user.Password) {
+		if err := s.DB.Model(&user).Update("password", req.Password).Error; err != nil {
+			utils.LogErrorWithErr("Failed to rehash password", err, "userID", user.ID)
+		}
 	}
 
-	// Initialize refresh token record with user ID; token will be assigned after ID generation
-	refreshTokenRecord := models.RefreshToken{
-		UserID:    user.ID,
-		IsRevoked: false,
+	if req.DeviceLabel != "" {
+		client.DeviceLabel = req.DeviceLabel
 	}
-	if err := s.DB.Create(&refreshTokenRecord).Error; err != nil {
-		utils.LogErrorWithErr("Failed to create token record", err)
+
+	return s.completeLogin(&user, client, req.Remember)
+}
+
+// completeLogin finishes a first factor login: two-factor accounts get a challenge
+// that is exchanged for tokens with a code, other accounts get the tokens.
+// The user must be loaded with Role.Claims and Claims preloaded.
+func (s *UserService) completeLogin(user *models.User, client dto.SessionInfo, remember bool) (*dto.LoginResponse, error) {
+	// Checked before the challenge too, so a blocked account isn't asked for its second factor
+	if err := checkLoginAllowed(user); err != nil {
 		return nil, err
 	}
 
-	tokenID := strconv.FormatUint(uint64(refreshTokenRecord.ID), 10)
-	refreshToken, err := utils.GenerateRefreshToken(userID, tokenID, req.Remember)
-	if err != nil {
-		utils.LogErrorWithErr("Failed to generate refresh token", err)
-		return nil, err
+	if user.TOTPEnabled {
+		mfaToken, err := createMFAChallenge(s.DB, user, remember, client.DeviceLabel)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.LoginResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
 	}
 
-	refreshTokenRecord.Token = refreshToken
-	if err := s.DB.Save(&refreshTokenRecord).Error; err != nil {
-		utils.LogErrorWithErr("Failed to save token record", err)
+	tokens, err := issueTokens(s.DB, user, newTokenSession(client, remember))
+	if err != nil {
 		return nil, err
 	}
 
 	return &dto.LoginResponse{
-		AccessToken:  token,
-		RefreshToken: refreshToken,
+		AccessToken:            tokens.AccessToken,
+		RefreshToken:           tokens.RefreshToken,
+		TwoFactorSetupRequired: tokens.TwoFactorSetupRequired,
 	}, nil
 }
 
-func (s *UserService) Refresh(req dto.RefreshRequest) (*dto.RefreshResponse, error) {
+// createMFAChallenge returns the token of a new second-factor challenge for the user,
+// the attempts of the challenge are counted on its record, see TwoFactorService.VerifyLogin
+func createMFAChallenge(db *gorm.DB, user *models.User, remember bool, deviceLabel string) (string, error) {
+	mfaToken, mfaClaims, err := utils.GenerateMFAChallengeToken(strconv.FormatUint(uint64(user.ID), 10), remember, deviceLabel)
+	if err != nil {
+		utils.LogErrorWithErr("Failed to generate MFA challenge token", err)
+		return "", err
+	}
+	if err := db.Where("user_id = ? AND expires_at < ?", user.ID, time.Now()).Delete(&models.MFAChallenge{}).Error; err != nil {
+		utils.LogErrorWithErr("Failed to delete expired MFA challenges", err, "userID", user.ID)
+	}
+	challenge := models.MFAChallenge{
+		TokenID:   mfaClaims.ID,
+		UserID:    user.ID,
+		ExpiresAt: mfaClaims.ExpiresAt.Time,
+	}
+	if err := db.Create(&challenge).Error; err != nil {
+		utils.LogErrorWithErr("Failed to save MFA challenge", err)
+		return "", err
+	}
+	return mfaToken, nil
+}
+
+// Refresh rotates the refresh token: a new token is issued in the same family and the
+// presented one is invalidated. Presenting a rotated-out token revokes the whole family.
+func (s *UserService) Refresh(req dto.RefreshRequest, client dto.SessionInfo) (*dto.RefreshResponse, error) {
 	utils.LogInfo("Refreshing token")
 
 	claims, err := utils.ValidateRefreshToken(req.RefreshToken)
@@ -156,7 +243,7 @@ func (s *UserService) Refresh(req dto.RefreshRequest) (*dto.RefreshResponse, err
 	tokenID64, err := strconv.ParseUint(claims.TokenID, 10, 32)
 	if err != nil {
 		utils.LogErrorWithErr("Failed to parse token ID", err)
-		return nil, err
+		return nil, ErrParseError
 	}
 	tokenID := uint(tokenID64)
 
@@ -164,7 +251,7 @@ func (s *UserService) Refresh(req dto.RefreshRequest) (*dto.RefreshResponse, err
 	userID64, err := strconv.ParseUint(claims.UserID, 10, 32)
 	if err != nil {
 		utils.LogErrorWithErr("Failed to parse user ID", err)
-		return nil, err
+		return nil, ErrParseError
 	}
 	userID := uint(userID64)
 
@@ -173,47 +260,79 @@ func (s *UserService) Refresh(req dto.RefreshRequest) (*dto.RefreshResponse, err
 		Preload("User").
 		Preload("User.Claims").
 		Preload("User.Role.Claims").
-		Where("ID = ?", tokenID).
+		Where("id = ?", tokenID).
 		First(&token).Error; err != nil {
 		utils.LogErrorWithErr("Failed to find token", err)
 		return nil, ErrTokenNotFound
 	}
 
-	if userID != token.UserID {
+	if userID != token.UserID || token.Token != req.RefreshToken {
 		utils.LogError("Token and user mismatch")
 		return nil, ErrMismatchTokenAndUser
 	}
 
-	// merged role and user claims
-	claimNameSet := make(map[string]struct{})
-	for _, c := range token.User.Role.Claims {
-		claimNameSet[c.Name] = struct{}{}
-	}
-	for _, c := range token.User.Claims {
-		claimNameSet[c.Name] = struct{}{}
+	if token.IsRevoked {
+		if token.ReplacedByID != nil {
+			return nil, s.handleRefreshTokenReuse(&token)
+		}
+		utils.LogInfo("Refresh token revoked", "tokenID", token.ID)
+		return nil, ErrTokenRevoked
 	}
-	mergedClaimNames := make([]string, 9, len(claimNameSet))
-	for name := range claimNameSet {
-		mergedClaimNames = append(mergedClaimNames, name)
+
+	if token.ExpiresAt.Before(time.Now()) {
+		utils.LogInfo("Refresh token expired", "tokenID", token.ID)
+		return nil, ErrRefreshTokenExpired
 	}
 
-	accessToken, err := utils.GenerateAccessToken(
-		claims.UserID,
-		token.User.Email,
-		token.User.Username,
-		token.User.RoleID,
-		mergedClaimNames,
-	)
+	var tokens *issuedTokens
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		tokens, err = issueTokens(tx, &token.User, rotatedTokenSession(&token, client))
+		if err != nil {
+			return err
+		}
+
+		// Only the first refresh may rotate the token, concurrent ones are treated as reuse
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND is_revoked = ?", token.ID, false).
+			Updates(map[string]any{"is_revoked": true, "replaced_by_id": tokens.Record.ID})
+		if result.Error != nil {
+			utils.LogErrorWithErr("Failed to rotate refresh token", result.Error)
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+		return nil
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		return nil, s.handleRefreshTokenReuse(&token)
+	}
 	if err != nil {
-		utils.LogErrorWithErr("Failed to generate access token", err)
 		return nil, err
 	}
 
 	return &dto.RefreshResponse{
-		AccessToken: accessToken,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}, nil
 }
 
+// handleRefreshTokenReuse revokes the whole family of a rotated-out refresh token
+func (s *UserService) handleRefreshTokenReuse(token *models.RefreshToken) error {
+	utils.LogWarn("Security event: refresh token reuse detected, revoking token family",
+		"userID", token.UserID,
+		"tokenID", token.ID,
+		"familyID", token.FamilyID,
+	)
+
+	if err := revokeTokenFamily(s.DB, s.TokenDenylist, token.FamilyID); err != nil {
+		utils.LogErrorWithErr("Failed to revoke token family", err, "familyID", token.FamilyID)
+		return err
+	}
+
+	return ErrRefreshTokenReused
+}
+
 func (s *UserService) RequestPasswordReset(req dto.RequestPasswordResetRequest) (*dto.RequestPasswordResetResponse, error) {
 	utils.LogInfo("Requesting password reset", "email", req.Email)
 
@@ -275,39 +394,99 @@ func (s *UserService) RequestPasswordReset(req dto.RequestPasswordResetRequest)
 	return &dto.RequestPasswordResetResponse{IsSuccess: true}, nil
 }
 
-func (s *UserService) Logout(req dto.LogoutRequest) (*dto.LogoutResponse, error) {
-	if err := s.DB.Model(&models.RefreshToken{}).
-		Where("token = ?", req.RefreshToken).
-		Update("is_revoked", true).Error; err != nil {
-		utils.LogErrorWithErr("Failed to logout", err)
-		return &dto.LogoutResponse{IsSuccess: false}, err
+func (s *UserService) ValidatePasswordResetToken(req dto.ValidatePasswordResetTokenRequest) (*dto.ValidatePasswordResetTokenResponse, error) {
+	utils.LogInfo("Validating password reset token")
+
+	if _, err := s.findPasswordResetToken(s.DB, req.Token); err != nil {
+		return nil, err
 	}
 
-	return &dto.LogoutResponse{IsSuccess: true}, nil
+	return &dto.ValidatePasswordResetTokenResponse{IsValid: true}, nil
 }
 
-// SetClaims adds new claims and removes existing claims from the user
-func (s *UserService) SetClaims(userID uint, claimIDs []uint) error {
-	utils.LogInfo("Setting claims for user", "userID", userID)
+// ResetPassword redeems a password reset token, sets the new password and
+// revokes every outstanding refresh token of the user
+func (s *UserService) ResetPassword(req dto.ResetPasswordRequest) (*dto.ResetPasswordResponse, error) {
+	utils.LogInfo("Resetting password")
 
-	var user models.User
-	if err := s.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		utils.LogErrorWithErr("Failed to find user", err)
-		return ErrUserNotFound
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		resetToken, err := s.findPasswordResetToken(tx, req.Token)
+		if err != nil {
+			return err
+		}
+
+		// Consume the token before using it, so concurrent requests can't both redeem it.
+		// A rejected password rolls the transaction back and the token stays usable.
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND is_used = ?", resetToken.ID, false).
+			Update("is_used", true)
+		if result.Error != nil {
+			utils.LogErrorWithErr("Failed to mark password reset token as used", result.Error)
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			utils.LogInfo("Password reset token already used", "userID", resetToken.UserID)
+			return ErrResetTokenUsed
+		}
+
+		if err := s.PasswordPolicy.SetPassword(tx, &resetToken.User, req.Password); err != nil {
+			return err
+		}
+
+		if _, err := revokeRefreshTokens(tx, s.TokenDenylist, "user_id = ?", resetToken.UserID); err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ResetPasswordResponse{IsSuccess: true}, nil
+}
+
+// findPasswordResetToken returns the token record with its user if the token is still redeemable
+func (s *UserService) findPasswordResetToken(db *gorm.DB, token string) (*models.PasswordResetToken, error) {
+	var resetToken models.PasswordResetToken
+	if err := db.Preload("User").Where("token = ?", token).First(&resetToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogInfo("Password reset token not found")
+			return nil, ErrResetTokenNotFound
+		}
+		utils.LogErrorWithErr("Failed to find password reset token", err)
+		return nil, err
 	}
 
-	var claims []models.Claim
-	if err := s.DB.Where("id IN (?)", claimIDs).Find(&claims).Error; err != nil {
-		utils.LogErrorWithErr("Failed to find claims", err)
-		return ErrClaimsNotFound
+	if resetToken.IsUsed {
+		utils.LogInfo("Password reset token already used", "userID", resetToken.UserID)
+		return nil, ErrResetTokenUsed
 	}
 
-	user.Claims = claims
+	if resetToken.ExpiresAt.Before(time.Now()) {
+		utils.LogInfo("Password reset token expired", "userID", resetToken.UserID)
+		return nil, ErrResetTokenExpired
+	}
 
-	if err := s.DB.Save(&user).Error; err != nil {
-		utils.LogErrorWithErr("Failed to save user", err)
-		return err
+	return &resetToken, nil
+}
+
+// Logout ends the session the refresh token belongs to
+func (s *UserService) Logout(req dto.LogoutRequest) (*dto.LogoutResponse, error) {
+	var token models.RefreshToken
+	if err := s.DB.Where("token = ?", req.RefreshToken).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Nothing to revoke, the session is already gone
+			return &dto.LogoutResponse{IsSuccess: true}, nil
+		}
+		utils.LogErrorWithErr("Failed to find token", err)
+		return &dto.LogoutResponse{IsSuccess: false}, err
+	}
+
+	if err := revokeTokenFamily(s.DB, s.TokenDenylist, token.FamilyID); err != nil {
+		utils.LogErrorWithErr("Failed to logout", err)
+		return &dto.LogoutResponse{IsSuccess: false}, err
 	}
 
-	return nil
+	return &dto.LogoutResponse{IsSuccess: true}, nil
 }
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/authz"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAccountDisabled        = errors.New("account disabled")
+	ErrPasswordResetRequired  = errors.New("password reset required")
+	ErrClaimNotGrantable      = errors.New("claim is not granted to the caller")
+	ErrSelfAdministration     = errors.New("admins can't change their own account")
+	ErrAccountAlreadyDisabled = errors.New("account already disabled")
+	ErrAccountNotDisabled     = errors.New("account not disabled")
+)
+
+// ListUsers returns a page of the users matching the filters.
+// Username and email match partially, role and provider exactly.
+func (s *UserService) ListUsers(query dto.ListUsersQuery) (*dto.ListUsersResponse, error) {
+	utils.LogInfo("Listing users", "query", query)
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = defaultPageSize
+	}
+
+	db := s.DB.Model(&models.User{})
+	if query.Username != "" {
+		db = db.Where("users.username ILIKE ?", "%"+escapeLike(query.Username)+"%")
+	}
+	if query.Email != "" {
+		db = db.Where("users.email ILIKE ?", "%"+escapeLike(query.Email)+"%")
+	}
+	if query.Role != "" {
+		db = db.Joins("JOIN roles ON roles.id = users.role_id").Where("roles.name = ?", query.Role)
+	}
+	if query.Provider != "" {
+		db = db.Where("users.provider = ?", query.Provider)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		utils.LogErrorWithErr("Failed to count users", err)
+		return nil, err
+	}
+
+	var users []models.User
+	if err := db.Preload("Role").
+		Order("users.id").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&users).Error; err != nil {
+		utils.LogErrorWithErr("Failed to list users", err)
+		return nil, err
+	}
+
+	items := make([]dto.AdminUserResponse, len(users))
+	for i := range users {
+		items[i] = adminUserResponse(&users[i])
+	}
+
+	return &dto.ListUsersResponse{
+		Items:    items,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
+
+// GetUser returns the user with its role, direct and effective claims
+func (s *UserService) GetUser(userID uint) (*dto.AdminUserDetailResponse, error) {
+	utils.LogInfo("Getting user", "userID", userID)
+
+	var user models.User
+	if err := s.DB.
+		Preload("Role.Claims").
+		Preload("Claims").
+		Where("id = ?", userID).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+
+	effective := mergeClaimNames(&user)
+	slices.Sort(effective)
+	return &dto.AdminUserDetailResponse{
+		AdminUserResponse: adminUserResponse(&user),
+		RoleClaims:        claimNames(user.Role.Claims),
+		DirectClaims:      claimNames(user.Claims),
+		EffectiveClaims:   effective,
+	}, nil
+}
+
+// SetRole moves the user to another role. The caller must hold every claim of the role,
+// so an admin can't grant more than it has. Access tokens already issued keep their claims.
+func (s *UserService) SetRole(callerID uint, callerClaims []string, userID uint, req dto.SetUserRoleRequest) (*dto.AdminUserDetailResponse, error) {
+	utils.LogInfo("Setting role of user", "userID", userID, "roleID", req.RoleID)
+
+	if callerID == userID {
+		return nil, ErrSelfAdministration
+	}
+
+	var role models.Role
+	if err := s.DB.Preload("Claims").Where("id = ?", req.RoleID).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		utils.LogErrorWithErr("Failed to find role", err)
+		return nil, err
+	}
+	if err := checkGrantable(callerClaims, role.Claims); err != nil {
+		return nil, err
+	}
+
+	result := s.DB.Model(&models.User{}).Where("id = ?", userID).Update("role_id", role.ID)
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to set role of user", result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrUserNotFound
+	}
+
+	utils.LogInfo("Role of user changed", "userID", userID, "roleID", role.ID, "by", callerID)
+	return s.GetUser(userID)
+}
+
+// SetClaims replaces the direct claims of the user. The caller must hold every assigned claim.
+func (s *UserService) SetClaims(callerID uint, callerClaims []string, userID uint, claimIDs []uint) error {
+	utils.LogInfo("Setting claims for user", "userID", userID)
+
+	if callerID == userID {
+		return ErrSelfAdministration
+	}
+
+	var user models.User
+	if err := s.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		utils.LogErrorWithErr("Failed to find user", err)
+		return ErrUserNotFound
+	}
+
+	var claims []models.Claim
+	if len(claimIDs) > 0 {
+		if err := s.DB.Where("id IN (?)", claimIDs).Find(&claims).Error; err != nil {
+			utils.LogErrorWithErr("Failed to find claims", err)
+			return err
+		}
+		if len(claims) != len(slices.Compact(slices.Sorted(slices.Values(claimIDs)))) {
+			return ErrClaimsNotFound
+		}
+	}
+	if err := checkGrantable(callerClaims, claims); err != nil {
+		return err
+	}
+
+	if err := s.DB.Model(&user).Association("Claims").Replace(claims); err != nil {
+		utils.LogErrorWithErr("Failed to save user", err)
+		return err
+	}
+
+	return nil
+}
+
+// DisableUser blocks every login of the user and ends its sessions and impersonations
+func (s *UserService) DisableUser(callerID uint, userID uint) (*dto.AdminUserDetailResponse, error) {
+	utils.LogInfo("Disabling user", "userID", userID)
+
+	if callerID == userID {
+		return nil, ErrSelfAdministration
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.DisabledAt != nil {
+			return ErrAccountAlreadyDisabled
+		}
+
+		if err := tx.Model(user).Update("disabled_at", time.Now()).Error; err != nil {
+			utils.LogErrorWithErr("Failed to disable user", err)
+			return err
+		}
+		if _, err := revokeRefreshTokens(tx, s.TokenDenylist, "user_id = ?", userID); err != nil {
+			return err
+		}
+		return s.denyImpersonationTokens(tx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogWarn("Account disabled", "userID", userID, "by", callerID)
+	return s.GetUser(userID)
+}
+
+// EnableUser lifts the disabling of the user
+func (s *UserService) EnableUser(callerID uint, userID uint) (*dto.AdminUserDetailResponse, error) {
+	utils.LogInfo("Enabling user", "userID", userID)
+
+	if callerID == userID {
+		return nil, ErrSelfAdministration
+	}
+
+	result := s.DB.Model(&models.User{}).
+		Where("id = ? AND disabled_at IS NOT NULL", userID).
+		Update("disabled_at", nil)
+	if result.Error != nil {
+		utils.LogErrorWithErr("Failed to enable user", result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := s.DB.Where("id = ?", userID).First(&models.User{}).Error; err != nil {
+			return nil, ErrUserNotFound
+		}
+		return nil, ErrAccountNotDisabled
+	}
+
+	utils.LogWarn("Account enabled", "userID", userID, "by", callerID)
+	return s.GetUser(userID)
+}
+
+// ForcePasswordReset signs the user out everywhere and blocks every login method until the
+// password is reset with the link emailed to the user
+func (s *UserService) ForcePasswordReset(callerID uint, userID uint) (*dto.ForcePasswordResetResponse, error) {
+	utils.LogInfo("Forcing password reset", "userID", userID)
+
+	if callerID == userID {
+		return nil, ErrSelfAdministration
+	}
+
+	var user *models.User
+	var revoked int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(user).Update("password_reset_required", true).Error; err != nil {
+			utils.LogErrorWithErr("Failed to require password reset", err)
+			return err
+		}
+		revoked, err = revokeRefreshTokens(tx, s.TokenDenylist, "user_id = ?", userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogWarn("Password reset forced", "userID", userID, "by", callerID)
+
+	if _, err := s.RequestPasswordReset(dto.RequestPasswordResetRequest{Email: user.Email}); err != nil {
+		utils.LogErrorWithErr("Failed to send password reset email", err, "userID", userID)
+	}
+
+	return &dto.ForcePasswordResetResponse{IsSuccess: true, RevokedSessions: revoked}, nil
+}
+
+func lockUser(tx *gorm.DB, userID uint) (*models.User, error) {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		utils.LogErrorWithErr("Failed to find user", err)
+		return nil, err
+	}
+	return &user, nil
+}
+
+// checkGrantable verifies the caller holds every claim it is about to grant
+func checkGrantable(callerClaims []string, claims []models.Claim) error {
+	return checkGrantableSet(authz.NewClaimSet(callerClaims), claims)
+}
+
+func checkGrantableSet(granted authz.ClaimSet, claims []models.Claim) error {
+	for _, claim := range claims {
+		if !granted.Has(claim.Name) {
+			utils.LogWarn("Security event: grant of a claim the caller doesn't hold denied", "claim", claim.Name)
+			return ErrClaimNotGrantable
+		}
+	}
+	return nil
+}
+
+func adminUserResponse(user *models.User) dto.AdminUserResponse {
+	return dto.AdminUserResponse{
+		ID:                    user.ID,
+		Username:              user.Username,
+		Email:                 user.Email,
+		EmailVerified:         user.EmailVerified,
+		Provider:              user.Provider,
+		Role:                  dto.AdminUserRole{ID: user.Role.ID, Name: user.Role.Name},
+		TwoFactorEnabled:      user.TOTPEnabled,
+		DisabledAt:            user.DisabledAt,
+		PasswordResetRequired: user.PasswordResetRequired,
+		LockedUntil:           user.LockedUntil,
+		DeletionScheduledAt:   user.DeletionScheduledAt,
+		CreatedAt:             user.CreatedAt,
+		UpdatedAt:             user.UpdatedAt,
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+func TestEnableUserRejectsSelfAdministration(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin")
+	service := NewUserService(db, config.Auth{}, NewMemoryTokenDenylist())
+
+	if _, err := service.EnableUser(admin.ID, admin.ID); !errors.Is(err, ErrSelfAdministration) {
+		t.Errorf("EnableUser() error = %v, want %v", err, ErrSelfAdministration)
+	}
+}
+
+// forcePasswordReset forces the reset of the user's password by another admin
+func forcePasswordReset(t *testing.T, db *gorm.DB, userID uint) {
+	t.Helper()
+
+	service := NewUserService(db, config.Auth{}, NewMemoryTokenDenylist())
+	if _, err := service.ForcePasswordReset(userID+1, userID); err != nil {
+		t.Fatalf("ForcePasswordReset() error = %v", err)
+	}
+}
+
+func TestForcedPasswordResetBlocksLogins(t *testing.T) {
+	t.Run("password", func(t *testing.T) {
+		db := newTestDB(t)
+		user := createTestUser(t, db, "alice")
+		forcePasswordReset(t, db, user.ID)
+
+		service := NewUserService(db, config.Auth{}, NewMemoryTokenDenylist())
+		_, err := service.Login(dto.LoginRequest{Email: user.Email, Password: "Password123!"}, dto.SessionInfo{})
+		if !errors.Is(err, ErrPasswordResetRequired) {
+			t.Errorf("Login() error = %v, want %v", err, ErrPasswordResetRequired)
+		}
+	})
+
+	t.Run("magic link", func(t *testing.T) {
+		service, user := newTestMagicLinkService(t)
+		token := "magic-link-token"
+		if err := service.DB.Create(&models.MagicLinkToken{
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(time.Minute),
+			UserID:    user.ID,
+		}).Error; err != nil {
+			t.Fatalf("create magic link: %v", err)
+		}
+		forcePasswordReset(t, service.DB, user.ID)
+
+		_, err := service.VerifyMagicLink(dto.VerifyMagicLinkRequest{Token: token}, dto.SessionInfo{}, "")
+		if !errors.Is(err, ErrPasswordResetRequired) {
+			t.Errorf("VerifyMagicLink() error = %v, want %v", err, ErrPasswordResetRequired)
+		}
+	})
+
+	t.Run("passkey", func(t *testing.T) {
+		service, db := newTestPasskeyService(t)
+		user := createTestUser(t, db, "alice")
+		authenticator := newSoftAuthenticator(t)
+		if _, err := registerPasskey(t, service, user.ID, authenticator); err != nil {
+			t.Fatalf("FinishRegistration() error = %v", err)
+		}
+		forcePasswordReset(t, db, user.ID)
+
+		authenticator.signCount = 1
+		if _, _, _, err := loginWithPasskey(t, service, authenticator); !errors.Is(err, ErrPasswordResetRequired) {
+			t.Errorf("FinishLogin() error = %v, want %v", err, ErrPasswordResetRequired)
+		}
+	})
+
+	t.Run("oauth", func(t *testing.T) {
+		idp := newMockIdP(t)
+		service, db := newTestOAuthService(t, idp, config.Auth{OAuthAutoLinkVerifiedEmail: true})
+		idp.claims = jwt.MapClaims{
+			"sub":            "subject-1",
+			"nonce":          testOIDCNonce,
+			"email":          "existing@example.com",
+			"email_verified": true,
+		}
+		var user models.User
+		if err := db.Where("email = ?", "existing@example.com").First(&user).Error; err != nil {
+			t.Fatalf("find user: %v", err)
+		}
+		forcePasswordReset(t, db, user.ID)
+
+		if _, err := handleTestCallback(service); !errors.Is(err, ErrPasswordResetRequired) {
+			t.Errorf("HandleCallback() error = %v, want %v", err, ErrPasswordResetRequired)
+		}
+	})
+
+	t.Run("personal access token", func(t *testing.T) {
+		db := newTestDB(t)
+		user := createTestUser(t, db, "alice")
+		if err := db.Create(&models.Claim{Name: "user:read"}).Error; err != nil {
+			t.Fatalf("create claim: %v", err)
+		}
+		service := NewPersonalAccessTokenService(db, NewPolicies())
+		created, err := service.CreateToken(user.ID, []string{"user:read"}, dto.CreatePersonalAccessTokenRequest{
+			Name:          "ci",
+			Scopes:        []string{"user:read"},
+			ExpiresInDays: 30,
+		})
+		if err != nil {
+			t.Fatalf("CreateToken() error = %v", err)
+		}
+		forcePasswordReset(t, db, user.ID)
+
+		if _, err := service.VerifyPersonalAccessToken(created.Token); !errors.Is(err, ErrPasswordResetRequired) {
+			t.Errorf("VerifyPersonalAccessToken() error = %v, want %v", err, ErrPasswordResetRequired)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"knowstack/internal/api/dto"
+	"knowstack/internal/core/config"
+	"knowstack/internal/data/models"
+	"knowstack/internal/utils"
+	"strings"
+	"testing"
+)
+
+func TestLoginRehashesOutdatedHash(t *testing.T) {
+	t.Setenv("ARGON2_MEMORY_KB", "1024")
+	t.Setenv("ARGON2_ITERATIONS", "1")
+	t.Setenv("ARGON2_PARALLELISM", "1")
+
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	service := NewUserService(db, config.Auth{}, nil)
+
+	// The cost was raised since the password was hashed
+	t.Setenv("ARGON2_ITERATIONS", "2")
+	if !utils.NeedsRehash(user.Password) {
+		t.Fatalf("hash %q doesn't need a rehash", user.Password)
+	}
+
+	if _, err := service.Login(dto.LoginRequest{Email: "alice@example.com", Password: "Password123!"}, dto.SessionInfo{}); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	var stored models.User
+	if err := db.First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+	if !strings.HasPrefix(stored.Password, "v2:argon2id:m=1024,t=2,p=1:") {
+		t.Fatalf("stored password = %q, want an argon2id hash of the new cost", stored.Password)
+	}
+	if !utils.VerifyPassword("Password123!", stored.Password) {
+		t.Errorf("the rehashed password doesn't verify")
+	}
+
+	if _, err := service.Login(dto.LoginRequest{Email: "alice@example.com", Password: "Password123!"}, dto.SessionInfo{}); err != nil {
+		t.Errorf("Login() with the rehashed password error = %v", err)
+	}
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	db := newTestDB(t)
+	createTestUser(t, db, "alice")
+	denylist := NewMemoryTokenDenylist()
+	service := NewUserService(db, config.Auth{}, denylist)
+
+	login, err := service.Login(dto.LoginRequest{Email: "alice@example.com", Password: "Password123!"}, dto.SessionInfo{})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	rotated, err := service.Refresh(dto.RefreshRequest{RefreshToken: login.RefreshToken}, dto.SessionInfo{})
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if rotated.RefreshToken == login.RefreshToken {
+		t.Fatalf("Refresh() returned the presented refresh token")
+	}
+
+	var old models.RefreshToken
+	if err := db.Where("token = ?", login.RefreshToken).First(&old).Error; err != nil {
+		t.Fatalf("find rotated-out token: %v", err)
+	}
+	if !old.IsRevoked || old.ReplacedByID == nil {
+		t.Fatalf("rotated-out token revoked = %t, replaced = %t, want both", old.IsRevoked, old.ReplacedByID != nil)
+	}
+
+	// The rotated-out token is presented again, the whole session ends
+	if _, err := service.Refresh(dto.RefreshRequest{RefreshToken: login.RefreshToken}, dto.SessionInfo{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("Refresh() with the rotated-out token error = %v, want %v", err, ErrRefreshTokenReused)
+	}
+	var active int64
+	db.Model(&models.RefreshToken{}).Where("family_id = ? AND is_revoked = ?", old.FamilyID, false).Count(&active)
+	if active != 0 {
+		t.Errorf("active tokens of the family = %d, want 0", active)
+	}
+	if _, err := service.Refresh(dto.RefreshRequest{RefreshToken: rotated.RefreshToken}, dto.SessionInfo{}); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Refresh() with the latest token error = %v, want %v", err, ErrTokenRevoked)
+	}
+
+	var current models.RefreshToken
+	if err := db.Where("token = ?", rotated.RefreshToken).First(&current).Error; err != nil {
+		t.Fatalf("find latest token: %v", err)
+	}
+	if revoked, _ := denylist.IsRevoked(current.AccessTokenJTI); !revoked {
+		t.Errorf("access token of the session isn't denied")
+	}
+}
@@ -4,15 +4,37 @@ import (
 	"errors"
 	"knowstack/internal/data/models"
 	"knowstack/internal/utils"
+
+	"gorm.io/gorm"
 )
 
 func AutoMigrate() error {
-	err := db.AutoMigrate(&models.Role{}, &models.Claim{}, &models.User{}, &models.RefreshToken{}, &models.PasswordResetToken{})
+	// Roles created before two-factor requirements existed are upgraded below
+	upgradeRoles := db.Migrator().HasTable(&models.Role{}) && !db.Migrator().HasColumn(&models.Role{}, "requires_two_factor")
+
+	err := db.AutoMigrate(&models.Role{}, &models.Claim{}, &models.User{}, &models.RefreshToken{}, &models.PasswordResetToken{}, &models.RecoveryCode{}, &models.MFAChallenge{}, &models.PasskeyCredential{}, &models.PasskeyChallenge{}, &models.UserIdentity{}, &models.RevokedToken{}, &models.LoginAttempt{}, &models.PersonalAccessToken{}, &models.Impersonation{}, &models.MagicLinkToken{}, &models.PasswordHistory{}, &models.Organization{}, &models.OrganizationMembership{}, &models.OrganizationInvitation{})
 
 	if err != nil {
 		return errors.New("failed to auto migrate the database")
 	}
 
+	if err := migrateUserIdentities(); err != nil {
+		utils.LogErrorWithErr("Failed to migrate user identities", err)
+		return err
+	}
+
+	if upgradeRoles {
+		if err := db.Model(&models.Role{}).Where("name = ?", "admin").Update("requires_two_factor", true).Error; err != nil {
+			utils.LogErrorWithErr("Failed to require two-factor authentication for the admin role", err)
+			return err
+		}
+	}
+
+	if err := encryptTOTPSecrets(); err != nil {
+		utils.LogErrorWithErr("Failed to encrypt TOTP secrets", err)
+		return err
+	}
+
 	utils.LogInfo("Auto migration completed")
 
 	// Run seed data
@@ -23,3 +45,70 @@ func AutoMigrate() error {
 
 	return nil
 }
+
+/*
+Move the external accounts stored on the users table into user_identities.
+The google_id and external_id columns are dropped once their rows are copied.
+*/
+func migrateUserIdentities() error {
+	migrator := db.Migrator()
+	hasGoogleID := migrator.HasColumn(&models.User{}, "google_id")
+	hasExternalID := migrator.HasColumn(&models.User{}, "external_id")
+	if !hasGoogleID && !hasExternalID {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if hasGoogleID {
+			if err := tx.Exec(`INSERT INTO user_identities (provider, subject, email, user_id, created_at, updated_at)
+				SELECT 'google', google_id, email, id, NOW(), NOW() FROM users
+				WHERE google_id IS NOT NULL AND google_id <> ''
+				ON CONFLICT DO NOTHING`).Error; err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&models.User{}, "google_id"); err != nil {
+				return err
+			}
+		}
+
+		if hasExternalID {
+			if err := tx.Exec(`INSERT INTO user_identities (provider, subject, email, user_id, created_at, updated_at)
+				SELECT split_part(external_id, ':', 1), substr(external_id, strpos(external_id, ':') + 1), email, id, NOW(), NOW() FROM users
+				WHERE external_id IS NOT NULL AND strpos(external_id, ':') > 0
+				ON CONFLICT DO NOTHING`).Error; err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&models.User{}, "external_id"); err != nil {
+				return err
+			}
+		}
+
+		utils.LogInfo("Moved linked accounts to user identities")
+		return nil
+	})
+}
+
+// encryptTOTPSecrets encrypts the TOTP secrets stored before they were encrypted at rest
+func encryptTOTPSecrets() error {
+	var users []models.User
+	if err := db.Select("id", "totp_secret").
+		Where("totp_secret <> '' AND totp_secret NOT LIKE ?", "enc:%").
+		Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		encrypted, err := utils.EncryptTOTPSecret(user.TOTPSecret)
+		if err != nil {
+			return err
+		}
+		if err := db.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumn("totp_secret", encrypted).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(users) > 0 {
+		utils.LogInfo("Encrypted TOTP secrets", "count", len(users))
+	}
+	return nil
+}
@@ -6,25 +6,11 @@ import (
 )
 
 func SeedData() error {
-	// Seed Roles
-	roles := []models.Role{
-		{Name: "user", IsDefault: true},
-		{Name: "admin", IsDefault: false},
-	}
-
-	for _, role := range roles {
-		var existingRole models.Role
-		if err := db.Where("name = ?", role.Name).First(&existingRole).Error; err != nil {
-			// Role doesn't exist, create it
-			if err := db.Create(&role).Error; err != nil {
-				utils.LogError("Failed to create role: " + role.Name)
-				return err
-			}
-		}
-	}
-
 	// Seed Claims
 	claims := []models.Claim{
+		// Grants every claim, see authz.ClaimSet
+		{Name: "*"},
+
 		// User claims
 		{Name: "user:read"},
 		{Name: "user:write"},
@@ -32,6 +18,8 @@ func SeedData() error {
 		{Name: "user:update"},
 		{Name: "user:refresh"},
 		{Name: "user:logout"},
+		{Name: "user:impersonate"},
+		{Name: "user:export"},
 
 		// Claim claims
 		{Name: "claim:read"},
@@ -44,37 +32,68 @@ func SeedData() error {
 		{Name: "role:write"},
 		{Name: "role:delete"},
 		{Name: "role:update"},
+
+		// Organization claims, granted in an organization by the role of the membership
+		{Name: "organization:create"},
+		{Name: "organization:read"},
+		{Name: "organization:update"},
+		{Name: "organization:delete"},
+		{Name: "organization:member:read"},
+		{Name: "organization:member:write"},
+		{Name: "organization:member:delete"},
 	}
 
 	for _, claim := range claims {
-		var existingClaim models.Claim
-		if err := db.Where("name = ?", claim.Name).First(&existingClaim).Error; err != nil {
-			// Claim doesn't exist, create it
-			if err := db.Create(&claim).Error; err != nil {
-				utils.LogError("Failed to create claim: " + claim.Name)
-				return err
-			}
+		// A deleted claim keeps its row, it was deleted by an operator through the claim API
+		// and isn't created again, see ClaimService.DeleteClaim
+		if err := db.Unscoped().Where("name = ?", claim.Name).First(&models.Claim{}).Error; err == nil {
+			continue
 		}
-	}
-
-	// Assign all claims to admin role
-	var adminRole models.Role
-	if err := db.Where("name = ?", "admin").First(&adminRole).Error; err == nil {
-		var allClaims []models.Claim
-		db.Find(&allClaims)
-		if err := db.Model(&adminRole).Association("Claims").Replace(&allClaims); err != nil {
-			utils.LogError("Failed to assign claims to admin role")
+		if err := db.Create(&claim).Error; err != nil {
+			utils.LogError("Failed to create claim: " + claim.Name)
 			return err
 		}
 	}
 
-	// Assign basic read claims to user role
-	var userRole models.Role
-	if err := db.Where("name = ?", "user").First(&userRole).Error; err == nil {
-		var userClaims []models.Claim
-		db.Where("name IN ?", []string{"user:read", "claim:read", "role:read"}).Find(&userClaims)
-		if err := db.Model(&userRole).Association("Claims").Replace(&userClaims); err != nil {
-			utils.LogError("Failed to assign claims to user role")
+	// Seed Roles with their initial claims. Claims are only assigned when the role is created,
+	// so changes made through the role API are kept.
+	roles := []struct {
+		role   models.Role
+		claims []string
+	}{
+		// nil claims assign every claim
+		{role: models.Role{Name: "admin", IsDefault: false, RequiresTwoFactor: true}},
+		{role: models.Role{Name: "user", IsDefault: true}, claims: []string{"user:read", "claim:read", "role:read", "organization:create"}},
+		// Roles assigned inside an organization
+		{role: models.Role{Name: "organization_owner", IsDefault: false}, claims: []string{
+			"organization:read", "organization:update", "organization:delete",
+			"organization:member:read", "organization:member:write", "organization:member:delete",
+		}},
+		{role: models.Role{Name: "organization_member", IsDefault: false}, claims: []string{"organization:read", "organization:member:read"}},
+	}
+
+	for _, seed := range roles {
+		role := seed.role
+		if err := db.Where("name = ?", role.Name).First(&models.Role{}).Error; err == nil {
+			continue
+		}
+		// A renamed default role is still the default, a second one isn't created
+		if role.IsDefault {
+			if err := db.Where("is_default = ?", true).First(&models.Role{}).Error; err == nil {
+				continue
+			}
+		}
+
+		query := db
+		if seed.claims != nil {
+			query = db.Where("name IN ?", seed.claims)
+		}
+		if err := query.Find(&role.Claims).Error; err != nil {
+			utils.LogError("Failed to find claims of role: " + role.Name)
+			return err
+		}
+		if err := db.Create(&role).Error; err != nil {
+			utils.LogError("Failed to create role: " + role.Name)
 			return err
 		}
 	}
@@ -86,11 +105,12 @@ func SeedData() error {
 		var adminRoleForUser models.Role
 		if err := db.Where("name = ?", "admin").First(&adminRoleForUser).Error; err == nil {
 			adminUser := models.User{
-				Username: "admin",
-				Email:    "admin@knowstack.com",
-				Password: "admin123", // This will be hashed by BeforeCreate hook
-				Provider: "local",
-				RoleID:   adminRoleForUser.ID,
+				Username:      "admin",
+				Email:         "admin@knowstack.com",
+				Password:      "admin123", // This will be hashed by BeforeCreate hook
+				Provider:      "local",
+				RoleID:        adminRoleForUser.ID,
+				EmailVerified: true,
 			}
 			if err := db.Create(&adminUser).Error; err != nil {
 				utils.LogError("Failed to create admin user")
@@ -99,6 +119,12 @@ func SeedData() error {
 		}
 	}
 
+	// The admin role requires two-factor authentication, until it is enrolled the sessions of the admin are restricted
+	var adminUser models.User
+	if err := db.Where("username = ?", "admin").First(&adminUser).Error; err == nil && !adminUser.TOTPEnabled {
+		utils.LogWarn("Default admin user has no two-factor authentication enabled, its sessions are restricted until it is enrolled via /api/v1/users/me/2fa")
+	}
+
 	utils.LogInfo("Seed data completed successfully")
 	return nil
 }
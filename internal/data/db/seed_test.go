@@ -0,0 +1,95 @@
+package db
+
+import (
+	"knowstack/internal/data/models"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useTestDB points the package at an empty in-memory database for the test
+func useTestDB(t *testing.T) {
+	t.Helper()
+
+	testDB, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := testDB.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := testDB.AutoMigrate(&models.Role{}, &models.Claim{}, &models.User{}); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	previous := db
+	db = testDB
+	t.Cleanup(func() {
+		db = previous
+		sqlDB.Close()
+	})
+}
+
+func seed(t *testing.T) {
+	t.Helper()
+	if err := SeedData(); err != nil {
+		t.Fatalf("SeedData() error = %v", err)
+	}
+}
+
+func TestSeedKeepsRoleChanges(t *testing.T) {
+	useTestDB(t)
+	seed(t)
+
+	var role models.Role
+	if err := db.Preload("Claims").Where("name = ?", "user").First(&role).Error; err != nil {
+		t.Fatalf("find role: %v", err)
+	}
+	if len(role.Claims) == 0 {
+		t.Fatalf("seeded role has no claims")
+	}
+	if err := db.Model(&role).Association("Claims").Clear(); err != nil {
+		t.Fatalf("clear claims: %v", err)
+	}
+	if err := db.Model(&role).Update("name", "member").Error; err != nil {
+		t.Fatalf("rename role: %v", err)
+	}
+
+	seed(t)
+
+	var defaults int64
+	db.Model(&models.Role{}).Where("is_default = ?", true).Count(&defaults)
+	if defaults != 1 {
+		t.Errorf("default roles = %d, want 1", defaults)
+	}
+	if err := db.Preload("Claims").First(&role, role.ID).Error; err != nil {
+		t.Fatalf("find role: %v", err)
+	}
+	if len(role.Claims) != 0 {
+		t.Errorf("claims of the role after seeding again = %v, want none", role.Claims)
+	}
+}
+
+func TestSeedKeepsDeletedClaims(t *testing.T) {
+	useTestDB(t)
+	seed(t)
+
+	if err := db.Where("name = ?", "user:export").Delete(&models.Claim{}).Error; err != nil {
+		t.Fatalf("delete claim: %v", err)
+	}
+
+	seed(t)
+
+	if err := db.Where("name = ?", "user:export").First(&models.Claim{}).Error; err == nil {
+		t.Errorf("claim deleted by an operator was seeded again")
+	}
+	var rows int64
+	db.Unscoped().Model(&models.Claim{}).Where("name = ?", "user:export").Count(&rows)
+	if rows != 1 {
+		t.Errorf("rows of the deleted claim = %d, want 1", rows)
+	}
+}
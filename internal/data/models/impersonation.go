@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Impersonation is the audit record of an admin acting as another user
+type Impersonation struct {
+	ID        uint   `gorm:"primaryKey"`
+	ActorID   uint   `gorm:"not null;index"`
+	Actor     User   `gorm:"foreignKey:ActorID"`
+	SubjectID uint   `gorm:"not null;index"`
+	Subject   User   `gorm:"foreignKey:SubjectID"`
+	Reason    string `gorm:"type:text;not null"`
+	TokenJTI  string `gorm:"index"`
+	// SessionID is the token family of the admin's session the token belongs to
+	SessionID string     `gorm:"index"`
+	IPAddress string     `gorm:""`
+	UserAgent string     `gorm:""`
+	ExpiresAt time.Time  `gorm:"not null"`
+	EndedAt   *time.Time `gorm:""`
+	CreatedAt time.Time  `gorm:"autoCreateTime;index"`
+}
+
+func (Impersonation) TableName() string {
+	return "impersonations"
+}
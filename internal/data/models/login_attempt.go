@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// LoginAttempt records a password login for throttling and auditing.
+// UserID is nil when no account matched the email.
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey"`
+	Email     string    `gorm:"index"`
+	IPAddress string    `gorm:"index"`
+	Succeeded bool      `gorm:"default:false"`
+	UserID    *uint     `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// MagicLinkToken is a single-use passwordless login link. Only hashes of the token
+// and of the optional browser binding are stored.
+type MagicLinkToken struct {
+	ID          uint       `gorm:"primaryKey"`
+	TokenHash   string     `gorm:"uniqueIndex;not null"`
+	BindingHash string     `gorm:""`
+	Remember    bool       `gorm:"default:false"`
+	IPAddress   string     `gorm:""`
+	ExpiresAt   time.Time  `gorm:"not null;index"`
+	UsedAt      *time.Time `gorm:""`
+	UserID      uint       `gorm:"not null;index"`
+	User        User       `gorm:"foreignKey:UserID"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+}
+
+func (MagicLinkToken) TableName() string {
+	return "magic_link_tokens"
+}
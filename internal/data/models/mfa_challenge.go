@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// MFAChallenge tracks the second factor attempts of a login, TokenID is the jti of the challenge token
+type MFAChallenge struct {
+	ID        uint       `gorm:"primaryKey"`
+	TokenID   string     `gorm:"uniqueIndex;not null"`
+	Attempts  int        `gorm:"default:0"`
+	UsedAt    *time.Time `gorm:""`
+	ExpiresAt time.Time  `gorm:"index;not null"`
+	UserID    uint       `gorm:"not null;index"`
+	User      User       `gorm:"foreignKey:UserID"`
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+func (MFAChallenge) TableName() string {
+	return "mfa_challenges"
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Organization is a workspace of a team, users join it through memberships
+type Organization struct {
+	ID        uint      `gorm:"primaryKey"`
+	Name      string    `gorm:"unique not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// OrganizationMembership assigns the user a role inside the organization.
+// The claims of the role are only granted to requests made in that organization.
+type OrganizationMembership struct {
+	ID             uint         `gorm:"primaryKey"`
+	OrganizationID uint         `gorm:"not null;uniqueIndex:idx_organization_memberships_member"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+	UserID         uint         `gorm:"not null;uniqueIndex:idx_organization_memberships_member;index"`
+	User           User         `gorm:"foreignKey:UserID"`
+	RoleID         uint         `gorm:"not null;index"`
+	Role           Role         `gorm:"foreignKey:RoleID"`
+	CreatedAt      time.Time    `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time    `gorm:"autoUpdateTime"`
+}
+
+func (OrganizationMembership) TableName() string {
+	return "organization_memberships"
+}
+
+// OrganizationInvitation offers the role in the organization to the owner of the email.
+// The membership is only created when the invited user accepts it.
+type OrganizationInvitation struct {
+	ID             uint         `gorm:"primaryKey"`
+	OrganizationID uint         `gorm:"not null;uniqueIndex:idx_organization_invitations_email"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+	Email          string       `gorm:"not null;uniqueIndex:idx_organization_invitations_email;index"`
+	RoleID         uint         `gorm:"not null;index"`
+	Role           Role         `gorm:"foreignKey:RoleID"`
+	InvitedByID    uint         `gorm:"not null"`
+	ExpiresAt      time.Time    `gorm:"not null"`
+	CreatedAt      time.Time    `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time    `gorm:"autoUpdateTime"`
+}
+
+func (OrganizationInvitation) TableName() string {
+	return "organization_invitations"
+}
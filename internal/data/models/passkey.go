@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// PasskeyCredential is a WebAuthn public key credential registered by a user
+type PasskeyCredential struct {
+	ID              uint       `gorm:"primaryKey"`
+	Name            string     `gorm:""`
+	CredentialID    []byte     `gorm:"uniqueIndex;not null"`
+	PublicKey       []byte     `gorm:"not null"`
+	AttestationType string     `gorm:""`
+	Transports      string     `gorm:""`
+	Flags           uint8      `gorm:"default:0"`
+	AAGUID          []byte     `gorm:""`
+	SignCount       uint32     `gorm:"default:0"`
+	CloneWarning    bool       `gorm:"default:false"`
+	LastUsedAt      *time.Time `gorm:""`
+	UserID          uint       `gorm:"not null;index"`
+	User            User       `gorm:"foreignKey:UserID"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime"`
+}
+
+func (PasskeyCredential) TableName() string {
+	return "passkey_credentials"
+}
+
+// PasskeyChallenge stores the server side state of a WebAuthn ceremony until it is finished
+type PasskeyChallenge struct {
+	ID           uint      `gorm:"primaryKey"`
+	ChallengeKey string    `gorm:"uniqueIndex;not null"`
+	Ceremony     string    `gorm:"not null"`
+	SessionData  string    `gorm:"type:text;not null"`
+	UserID       *uint     `gorm:""`
+	ExpiresAt    time.Time `gorm:"not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+func (PasskeyChallenge) TableName() string {
+	return "passkey_challenges"
+}
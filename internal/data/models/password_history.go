@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PasswordHistory keeps the hashes of previous passwords so they can't be reused
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey"`
+	UserID       uint      `gorm:"not null;index"`
+	PasswordHash string    `gorm:"not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime;index"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_histories"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PersonalAccessToken is a long lived API token created by a user for automation.
+// Only the SHA-256 hash of the token is stored; Claims are the scopes it is limited to.
+type PersonalAccessToken struct {
+	ID         uint       `gorm:"primaryKey"`
+	Name       string     `gorm:"not null"`
+	TokenHash  string     `gorm:"uniqueIndex;not null"`
+	Prefix     string     `gorm:"not null"`
+	Claims     []Claim    `gorm:"many2many:personal_access_token_claims;"`
+	ExpiresAt  time.Time  `gorm:"not null;index"`
+	LastUsedAt *time.Time `gorm:""`
+	UserID     uint       `gorm:"not null;index"`
+	User       User       `gorm:"foreignKey:UserID"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+}
+
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RecoveryCode is a single-use two-factor backup code, only its hash is stored
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey"`
+	CodeHash  string     `gorm:"index;not null"`
+	UsedAt    *time.Time `gorm:""`
+	UserID    uint       `gorm:"not null;index"`
+	User      User       `gorm:"foreignKey:UserID"`
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
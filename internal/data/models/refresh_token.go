@@ -2,15 +2,28 @@ package models
 
 import "time"
 
+// RefreshToken is a session record. Rotated tokens share the FamilyID, which
+// identifies the session (device) across refreshes.
 type RefreshToken struct {
-	ID        uint      `gorm:"primaryKey"`
-	Token     string    `gorm:"index"`
-	ExpiresAt time.Time `gorm:""`
-	IsRevoked bool      `gorm:"default:false"`
-	UserID    uint      `gorm:"not null"`
-	User      User      `gorm:"foreignKey:UserID"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID               uint      `gorm:"primaryKey"`
+	Token            string    `gorm:"index"`
+	FamilyID         string    `gorm:"index"`
+	Remember         bool      `gorm:"default:false"`
+	ExpiresAt        time.Time `gorm:""`
+	IsRevoked        bool      `gorm:"default:false"`
+	ReplacedByID     *uint     `gorm:""`
+	UserAgent        string    `gorm:""`
+	IPAddress        string    `gorm:""`
+	DeviceLabel      string    `gorm:""`
+	SessionStartedAt time.Time `gorm:""`
+	LastUsedAt       time.Time `gorm:""`
+	// AccessTokenJTI identifies the access token issued together with this refresh token
+	AccessTokenJTI       string    `gorm:"index"`
+	AccessTokenExpiresAt time.Time `gorm:""`
+	UserID               uint      `gorm:"not null"`
+	User                 User      `gorm:"foreignKey:UserID"`
+	CreatedAt            time.Time `gorm:"autoCreateTime"`
+	UpdatedAt            time.Time `gorm:"autoUpdateTime"`
 }
 
 func (RefreshToken) TableName() string {
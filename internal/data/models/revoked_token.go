@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RevokedToken denies an access token by its jti until the token expires
+type RevokedToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	JTI       string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
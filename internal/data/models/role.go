@@ -3,12 +3,14 @@ package models
 import "time"
 
 type Role struct {
-	ID        uint      `gorm:"primaryKey"`
-	Name      string    `gorm:"unique not null"`
-	IsDefault bool      `gorm:"default:false"`
-	Claims    []Claim   `gorm:"many2many:role_claims;"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"unique not null"`
+	IsDefault bool   `gorm:"default:false"`
+	// RequiresTwoFactor restricts the sessions of members without two-factor authentication to their own account
+	RequiresTwoFactor bool      `gorm:"default:false"`
+	Claims            []Claim   `gorm:"many2many:role_claims;"`
+	CreatedAt         time.Time `gorm:"autoCreateTime"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime"`
 }
 
 func (Role) TableName() string {
@@ -8,18 +8,32 @@ import (
 )
 
 type User struct {
-	ID           uint      `gorm:"primaryKey"`
-	Username     string    `gorm:"unique"`
-	Email        string    `gorm:"unique"`
-	Password     string    `gorm:""`
-	RoleID       uint      `gorm:"not null"`
-	Role         Role      `gorm:"foreignKey:RoleID"`
-	Claims       []Claim   `gorm:"many2many:user_claims;"`
-	GoogleID     string    `gorm:"uniqueIndex"`
-	Provider     string    `gorm:"default:local"`
-	ProfileImage string    `gorm:""`
-	CreatedAt    time.Time `gorm:"autoCreateTime"`
-	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+	ID               uint       `gorm:"primaryKey"`
+	Username         string     `gorm:"unique"`
+	Email            string     `gorm:"unique"`
+	EmailVerified    bool       `gorm:"default:false"`
+	EmailVerifiedAt  *time.Time `gorm:""`
+	Password         string     `gorm:""`
+	RoleID           uint       `gorm:"not null"`
+	Role             Role       `gorm:"foreignKey:RoleID"`
+	Claims           []Claim    `gorm:"many2many:user_claims;"`
+	Provider         string     `gorm:"default:local"`
+	ProfileImage     string     `gorm:""`
+	TOTPSecret       string     `gorm:""`
+	TOTPEnabled      bool       `gorm:"default:false"`
+	TOTPLastUsedStep int64      `gorm:"default:0"`
+	PasskeyHandle    []byte     `gorm:"uniqueIndex"`
+	FailedLoginCount int        `gorm:"default:0"`
+	LockedUntil      *time.Time `gorm:"index"`
+	// DisabledAt is set while an admin has disabled the account
+	DisabledAt *time.Time `gorm:"index"`
+	// PasswordResetRequired blocks every login method until the password is reset
+	PasswordResetRequired bool `gorm:"default:false"`
+	// DeletionScheduledAt is when a requested account deletion is carried out
+	DeletionScheduledAt *time.Time `gorm:"index"`
+	AnonymizedAt        *time.Time
+	CreatedAt           time.Time `gorm:"autoCreateTime"`
+	UpdatedAt           time.Time `gorm:"autoUpdateTime"`
 }
 
 func (User) TableName() string {
@@ -28,14 +42,56 @@ func (User) TableName() string {
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	if u.Provider == "local" {
-		u.Password = utils.HashPassword(u.Password)
+		u.Password, err = utils.HashPassword(u.Password)
 	}
 	return
 }
 
+// BeforeUpdate hashes the password when it is set through Update/Updates.
+// Save calls with an unchanged password keep the stored hash as is.
 func (u *User) BeforeUpdate(tx *gorm.DB) (err error) {
-	if u.Provider == "" && tx.Statement.Changed("Password") {
-		u.Password = utils.HashPassword(u.Password)
+	if !tx.Statement.Changed("Password") {
+		return
+	}
+	if plain := updatedPassword(tx.Statement.Dest); plain != "" {
+		hashed, err := utils.HashPassword(plain)
+		if err != nil {
+			return err
+		}
+		setUpdatedPassword(tx.Statement, hashed)
 	}
 	return
 }
+
+// setUpdatedPassword replaces the plain password of the update with its hash
+func setUpdatedPassword(stmt *gorm.Statement, hashed string) {
+	// SetColumn would add a "Password" key next to a "password" one, and which of
+	// the two ends up in the query depends on the map order
+	if d, ok := stmt.Dest.(map[string]any); ok {
+		for _, key := range []string{"password", "Password"} {
+			if _, ok := d[key]; ok {
+				d[key] = hashed
+			}
+		}
+		return
+	}
+	stmt.SetColumn("Password", hashed)
+}
+
+// updatedPassword extracts the new plain password from the update destination
+func updatedPassword(dest any) string {
+	switch d := dest.(type) {
+	case map[string]any:
+		if v, ok := d["password"].(string); ok {
+			return v
+		}
+		if v, ok := d["Password"].(string); ok {
+			return v
+		}
+	case User:
+		return d.Password
+	case *User:
+		return d.Password
+	}
+	return ""
+}
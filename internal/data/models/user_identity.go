@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// UserIdentity is an external OAuth/OIDC account linked to a user
+type UserIdentity struct {
+	ID         uint       `gorm:"primaryKey"`
+	Provider   string     `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject    string     `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email      string     `gorm:""`
+	LastUsedAt *time.Time `gorm:""`
+	UserID     uint       `gorm:"not null;index"`
+	User       User       `gorm:"foreignKey:UserID"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
@@ -22,3 +22,13 @@ func GetEnvAsInt(key string, defaultVal int) int {
 
 	return defaultVal
 }
+
+func GetEnvAsBool(key string, defaultVal bool) bool {
+	strVal := GetEnv(key, strconv.FormatBool(defaultVal))
+
+	if val, err := strconv.ParseBool(strVal); err == nil {
+		return val
+	}
+
+	return defaultVal
+}
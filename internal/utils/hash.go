@@ -2,23 +2,67 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"hash/fnv"
 	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
 /*
-Error returned when the stored hash format is invalid
+Errors returned while hashing or parsing stored password hashes
 */
-var ErrInvalidStoredHash = errors.New("invalid stored hash format")
+var (
+	ErrInvalidStoredHash        = errors.New("invalid stored hash format")
+	ErrUnsupportedHashAlgorithm = errors.New("unsupported password hash algorithm")
+)
 
 const (
-	hashVersion = "v1"
+	// legacyHashVersion is the FNV based digest, only kept to verify old hashes
+	legacyHashVersion = "v1"
+	// hashVersion is written for every new hash, followed by the algorithm name
+	hashVersion = "v2"
 	saltBytes   = 16
+
+	HashAlgorithmArgon2id = "argon2id"
+	HashAlgorithmBcrypt   = "bcrypt"
 )
 
+// PasswordHashParams holds the tunable KDF parameters.
+type PasswordHashParams struct {
+	Algorithm         string
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	Argon2KeyLength   uint32
+	BcryptCost        int
+}
+
+// LoadPasswordHashParams reads the KDF parameters from the environment:
+// - PASSWORD_HASH_ALGORITHM: argon2id or bcrypt (default: argon2id)
+// - ARGON2_MEMORY_KB: memory in KiB (default: 65536)
+// - ARGON2_ITERATIONS: number of passes (default: 3)
+// - ARGON2_PARALLELISM: number of lanes (default: 2)
+// - ARGON2_KEY_LENGTH: derived key length in bytes (default: 32)
+// - BCRYPT_COST: bcrypt cost (default: 12)
+func LoadPasswordHashParams() PasswordHashParams {
+	return PasswordHashParams{
+		Algorithm:         GetEnv("PASSWORD_HASH_ALGORITHM", HashAlgorithmArgon2id),
+		Argon2Memory:      uint32(GetEnvAsInt("ARGON2_MEMORY_KB", 64*1024)),
+		Argon2Iterations:  uint32(GetEnvAsInt("ARGON2_ITERATIONS", 3)),
+		Argon2Parallelism: uint8(GetEnvAsInt("ARGON2_PARALLELISM", 2)),
+		Argon2KeyLength:   uint32(GetEnvAsInt("ARGON2_KEY_LENGTH", 32)),
+		BcryptCost:        GetEnvAsInt("BCRYPT_COST", 12),
+	}
+}
+
 func generateRandomBytes(length int) ([]byte, error) {
 	b := make([]byte, length)
 	_, err := rand.Read(b)
@@ -28,32 +72,119 @@ func generateRandomBytes(length int) ([]byte, error) {
 	return b, nil
 }
 
-// HashPassword applies a custom reversible mix using FNV-1a rounds with a secret and random salt.
-// Output format: version:saltBase64:digestBase64
-func HashPassword(plain string) string {
-	secret := GetEnv("HASH_SECRET", "")
-	salt, err := generateRandomBytes(saltBytes)
-	if err != nil {
-		// As a fallback, use empty salt if RNG fails
-		salt = []byte{}
-	}
+// HashPassword hashes the password with the configured KDF.
+// Output formats:
+// - v2:argon2id:m=<memory>,t=<iterations>,p=<parallelism>:saltBase64:keyBase64
+// - v2:bcrypt:<bcrypt hash>
+func HashPassword(plain string) (string, error) {
+	params := LoadPasswordHashParams()
 
-	digest := customDigest(plain, secret, salt)
+	switch params.Algorithm {
+	case HashAlgorithmArgon2id:
+		salt, err := generateRandomBytes(saltBytes)
+		if err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(plain), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, params.Argon2KeyLength)
 
-	return strings.Join([]string{
-		hashVersion,
-		base64.RawURLEncoding.EncodeToString(salt),
-		base64.RawURLEncoding.EncodeToString(digest),
-	}, ":")
+		return strings.Join([]string{
+			hashVersion,
+			HashAlgorithmArgon2id,
+			fmt.Sprintf("m=%d,t=%d,p=%d", params.Argon2Memory, params.Argon2Iterations, params.Argon2Parallelism),
+			base64.RawURLEncoding.EncodeToString(salt),
+			base64.RawURLEncoding.EncodeToString(key),
+		}, ":"), nil
+	case HashAlgorithmBcrypt:
+		hashed, err := bcrypt.GenerateFromPassword([]byte(plain), params.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join([]string{hashVersion, HashAlgorithmBcrypt, string(hashed)}, ":"), nil
+	default:
+		return "", ErrUnsupportedHashAlgorithm
+	}
 }
 
-// VerifyPassword recomputes the digest and compares against stored value.
+// VerifyPassword checks the password against the stored hash.
+// Legacy v1 hashes are still accepted so they can be migrated on login.
 func VerifyPassword(plain string, stored string) bool {
 	parts := strings.Split(stored, ":")
-	if len(parts) != 3 {
+	switch parts[0] {
+	case legacyHashVersion:
+		return verifyLegacyPassword(plain, parts)
+	case hashVersion:
+		if len(parts) < 3 {
+			return false
+		}
+		switch parts[1] {
+		case HashAlgorithmArgon2id:
+			return verifyArgon2idPassword(plain, parts)
+		case HashAlgorithmBcrypt:
+			return bcrypt.CompareHashAndPassword([]byte(strings.Join(parts[2:], ":")), []byte(plain)) == nil
+		}
+	}
+	return false
+}
+
+// NeedsRehash reports whether the stored hash was not produced with the current
+// algorithm and parameters, e.g. a legacy v1 hash or an outdated argon2 cost.
+func NeedsRehash(stored string) bool {
+	params := LoadPasswordHashParams()
+	parts := strings.Split(stored, ":")
+	if len(parts) < 3 || parts[0] != hashVersion || parts[1] != params.Algorithm {
+		return true
+	}
+
+	switch parts[1] {
+	case HashAlgorithmArgon2id:
+		memory, iterations, parallelism, err := parseArgon2Params(parts[2])
+		if err != nil {
+			return true
+		}
+		return memory != params.Argon2Memory ||
+			iterations != params.Argon2Iterations ||
+			parallelism != params.Argon2Parallelism
+	case HashAlgorithmBcrypt:
+		cost, err := bcrypt.Cost([]byte(strings.Join(parts[2:], ":")))
+		if err != nil {
+			return true
+		}
+		return cost != params.BcryptCost
+	}
+	return true
+}
+
+func verifyArgon2idPassword(plain string, parts []string) bool {
+	if len(parts) != 5 {
 		return false
 	}
-	if parts[0] != hashVersion {
+	memory, iterations, parallelism, err := parseArgon2Params(parts[2])
+	if err != nil {
+		return false
+	}
+	salt, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawURLEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+
+	actual := argon2.IDKey([]byte(plain), salt, iterations, memory, parallelism, uint32(len(expected)))
+	return subtle.ConstantTimeCompare(actual, expected) == 1
+}
+
+func parseArgon2Params(encoded string) (memory uint32, iterations uint32, parallelism uint8, err error) {
+	if _, err = fmt.Sscanf(encoded, "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return 0, 0, 0, ErrInvalidStoredHash
+	}
+	return memory, iterations, parallelism, nil
+}
+
+// verifyLegacyPassword recomputes the v1 FNV digest and compares against stored value.
+func verifyLegacyPassword(plain string, parts []string) bool {
+	if len(parts) != 3 {
 		return false
 	}
 	salt, err := base64.RawURLEncoding.DecodeString(parts[1])
@@ -68,16 +199,10 @@ func VerifyPassword(plain string, stored string) bool {
 	secret := GetEnv("HASH_SECRET", "")
 	actual := customDigest(plain, secret, salt)
 
-	if len(actual) != len(expected) {
-		return false
-	}
-	var diff byte
-	for i := range actual {
-		diff |= actual[i] ^ expected[i]
-	}
-	return diff == 0
+	return subtle.ConstantTimeCompare(actual, expected) == 1
 }
 
+// customDigest is the legacy v1 FNV-1a digest. It must not be used for new hashes.
 func customDigest(plain, secret string, salt []byte) []byte {
 	// Build input: salt || secret || plain || salt
 	mixed := make([]byte, 0, len(salt)+len(secret)+len(plain)+len(salt))
@@ -138,6 +263,11 @@ func fnvBlock(seed []byte, counter uint64) []byte {
 // GeneratePasswordResetToken generates a secure random token for password reset.
 // Returns a URL-safe base64 encoded token (32 bytes = 44 characters when encoded).
 func GeneratePasswordResetToken() (string, error) {
+	return GenerateSecureToken()
+}
+
+// GenerateSecureToken generates a secure random URL-safe token (32 bytes = 43 characters when encoded).
+func GenerateSecureToken() (string, error) {
 	bytes, err := generateRandomBytes(32)
 	if err != nil {
 		return "", err
@@ -145,3 +275,27 @@ func GeneratePasswordResetToken() (string, error) {
 	// Use RawURLEncoding for URL-safe tokens (no padding, URL-safe characters)
 	return base64.RawURLEncoding.EncodeToString(bytes), nil
 }
+
+// PersonalAccessTokenPrefix marks personal access tokens so they can be told apart from JWTs
+const PersonalAccessTokenPrefix = "ksp_"
+
+// GeneratePersonalAccessToken generates a secure random token with the personal access token prefix.
+func GeneratePersonalAccessToken() (string, error) {
+	token, err := GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + token, nil
+}
+
+// GenerateRandomBytes returns length bytes from the secure random source.
+func GenerateRandomBytes(length int) ([]byte, error) {
+	return generateRandomBytes(length)
+}
+
+// HashToken returns the SHA-256 hex digest of a high entropy secret such as a recovery code.
+// It must not be used for passwords.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
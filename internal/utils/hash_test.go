@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// useFastHashParams keeps the KDF costs low, the tests hash many passwords
+func useFastHashParams(t *testing.T, algorithm string) {
+	t.Helper()
+
+	t.Setenv("PASSWORD_HASH_ALGORITHM", algorithm)
+	t.Setenv("ARGON2_MEMORY_KB", "1024")
+	t.Setenv("ARGON2_ITERATIONS", "1")
+	t.Setenv("ARGON2_PARALLELISM", "1")
+	t.Setenv("BCRYPT_COST", "4")
+}
+
+func TestHashPassword(t *testing.T) {
+	for _, algorithm := range []string{HashAlgorithmArgon2id, HashAlgorithmBcrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			useFastHashParams(t, algorithm)
+
+			hashed, err := HashPassword("correct horse")
+			if err != nil {
+				t.Fatalf("HashPassword() error = %v", err)
+			}
+			if prefix := hashVersion + ":" + algorithm + ":"; !strings.HasPrefix(hashed, prefix) {
+				t.Errorf("hash = %q, want prefix %q", hashed, prefix)
+			}
+			if !VerifyPassword("correct horse", hashed) {
+				t.Errorf("VerifyPassword() rejected the password")
+			}
+			if VerifyPassword("wrong horse", hashed) {
+				t.Errorf("VerifyPassword() accepted a wrong password")
+			}
+			if NeedsRehash(hashed) {
+				t.Errorf("NeedsRehash() = true for a hash of the current parameters")
+			}
+
+			again, err := HashPassword("correct horse")
+			if err != nil {
+				t.Fatalf("HashPassword() error = %v", err)
+			}
+			if again == hashed {
+				t.Errorf("hashes of the same password are equal, the salt is missing")
+			}
+		})
+	}
+}
+
+func TestHashPasswordUnsupportedAlgorithm(t *testing.T) {
+	t.Setenv("PASSWORD_HASH_ALGORITHM", "md5")
+
+	if _, err := HashPassword("correct horse"); err != ErrUnsupportedHashAlgorithm {
+		t.Errorf("HashPassword() error = %v, want %v", err, ErrUnsupportedHashAlgorithm)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	useFastHashParams(t, HashAlgorithmArgon2id)
+	argon2Hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	useFastHashParams(t, HashAlgorithmBcrypt)
+	bcryptHash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	t.Run("algorithm changed", func(t *testing.T) {
+		useFastHashParams(t, HashAlgorithmArgon2id)
+		if !NeedsRehash(bcryptHash) {
+			t.Errorf("NeedsRehash() = false for a bcrypt hash with argon2id configured")
+		}
+	})
+	t.Run("argon2 cost changed", func(t *testing.T) {
+		useFastHashParams(t, HashAlgorithmArgon2id)
+		t.Setenv("ARGON2_ITERATIONS", "2")
+		if !NeedsRehash(argon2Hash) {
+			t.Errorf("NeedsRehash() = false for an outdated argon2 cost")
+		}
+		// The parameters of the hash still verify it
+		if !VerifyPassword("correct horse", argon2Hash) {
+			t.Errorf("VerifyPassword() rejected a hash of older parameters")
+		}
+	})
+	t.Run("bcrypt cost changed", func(t *testing.T) {
+		useFastHashParams(t, HashAlgorithmBcrypt)
+		t.Setenv("BCRYPT_COST", "5")
+		if !NeedsRehash(bcryptHash) {
+			t.Errorf("NeedsRehash() = false for an outdated bcrypt cost")
+		}
+	})
+	t.Run("legacy hash", func(t *testing.T) {
+		useFastHashParams(t, HashAlgorithmArgon2id)
+		if !NeedsRehash(legacyHash(t, "correct horse")) {
+			t.Errorf("NeedsRehash() = false for a legacy hash")
+		}
+	})
+}
+
+// legacyHash builds a v1 hash like the ones stored before the KDFs were introduced
+func legacyHash(t *testing.T, plain string) string {
+	t.Helper()
+
+	salt := []byte("0123456789abcdef")
+	digest := customDigest(plain, GetEnv("HASH_SECRET", ""), salt)
+	return strings.Join([]string{
+		legacyHashVersion,
+		base64.RawURLEncoding.EncodeToString(salt),
+		base64.RawURLEncoding.EncodeToString(digest),
+	}, ":")
+}
+
+func TestVerifyLegacyPassword(t *testing.T) {
+	t.Setenv("HASH_SECRET", "legacy-secret")
+	stored := legacyHash(t, "correct horse")
+
+	if !VerifyPassword("correct horse", stored) {
+		t.Errorf("VerifyPassword() rejected the legacy hash")
+	}
+	if VerifyPassword("wrong horse", stored) {
+		t.Errorf("VerifyPassword() accepted a wrong password for the legacy hash")
+	}
+
+	t.Setenv("HASH_SECRET", "other-secret")
+	if VerifyPassword("correct horse", stored) {
+		t.Errorf("VerifyPassword() accepted the legacy hash with another secret")
+	}
+}
+
+func TestVerifyPasswordMalformedHash(t *testing.T) {
+	for _, stored := range []string{"", "plain-password", "v2:argon2id", "v2:argon2id:m=x:salt:key", "v2:scrypt:abc", "v1:only"} {
+		if VerifyPassword("plain-password", stored) {
+			t.Errorf("VerifyPassword() accepted the malformed hash %q", stored)
+		}
+	}
+}
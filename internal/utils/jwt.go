@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"encoding/hex"
 	"errors"
+	"slices"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
@@ -22,68 +24,164 @@ var (
 // TokenClaims defines the JWT claims used across the application.
 // It embeds jwt.RegisteredClaims and adds a domain-specific UserID.
 type TokenClaims struct {
-	UserID   string   `json:"uid"`
-	Email    string   `json:"email"`
-	Username string   `json:"username"`
-	RoleID   uint     `json:"role_id"`
-	Claims   []string `json:"claim_ids"`
+	UserID    string   `json:"uid"`
+	Email     string   `json:"email"`
+	Username  string   `json:"username"`
+	RoleID    uint     `json:"role_id"`
+	Claims    []string `json:"claim_ids"`
+	SessionID string   `json:"sid,omitempty"`
+	// Actor is the admin acting as the subject, set only on impersonation tokens (RFC 8693 "act")
+	Actor *ActorClaims `json:"act,omitempty"`
+	// PersonalAccessTokenID is set when the request was authenticated with a personal access token
+	PersonalAccessTokenID uint `json:"-"`
+	// OrganizationID is the active organization of the request, resolved by middleware.ActiveOrganization
+	// on the organization routes only. The organization fields are request context, the token carries
+	// no organization, so a membership change applies to the next request without a new token.
+	OrganizationID uint `json:"-"`
+	// OrganizationRoleID is the role of the user in the active organization
+	OrganizationRoleID uint `json:"-"`
+	// OrganizationClaims are the claims of the user's role in the active organization
+	OrganizationClaims []string `json:"-"`
 	jwt.RegisteredClaims
 }
 
+// GrantedClaims returns the claims of the request, including the ones granted in its active organization
+func (c *TokenClaims) GrantedClaims() []string {
+	if len(c.OrganizationClaims) == 0 {
+		return c.Claims
+	}
+	return append(slices.Clone(c.Claims), c.OrganizationClaims...)
+}
+
+// ActorClaims identifies the user acting on behalf of the token subject
+type ActorClaims struct {
+	UserID   string `json:"sub"`
+	Username string `json:"username"`
+	// ImpersonationID is the audit record of the impersonation
+	ImpersonationID string `json:"imp"`
+}
+
 type RefreshTokenClaim struct {
 	UserID  string `json:"uid"`
 	TokenID string `json:"tokenID"`
 	jwt.RegisteredClaims
 }
 
-// GenerateAccessToken creates a signed JWT token for the provided userID.
-// It uses HMAC-SHA256 and reads configuration from environment variables:
-// - JWT_SECRET: signing key (default: "dev_secret")
+// EmailVerificationClaims binds a verification token to the user and the email it was sent to.
+type EmailVerificationClaims struct {
+	UserID string `json:"uid"`
+	Email  string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// MFAChallengeClaims identifies a login that passed the password check but still needs a second factor.
+type MFAChallengeClaims struct {
+	UserID      string `json:"uid"`
+	Remember    bool   `json:"remember"`
+	DeviceLabel string `json:"device_label,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// OAuthLinkClaims marks an OAuth flow started by a logged in user to link the provider account.
+// State binds the token to the authorization request it was issued for.
+type OAuthLinkClaims struct {
+	UserID   string `json:"uid"`
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	jwt.RegisteredClaims
+}
+
+// AccountUnlockClaims unlocks a locked account. LockedUntil binds the token to a single lockout.
+type AccountUnlockClaims struct {
+	UserID      string `json:"uid"`
+	LockedUntil int64  `json:"locked_until"`
+	jwt.RegisteredClaims
+}
+
+// GenerateAccessToken creates a signed JWT token for the provided userID and returns it with its claims.
+// sessionID is the refresh token family the access token was issued for.
+// Every token gets a unique jti so it can be revoked before it expires.
+// It is signed with the active key of the keyset (see LoadKeySet) and the kid header,
+// or with HMAC-SHA256 when no keys are configured. It reads configuration from environment variables:
+// - JWT_SECRET: HS256 signing key without a keyset (default: "dev_secret")
 // - JWT_EXPIRES_IN_MIN: expiration in minutes (default: 60)
-func GenerateAccessToken(userID string, email string, username string, roleID uint, claimNames []string) (string, error) {
+func GenerateAccessToken(userID string, email string, username string, roleID uint, claimNames []string, sessionID string) (string, *TokenClaims, error) {
+	expiresInMinutes := GetEnvAsInt("JWT_EXPIRES_IN_MIN", 60)
+
+	return signAccessToken(TokenClaims{
+		UserID:    userID,
+		Email:     email,
+		Username:  username,
+		RoleID:    roleID,
+		Claims:    claimNames,
+		SessionID: sessionID,
+	}, time.Duration(expiresInMinutes)*time.Minute)
+}
+
+// GenerateImpersonationToken creates a short lived access token of the subject that records the acting admin.
+// sessionID is the session of the admin, so the token ends with it.
+// It reads JWT_IMPERSONATION_EXPIRES_IN_MIN from the environment (default: 15).
+func GenerateImpersonationToken(userID string, email string, username string, roleID uint, claimNames []string, sessionID string, actor ActorClaims) (string, *TokenClaims, error) {
+	expiresInMinutes := GetEnvAsInt("JWT_IMPERSONATION_EXPIRES_IN_MIN", 15)
+
+	return signAccessToken(TokenClaims{
+		UserID:    userID,
+		Email:     email,
+		Username:  username,
+		RoleID:    roleID,
+		Claims:    claimNames,
+		SessionID: sessionID,
+		Actor:     &actor,
+	}, time.Duration(expiresInMinutes)*time.Minute)
+}
+
+// signAccessToken sets the registered claims and signs the access token with the active key
+func signAccessToken(claims TokenClaims, expiresIn time.Duration) (string, *TokenClaims, error) {
 	secret := GetEnv("JWT_SECRET", "dev_secret")
 	issuer := GetEnv("JWT_ISSUER", "knowstack")
 	audience := GetEnv("JWT_AUDIENCE", "knowstack")
 
 	now := time.Now()
-	expiresInMinutes := GetEnvAsInt("JWT_EXPIRES_IN_MIN", 60)
-	expiresAt := now.Add(time.Duration(expiresInMinutes) * time.Minute)
+	jti, err := GenerateTokenID()
+	if err != nil {
+		return "", nil, err
+	}
 
-	claims := TokenClaims{
-		UserID:   userID,
-		Email:    email,
-		Username: username,
-		RoleID:   roleID,
-		Claims:   claimNames,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    issuer,
-			Audience:  jwt.ClaimStrings{audience},
-			Subject:   userID,
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(expiresAt),
-		},
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Audience:  jwt.ClaimStrings{audience},
+		Subject:   claims.UserID,
+		ID:        jti,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(secret))
+	ks, err := CurrentKeySet()
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return signed, nil
+
+	signed, err := ks.sign(claims, secret)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, &claims, nil
 }
 
 // VerifyAccessToken validates the token signature and expiration and returns parsed claims.
-// It reads JWT_SECRET from the environment (default: "dev_secret").
+// The verification key is selected by the kid header from the keyset, so tokens signed
+// with a retiring key stay valid until they expire.
+// Without a keyset it reads JWT_SECRET from the environment (default: "dev_secret").
 func VerifyAccessToken(tokenString string) (*TokenClaims, error) {
 	secret := GetEnv("JWT_SECRET", "dev_secret")
 	issuer := GetEnv("JWT_ISSUER", "knowstack")
 	audience := GetEnv("JWT_AUDIENCE", "knowstack")
-	parsedToken, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
+	ks, err := CurrentKeySet()
+	if err != nil {
+		return nil, err
+	}
+
+	parsedToken, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, ks.keyFunc(secret))
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
@@ -101,6 +199,11 @@ func VerifyAccessToken(tokenString string) (*TokenClaims, error) {
 		return nil, ErrInvalidSubject
 	}
 
+	// Tokens without a jti can't be revoked
+	if claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+
 	aud := claims.Audience
 	if len(aud) == 0 {
 		return nil, ErrInvalidAudience
@@ -119,8 +222,8 @@ func VerifyAccessToken(tokenString string) (*TokenClaims, error) {
 	return claims, nil
 }
 
-// Generates a refresh token with longer expire times
-func GenerateRefreshToken(userID, tokenID string, remember bool) (string, error) {
+// Generates a refresh token with longer expire times and returns it with its expiration time
+func GenerateRefreshToken(userID, tokenID string, remember bool) (string, time.Time, error) {
 	secret := GetEnv("JWT_REFRESH_SECRET", "dev_refresh_secret")
 	audiance := GetEnv("JWT_AUDIENCE", "knowstack")
 	issuer := GetEnv("JWT_ISSUER", "knowstack")
@@ -146,12 +249,16 @@ func GenerateRefreshToken(userID, tokenID string, remember bool) (string, error)
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expireAt, nil
 }
 
 // ValidateRefreshToken validates the refresh token signature and expirations and returns parsed claims
 func ValidateRefreshToken(token string) (*RefreshTokenClaim, error) {
-	secret := GetEnv("JWT_SECRET", "dev_seecret")
+	secret := GetEnv("JWT_REFRESH_SECRET", "dev_refresh_secret")
 	issuer := GetEnv("JWT_ISSUER", "knowstack")
 	audience := GetEnv("JWT_AUDIENCE", "knowstack")
 
@@ -162,6 +269,9 @@ func ValidateRefreshToken(token string) (*RefreshTokenClaim, error) {
 		return []byte(secret), nil
 	})
 	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
 		return nil, ErrInvalidToken
 	}
 
@@ -196,6 +306,271 @@ func ValidateRefreshToken(token string) (*RefreshTokenClaim, error) {
 	return claims, nil
 }
 
+// GenerateEmailVerificationToken creates a signed, expiring token proving ownership of the email.
+// It reads configuration from environment variables:
+// - JWT_EMAIL_VERIFICATION_SECRET: signing key (default: "dev_email_verification_secret")
+// - EMAIL_VERIFICATION_EXPIRES_IN_HOURS: expiration in hours (default: 24)
+func GenerateEmailVerificationToken(userID, email string) (string, error) {
+	secret := GetEnv("JWT_EMAIL_VERIFICATION_SECRET", "dev_email_verification_secret")
+	issuer := GetEnv("JWT_ISSUER", "knowstack")
+
+	now := time.Now()
+	expiresInHours := GetEnvAsInt("EMAIL_VERIFICATION_EXPIRES_IN_HOURS", 24)
+
+	claims := EmailVerificationClaims{
+		UserID: userID,
+		Email:  email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(expiresInHours) * time.Hour)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// ValidateEmailVerificationToken validates the verification token signature and expiration and returns parsed claims
+func ValidateEmailVerificationToken(token string) (*EmailVerificationClaims, error) {
+	secret := GetEnv("JWT_EMAIL_VERIFICATION_SECRET", "dev_email_verification_secret")
+	issuer := GetEnv("JWT_ISSUER", "knowstack")
+
+	parsedToken, err := jwt.ParseWithClaims(token, &EmailVerificationClaims{}, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidSignature
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := parsedToken.Claims.(*EmailVerificationClaims)
+	if !ok || !parsedToken.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Issuer != issuer {
+		return nil, ErrInvalidIssuer
+	}
+
+	if claims.Subject != claims.UserID {
+		return nil, ErrInvalidSubject
+	}
+
+	return claims, nil
+}
+
+// GenerateMFAChallengeToken creates a short-lived token exchanged for real tokens with a second factor.
+// The returned claims carry the jti the attempts of the challenge are tracked by.
+// It reads configuration from environment variables:
+// - JWT_MFA_SECRET: signing key (default: "dev_mfa_secret")
+// - MFA_CHALLENGE_EXPIRES_IN_MIN: expiration in minutes (default: 5)
+func GenerateMFAChallengeToken(userID string, remember bool, deviceLabel string) (string, *MFAChallengeClaims, error) {
+	secret := GetEnv("JWT_MFA_SECRET", "dev_mfa_secret")
+	issuer := GetEnv("JWT_ISSUER", "knowstack")
+
+	tokenID, err := GenerateTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	expiresInMinutes := GetEnvAsInt("MFA_CHALLENGE_EXPIRES_IN_MIN", 5)
+
+	claims := MFAChallengeClaims{
+		UserID:      userID,
+		Remember:    remember,
+		DeviceLabel: deviceLabel,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    issuer,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(expiresInMinutes) * time.Minute)),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, &claims, nil
+}
+
+// ValidateMFAChallengeToken validates the challenge token signature and expiration and returns parsed claims
+func ValidateMFAChallengeToken(token string) (*MFAChallengeClaims, error) {
+	secret := GetEnv("JWT_MFA_SECRET", "dev_mfa_secret")
+	issuer := GetEnv("JWT_ISSUER", "knowstack")
+
+	parsedToken, err := jwt.ParseWithClaims(token, &MFAChallengeClaims{}, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidSignature
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := parsedToken.Claims.(*MFAChallengeClaims)
+	if !ok || !parsedToken.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Issuer != issuer {
+		return nil, ErrInvalidIssuer
+	}
+
+	if claims.Subject != claims.UserID {
+		return nil, ErrInvalidSubject
+	}
+
+	return claims, nil
+}
+
+// GenerateOAuthLinkToken creates a short-lived token that turns the OAuth callback into a link request.
+// It reads configuration from environment variables:
+// - JWT_OAUTH_LINK_SECRET: signing key (default: "dev_oauth_link_secret")
+// - OAUTH_LINK_EXPIRES_IN_MIN: expiration in minutes (default: 10)
+func GenerateOAuthLinkToken(userID, provider, state string) (string, error) {
+	secret := GetEnv("JWT_OAUTH_LINK_SECRET", "dev_oauth_link_secret")
+	issuer := GetEnv("JWT_ISSUER", "knowstack")
+
+	now := time.Now()
+	expiresInMinutes := GetEnvAsInt("OAUTH_LINK_EXPIRES_IN_MIN", 10)
+
+	claims := OAuthLinkClaims{
+		UserID:   userID,
+		Provider: provider,
+		State:    state,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(expiresInMinutes) * time.Minute)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// ValidateOAuthLinkToken validates the link token signature and expiration and returns parsed claims
+func ValidateOAuthLinkToken(token string) (*OAuthLinkClaims, error) {
+	secret := GetEnv("JWT_OAUTH_LINK_SECRET", "dev_oauth_link_secret")
+	issuer := GetEnv("JWT_ISSUER", "knowstack")
+
+	parsedToken, err := jwt.ParseWithClaims(token, &OAuthLinkClaims{}, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidSignature
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := parsedToken.Claims.(*OAuthLinkClaims)
+	if !ok || !parsedToken.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Issuer != issuer {
+		return nil, ErrInvalidIssuer
+	}
+
+	if claims.Subject != claims.UserID {
+		return nil, ErrInvalidSubject
+	}
+
+	return claims, nil
+}
+
+// GenerateAccountUnlockToken creates the token of the unlock email; it expires with the lockout.
+// It reads JWT_UNLOCK_SECRET from the environment (default: "dev_unlock_secret").
+func GenerateAccountUnlockToken(userID string, lockedUntil time.Time) (string, error) {
+	secret := GetEnv("JWT_UNLOCK_SECRET", "dev_unlock_secret")
+	issuer := GetEnv("JWT_ISSUER", "knowstack")
+
+	claims := AccountUnlockClaims{
+		UserID:      userID,
+		LockedUntil: lockedUntil.Unix(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(lockedUntil),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// ValidateAccountUnlockToken validates the unlock token signature and expiration and returns parsed claims
+func ValidateAccountUnlockToken(token string) (*AccountUnlockClaims, error) {
+	secret := GetEnv("JWT_UNLOCK_SECRET", "dev_unlock_secret")
+	issuer := GetEnv("JWT_ISSUER", "knowstack")
+
+	parsedToken, err := jwt.ParseWithClaims(token, &AccountUnlockClaims{}, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidSignature
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := parsedToken.Claims.(*AccountUnlockClaims)
+	if !ok || !parsedToken.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Issuer != issuer {
+		return nil, ErrInvalidIssuer
+	}
+
+	if claims.Subject != claims.UserID {
+		return nil, ErrInvalidSubject
+	}
+
+	return claims, nil
+}
+
+// GenerateTokenFamilyID returns a random identifier shared by a chain of rotated refresh tokens.
+func GenerateTokenFamilyID() (string, error) {
+	b, err := generateRandomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateTokenID returns a random identifier for the jti claim.
+func GenerateTokenID() (string, error) {
+	b, err := generateRandomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 // ExtractBearerToken returns the token part from a typical Authorization header value.
 // If the header is not in the expected format, it returns an empty string.
 func ExtractBearerToken(authorizationHeader string) string {
@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownSigningKey   = errors.New("unknown signing key")
+	ErrUnsupportedKey      = errors.New("unsupported signing key")
+	ErrActiveKeyNotPrivate = errors.New("active signing key has no private key")
+)
+
+// SigningKey is a key of the access token keyset. Keys without a private key only verify tokens.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+}
+
+// SigningMethod returns the JWT signing method of the key algorithm
+func (k *SigningKey) SigningMethod() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+/*
+KeySet holds the keys access tokens are signed and verified with.
+Active signs new tokens; the other keys are retiring (or not yet active)
+keys that still verify tokens and are published in the JWKS.
+An empty keyset falls back to HS256 with JWT_SECRET.
+*/
+type KeySet struct {
+	Active *SigningKey
+	Keys   map[string]*SigningKey
+}
+
+var (
+	keySetMu       sync.RWMutex
+	cachedKeySet   *KeySet
+	keySetLoadedAt time.Time
+)
+
+/*
+Get the access token keyset. The key files of JWT_SIGNING_KEYS are read again every
+JWT_KEYSET_RELOAD_INTERVAL_MIN minutes (default: 5), the environment itself is fixed
+for the process, so the kids, their paths and the active kid only change on a restart.
+A key is rotated without a restart by swapping the file behind its path: the new key
+is published under the kid of the file, and the tokens the replaced key signed no longer
+verify, so only the file of a key whose tokens expired is swapped. A failed reload keeps
+the previous keyset.
+*/
+func CurrentKeySet() (*KeySet, error) {
+	interval := time.Duration(GetEnvAsInt("JWT_KEYSET_RELOAD_INTERVAL_MIN", 5)) * time.Minute
+
+	keySetMu.RLock()
+	ks, loadedAt := cachedKeySet, keySetLoadedAt
+	keySetMu.RUnlock()
+	if ks != nil && time.Since(loadedAt) < interval {
+		return ks, nil
+	}
+
+	keySetMu.Lock()
+	defer keySetMu.Unlock()
+	if cachedKeySet != nil && time.Since(keySetLoadedAt) < interval {
+		return cachedKeySet, nil
+	}
+
+	loaded, err := LoadKeySet()
+	if err != nil {
+		if cachedKeySet != nil {
+			LogErrorWithErr("Failed to reload the signing keyset, keeping the previous one", err)
+			keySetLoadedAt = time.Now()
+			return cachedKeySet, nil
+		}
+		return nil, err
+	}
+
+	cachedKeySet = loaded
+	keySetLoadedAt = time.Now()
+	return cachedKeySet, nil
+}
+
+// LoadKeySet loads the access token keyset from environment variables:
+// - JWT_SIGNING_KEYS: comma separated "kid:path" entries of PEM files. Private keys
+// (PKCS#8, PKCS#1 or SEC1) can sign, public keys (PKIX) only verify.
+// - JWT_ACTIVE_KEY_ID: kid of the key new tokens are signed with (default: the first entry)
+// The algorithm follows from the key: RSA uses RS256, ECDSA ES256/ES384/ES512 by curve and Ed25519 EdDSA.
+func LoadKeySet() (*KeySet, error) {
+	ks := &KeySet{Keys: make(map[string]*SigningKey)}
+
+	entries := strings.Split(GetEnv("JWT_SIGNING_KEYS", ""), ",")
+	firstID := ""
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, path, ok := strings.Cut(entry, ":")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("invalid JWT_SIGNING_KEYS entry %q", entry)
+		}
+		if _, exists := ks.Keys[kid]; exists {
+			return nil, fmt.Errorf("duplicate signing key id %q", kid)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read signing key %s: %w", kid, err)
+		}
+		key, err := ParseSigningKeyPEM(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("parse signing key %s: %w", kid, err)
+		}
+		ks.Keys[kid] = key
+		if firstID == "" {
+			firstID = kid
+		}
+	}
+
+	if len(ks.Keys) == 0 {
+		return ks, nil
+	}
+
+	activeID := GetEnv("JWT_ACTIVE_KEY_ID", firstID)
+	active, ok := ks.Keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q: %w", activeID, ErrUnknownSigningKey)
+	}
+	if active.Private == nil {
+		return nil, ErrActiveKeyNotPrivate
+	}
+	ks.Active = active
+
+	return ks, nil
+}
+
+// ParseSigningKeyPEM parses a PEM encoded private or public key into a signing key
+func ParseSigningKeyPEM(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrUnsupportedKey
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: kid}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.Private = signer
+		key.Public = signer.Public()
+	} else {
+		key.Public = parsed
+	}
+
+	key.Algorithm, err = keyAlgorithm(key.Public)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func keyAlgorithm(public crypto.PublicKey) (string, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return "", ErrUnsupportedKey
+		}
+		return "RS256", nil
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			return "ES256", nil
+		case elliptic.P384():
+			return "ES384", nil
+		case elliptic.P521():
+			return "ES512", nil
+		}
+	case ed25519.PublicKey:
+		return "EdDSA", nil
+	}
+	return "", ErrUnsupportedKey
+}
+
+// JWKS returns the public keys of the keyset for the /.well-known/jwks.json endpoint
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(ks.Keys))}
+	for _, key := range ks.Keys {
+		jwk, err := NewJWK(key.ID, key.Algorithm, key.Public)
+		if err != nil {
+			LogErrorWithErr("Failed to convert signing key to JWK", err, "kid", key.ID)
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// sign signs the claims with the active key, or HS256 with the secret when the keyset is empty
+func (ks *KeySet) sign(claims jwt.Claims, secret string) (string, error) {
+	if ks.Active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	}
+	token := jwt.NewWithClaims(ks.Active.SigningMethod(), claims)
+	token.Header["kid"] = ks.Active.ID
+	return token.SignedString(ks.Active.Private)
+}
+
+/*
+keyFunc resolves the verification key by the kid header.
+HS256 tokens are accepted while the keyset is empty, or during a migration to
+asymmetric keys when JWT_ACCEPT_LEGACY_HS256 is enabled.
+*/
+func (ks *KeySet) keyFunc(secret string) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			if len(ks.Keys) == 0 || GetEnvAsBool("JWT_ACCEPT_LEGACY_HS256", false) {
+				return []byte(secret), nil
+			}
+			return nil, ErrInvalidSignature
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.Keys[kid]
+		if !ok {
+			return nil, ErrUnknownSigningKey
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, ErrInvalidSignature
+		}
+		return key.Public, nil
+	}
+}
@@ -0,0 +1,305 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// writeKeyPEM writes the key as a PEM file into the directory and returns its path
+func writeKeyPEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name+".pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	return path
+}
+
+func newRSAKeyPEM(t *testing.T, dir, name string) (string, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal rsa key: %v", err)
+	}
+	return writeKeyPEM(t, dir, name, "PRIVATE KEY", der), key
+}
+
+// useKeySetEnv configures the keyset and drops the cached one, so CurrentKeySet loads it
+func useKeySetEnv(t *testing.T, keys, activeID string) {
+	t.Helper()
+
+	t.Setenv("JWT_SIGNING_KEYS", keys)
+	t.Setenv("JWT_ACTIVE_KEY_ID", activeID)
+	resetKeySet := func() {
+		keySetMu.Lock()
+		cachedKeySet, keySetLoadedAt = nil, time.Time{}
+		keySetMu.Unlock()
+	}
+	resetKeySet()
+	t.Cleanup(resetKeySet)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaPath, _ := newRSAKeyPEM(t, dir, "rsa")
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ec key: %v", err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("marshal ec key: %v", err)
+	}
+	ecPath := writeKeyPEM(t, dir, "ec", "EC PRIVATE KEY", ecDER)
+
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+	edDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	if err != nil {
+		t.Fatalf("marshal ed25519 key: %v", err)
+	}
+	edPath := writeKeyPEM(t, dir, "ed", "PUBLIC KEY", edDER)
+
+	useKeySetEnv(t, "rsa-1:"+rsaPath+", ec-1:"+ecPath+",ed-1:"+edPath, "ec-1")
+
+	ks, err := LoadKeySet()
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+	if ks.Active == nil || ks.Active.ID != "ec-1" {
+		t.Fatalf("active key = %+v, want ec-1", ks.Active)
+	}
+	for kid, want := range map[string]string{"rsa-1": "RS256", "ec-1": "ES384", "ed-1": "EdDSA"} {
+		key, ok := ks.Keys[kid]
+		if !ok {
+			t.Errorf("key %s missing", kid)
+			continue
+		}
+		if key.Algorithm != want {
+			t.Errorf("key %s algorithm = %s, want %s", kid, key.Algorithm, want)
+		}
+	}
+	if ks.Keys["ed-1"].Private != nil {
+		t.Errorf("public key ed-1 can sign")
+	}
+
+	// Without JWT_ACTIVE_KEY_ID the first entry signs
+	os.Unsetenv("JWT_ACTIVE_KEY_ID")
+	ks, err = LoadKeySet()
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+	if ks.Active.ID != "rsa-1" {
+		t.Errorf("default active key = %s, want rsa-1", ks.Active.ID)
+	}
+}
+
+func TestLoadKeySetRejected(t *testing.T) {
+	dir := t.TempDir()
+	rsaPath, _ := newRSAKeyPEM(t, dir, "rsa")
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+	edDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	if err != nil {
+		t.Fatalf("marshal ed25519 key: %v", err)
+	}
+	edPath := writeKeyPEM(t, dir, "ed", "PUBLIC KEY", edDER)
+
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	smallPath := writeKeyPEM(t, dir, "small", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(smallKey))
+
+	tests := []struct {
+		name     string
+		keys     string
+		activeID string
+		wantErr  error
+	}{
+		{"entry without path", "rsa-1", "", nil},
+		{"duplicate kid", "rsa-1:" + rsaPath + ",rsa-1:" + rsaPath, "", nil},
+		{"missing file", "rsa-1:" + filepath.Join(dir, "missing.pem"), "", nil},
+		{"unknown active kid", "rsa-1:" + rsaPath, "other", ErrUnknownSigningKey},
+		{"public active key", "ed-1:" + edPath, "ed-1", ErrActiveKeyNotPrivate},
+		{"short rsa key", "small:" + smallPath, "", ErrUnsupportedKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useKeySetEnv(t, tt.keys, tt.activeID)
+			if tt.activeID == "" {
+				os.Unsetenv("JWT_ACTIVE_KEY_ID")
+			}
+
+			_, err := LoadKeySet()
+			if err == nil {
+				t.Fatalf("LoadKeySet() error = nil, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoadKeySet() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeySetSelectsKeyByKid(t *testing.T) {
+	dir := t.TempDir()
+	activePath, _ := newRSAKeyPEM(t, dir, "active")
+	retiringPath, retiringKey := newRSAKeyPEM(t, dir, "retiring")
+	useKeySetEnv(t, "active:"+activePath+",retiring:"+retiringPath, "active")
+
+	ks, err := LoadKeySet()
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+	claims := jwt.RegisteredClaims{Subject: "1"}
+
+	signed, err := ks.sign(claims, "secret")
+	if err != nil {
+		t.Fatalf("sign() error = %v", err)
+	}
+	token, err := jwt.Parse(signed, ks.keyFunc("secret"))
+	if err != nil {
+		t.Fatalf("parse token of the active key: %v", err)
+	}
+	if kid := token.Header["kid"]; kid != "active" {
+		t.Errorf("kid = %v, want active", kid)
+	}
+
+	signWith := func(kid string, key crypto.Signer) string {
+		t.Helper()
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return signed
+	}
+
+	// A retiring key still verifies the tokens it signed
+	if _, err := jwt.Parse(signWith("retiring", retiringKey), ks.keyFunc("secret")); err != nil {
+		t.Errorf("parse token of the retiring key: %v", err)
+	}
+	// The kid picks the key, a token claiming another kid doesn't verify
+	if _, err := jwt.Parse(signWith("active", retiringKey), ks.keyFunc("secret")); err == nil {
+		t.Errorf("token signed by another key than its kid verified")
+	}
+	if _, err := jwt.Parse(signWith("unknown", retiringKey), ks.keyFunc("secret")); !errors.Is(err, ErrUnknownSigningKey) {
+		t.Errorf("parse token of an unknown kid error = %v, want %v", err, ErrUnknownSigningKey)
+	}
+
+	hs256, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("sign hs256 token: %v", err)
+	}
+	if _, err := jwt.Parse(hs256, ks.keyFunc("secret")); err == nil {
+		t.Errorf("HS256 token verified with an asymmetric keyset")
+	}
+	t.Setenv("JWT_ACCEPT_LEGACY_HS256", "true")
+	if _, err := jwt.Parse(hs256, ks.keyFunc("secret")); err != nil {
+		t.Errorf("parse legacy HS256 token: %v", err)
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	dir := t.TempDir()
+	bPath, bKey := newRSAKeyPEM(t, dir, "b")
+	aPath, aKey := newRSAKeyPEM(t, dir, "a")
+	useKeySetEnv(t, "b:"+bPath+",a:"+aPath, "b")
+
+	ks, err := LoadKeySet()
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+	set := ks.JWKS()
+
+	if len(set.Keys) != 2 || set.Keys[0].Kid != "a" || set.Keys[1].Kid != "b" {
+		t.Fatalf("JWKS() = %+v, want the keys a and b sorted by kid", set.Keys)
+	}
+	for i, want := range []*rsa.PrivateKey{aKey, bKey} {
+		jwk := set.Keys[i]
+		if jwk.Kty != "RSA" || jwk.Alg != "RS256" {
+			t.Errorf("key %s kty = %s, alg = %s, want RSA and RS256", jwk.Kid, jwk.Kty, jwk.Alg)
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("key %s PublicKey() error = %v", jwk.Kid, err)
+		}
+		if !want.PublicKey.Equal(public) {
+			t.Errorf("key %s doesn't match the public key of its file", jwk.Kid)
+		}
+	}
+}
+
+func TestCurrentKeySetReloadsKeyFiles(t *testing.T) {
+	dir := t.TempDir()
+	activePath, _ := newRSAKeyPEM(t, dir, "active")
+	nextPath, _ := newRSAKeyPEM(t, dir, "next")
+	useKeySetEnv(t, "active:"+activePath+",next:"+nextPath, "active")
+	t.Setenv("JWT_KEYSET_RELOAD_INTERVAL_MIN", "0")
+
+	before, err := CurrentKeySet()
+	if err != nil {
+		t.Fatalf("CurrentKeySet() error = %v", err)
+	}
+
+	// The file of the next key is swapped, its kid now publishes the new key
+	_, swapped := newRSAKeyPEM(t, dir, "swapped")
+	if err := os.Rename(filepath.Join(dir, "swapped.pem"), nextPath); err != nil {
+		t.Fatalf("swap key file: %v", err)
+	}
+
+	after, err := CurrentKeySet()
+	if err != nil {
+		t.Fatalf("CurrentKeySet() error = %v", err)
+	}
+	if before.Keys["next"].Public.(*rsa.PublicKey).Equal(after.Keys["next"].Public) {
+		t.Errorf("reload kept the replaced key")
+	}
+	if !swapped.PublicKey.Equal(after.Keys["next"].Public) {
+		t.Errorf("reload didn't load the swapped key file")
+	}
+
+	// A broken file keeps the previous keyset
+	if err := os.WriteFile(nextPath, []byte("not a key"), 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	kept, err := CurrentKeySet()
+	if err != nil {
+		t.Fatalf("CurrentKeySet() error = %v", err)
+	}
+	if kept != after {
+		t.Errorf("failed reload replaced the keyset")
+	}
+	if kept.Active.ID != "active" {
+		t.Errorf("active key = %s, want active", kept.Active.ID)
+	}
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits      = 6
+	totpPeriod      = 30
+	totpSecretBytes = 20
+	// totpSkew is the number of periods accepted before and after the current one
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random base32 encoded RFC 6238 secret.
+func GenerateTOTPSecret() (string, error) {
+	b, err := generateRandomBytes(totpSecretBytes)
+	if err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI builds the otpauth:// URI authenticator apps scan to enroll.
+// The issuer is read from TOTP_ISSUER (default: "Knowstack").
+func TOTPProvisioningURI(secret, accountName string) string {
+	issuer := GetEnv("TOTP_ISSUER", "Knowstack")
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprintf("%d", totpDigits))
+	query.Set("period", fmt.Sprintf("%d", totpPeriod))
+
+	label := url.PathEscape(issuer + ":" + accountName)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// ValidateTOTPCode checks the code against the secret at the given time, allowing a small clock skew.
+// It returns the matched time step so callers can reject codes that were already used.
+func ValidateTOTPCode(secret, code string, at time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := at.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode computes the RFC 4226 HOTP value for the time step
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// GenerateRecoveryCode returns a random single-use recovery code such as "k3j9d-a8x2q".
+func GenerateRecoveryCode() (string, error) {
+	b, err := generateRandomBytes(7)
+	if err != nil {
+		return "", err
+	}
+	code := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// totpSecretPrefix marks an encrypted secret, secrets stored before encryption have no prefix
+const totpSecretPrefix = "enc:v1:"
+
+// EncryptTOTPSecret encrypts the secret with AES-GCM for storage.
+// The key is derived from TOTP_ENCRYPTION_KEY (default: "dev_totp_encryption_key").
+func EncryptTOTPSecret(secret string) (string, error) {
+	gcm, err := totpCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := generateRandomBytes(gcm.NonceSize())
+	if err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return totpSecretPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptTOTPSecret returns the secret stored by EncryptTOTPSecret.
+// Secrets stored before encryption are returned unchanged.
+func DecryptTOTPSecret(stored string) (string, error) {
+	if !IsTOTPSecretEncrypted(stored) {
+		return stored, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(stored, totpSecretPrefix))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := totpCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted totp secret too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// IsTOTPSecretEncrypted reports whether the stored secret was encrypted by EncryptTOTPSecret
+func IsTOTPSecretEncrypted(stored string) bool {
+	return strings.HasPrefix(stored, totpSecretPrefix)
+}
+
+func totpCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(GetEnv("TOTP_ENCRYPTION_KEY", "dev_totp_encryption_key")))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
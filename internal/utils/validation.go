@@ -18,38 +18,73 @@ type FieldErrorMessages map[string]map[string]string
 // Returns true if binding/validation succeeded; false if an error response was written.
 func BindJSONAndValidate(c *gin.Context, dst any, messages FieldErrorMessages) bool {
 	if err := c.ShouldBindJSON(dst); err != nil {
-		var verrs validator.ValidationErrors
-		msg := err.Error()
-		key := "request"
-		in := "body"
-		if errors.As(err, &verrs) && len(verrs) > 0 {
-			fe := verrs[0]
-			key = fe.Field()
-			if fieldMsgs, ok := messages[key]; ok {
-				if m, found := fieldMsgs[fe.Tag()]; found {
-					msg = m
-				} else {
-					msg = defaultReadableMessage(fe)
-				}
+		writeBindError(c, err, "body", messages)
+		return false
+	}
+	return true
+}
+
+// BindQueryAndValidate binds the query string into dst like BindJSONAndValidate.
+func BindQueryAndValidate(c *gin.Context, dst any, messages FieldErrorMessages) bool {
+	if err := c.ShouldBindQuery(dst); err != nil {
+		writeBindError(c, err, "query", messages)
+		return false
+	}
+	return true
+}
+
+func writeBindError(c *gin.Context, err error, in string, messages FieldErrorMessages) {
+	var verrs validator.ValidationErrors
+	msg := err.Error()
+	key := "request"
+	if errors.As(err, &verrs) && len(verrs) > 0 {
+		fe := verrs[0]
+		key = fe.Field()
+		if fieldMsgs, ok := messages[key]; ok {
+			if m, found := fieldMsgs[fe.Tag()]; found {
+				msg = m
 			} else {
 				msg = defaultReadableMessage(fe)
 			}
+		} else {
+			msg = defaultReadableMessage(fe)
 		}
+	}
 
-		valErr := httperrors.NewHTTPValidationError(
-			http.StatusBadRequest,
-			"validation_error",
-			"Validation error",
-			httperrors.ValidationErrors{
-				Error: msg,
-				Key:   key,
-				In:    in,
-			},
-		)
-		valErr.Write(c)
-		return false
+	valErr := httperrors.NewHTTPValidationError(
+		http.StatusBadRequest,
+		"validation_error",
+		"Validation error",
+		httperrors.ValidationErrors{
+			Error: msg,
+			Key:   key,
+			In:    in,
+		},
+	)
+	valErr.Write(c)
+}
+
+// WriteFieldError writes a validation error of a single field in the format of BindJSONAndValidate.
+// It is used for rules checked after binding, e.g. by the services.
+func WriteFieldError(c *gin.Context, field, tag string, messages FieldErrorMessages) {
+	msg := field + " is invalid"
+	if fieldMsgs, ok := messages[field]; ok {
+		if m, found := fieldMsgs[tag]; found {
+			msg = m
+		}
 	}
-	return true
+
+	valErr := httperrors.NewHTTPValidationError(
+		http.StatusBadRequest,
+		"validation_error",
+		"Validation error",
+		httperrors.ValidationErrors{
+			Error: msg,
+			Key:   field,
+			In:    "body",
+		},
+	)
+	valErr.Write(c)
 }
 
 func defaultReadableMessage(fe validator.FieldError) string {